@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buck3000/wiz/internal/agent"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/spf13/cobra"
+)
+
+const agentSmokePrompt = "This is a connectivity test from wiz. Reply with the single word OK and do not modify any files."
+
+var agentsCmd = &cobra.Command{
+	Use:   "agents",
+	Short: "List, inspect and test agent definitions",
+}
+
+type agentJSON struct {
+	Name               string            `json:"name"`
+	Source             string            `json:"source"`
+	Installed          bool              `json:"installed"`
+	Error              string            `json:"error,omitempty"`
+	Command            string            `json:"command"`
+	Args               []string          `json:"args,omitempty"`
+	PromptMode         string            `json:"prompt_mode,omitempty"`
+	HeadlessArgs       []string          `json:"headless_args,omitempty"`
+	HeadlessPromptMode string            `json:"headless_prompt_mode,omitempty"`
+	Env                map[string]string `json:"env,omitempty"`
+	WorkDir            string            `json:"workdir,omitempty"`
+}
+
+func agentSource(info agent.Info) string {
+	if info.Custom {
+		return "custom"
+	}
+	return "builtin"
+}
+
+var agentsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List built-in and custom agents",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+
+		infos := agent.All(repo)
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if asJSON {
+			out := make([]agentJSON, 0, len(infos))
+			for _, info := range infos {
+				a := info.Agent
+				j := agentJSON{
+					Name:               a.Name,
+					Source:             agentSource(info),
+					Command:            a.Command,
+					Args:               a.Args,
+					PromptMode:         a.PromptMode,
+					HeadlessArgs:       a.HeadlessArgs,
+					HeadlessPromptMode: a.HeadlessPromptMode,
+					Env:                a.Env,
+					WorkDir:            a.WorkDir,
+				}
+				if err := a.Validate(); err != nil {
+					j.Error = err.Error()
+				} else {
+					j.Installed = true
+				}
+				out = append(out, j)
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		}
+
+		for _, info := range infos {
+			a := info.Agent
+			mark := "\033[32m\u2713\033[0m"
+			if err := a.Validate(); err != nil {
+				mark = "\033[31m\u2717\033[0m"
+			}
+			mode := a.PromptMode
+			if mode == "" {
+				mode = agent.PromptArg
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), " %s %-14s %-8s %-24s prompt: %s\n",
+				mark, a.Name, agentSource(info), a.Command, mode)
+		}
+		return nil
+	},
+}
+
+var agentsShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show the resolved command line for an agent",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+
+		a, err := agent.Lookup(repo, args[0])
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "\U0001f9d9 Agent: %s\n", a.Name)
+		fmt.Fprintf(out, "   Interactive: %s\n", a.BuildCommand("<prompt>"))
+		fmt.Fprintf(out, "   Headless:    %s\n", a.BuildHeadlessCommand("<prompt>"))
+		if a.WorkDir != "" {
+			fmt.Fprintf(out, "   Workdir:     %s\n", a.WorkDir)
+		}
+		for _, kv := range a.Environ() {
+			fmt.Fprintf(out, "   Env:         %s\n", kv)
+		}
+		if err := a.Validate(); err != nil {
+			fmt.Fprintf(out, "   Installed:   no (%v)\n", err)
+			return nil
+		}
+		version, err := a.Version(cmd.Context())
+		if err != nil {
+			version = "unknown"
+		}
+		fmt.Fprintf(out, "   Installed:   yes (%s)\n", version)
+		return nil
+	},
+}
+
+var agentsTestCmd = &cobra.Command{
+	Use:   "test <name>",
+	Short: "Run an agent headlessly in a throwaway context with a smoke prompt",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		prompt, _ := cmd.Flags().GetString("prompt")
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		if !repo.HasCommits(cmd.Context()) {
+			return fmt.Errorf("repository has no commits; create an initial commit before using wiz")
+		}
+
+		ag, err := agent.Resolve(repo, args[0])
+		if err != nil {
+			return err
+		}
+
+		// The throwaway context is provisioned directly and never added to the
+		// store, so it doesn't show up in `wiz list` or count against limits.
+		name := fmt.Sprintf("wiz-agent-test-%s-%d", wizctx.SafeDirName(ag.Name), time.Now().Unix())
+		prov := wizctx.NewProvisioner(wizctx.StrategyWorktree, repo)
+		path, err := prov.Create(cmd.Context(), wizctx.CreateOpts{
+			Name:   name,
+			Branch: name,
+			Repo:   repo,
+		})
+		if err != nil {
+			return err
+		}
+		defer func() {
+			bg := gocontext.Background()
+			prov.Destroy(bg, path, true)
+			repo.Run(bg, "branch", "-D", name)
+		}()
+
+		runCtx, cancel := gocontext.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
+		c, cleanup, err := ag.Cmd(runCtx, path, prompt, true)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Testing %s: %s\n", ag.Name, ag.BuildHeadlessCommand(prompt))
+		start := time.Now()
+		out, err := c.CombinedOutput()
+		elapsed := time.Since(start).Round(time.Millisecond)
+		output := strings.TrimSpace(string(out))
+		if runCtx.Err() == gocontext.DeadlineExceeded {
+			return fmt.Errorf("agent %q did not finish within %s", ag.Name, timeout)
+		}
+		if err != nil {
+			return fmt.Errorf("agent %q failed after %s: %w\n%s", ag.Name, elapsed, err, output)
+		}
+		if output == "" {
+			return fmt.Errorf("agent %q exited without output after %s", ag.Name, elapsed)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "   %s\n", lastLine(output))
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 %s OK (%s)\n", ag.Name, elapsed)
+		return nil
+	},
+}
+
+// lastLine returns the final line of s, which is usually the agent's answer.
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+func init() {
+	agentsListCmd.Flags().Bool("json", false, "Output as JSON")
+
+	agentsTestCmd.Flags().Duration("timeout", 2*time.Minute, "Maximum time to wait for the agent")
+	agentsTestCmd.Flags().String("prompt", agentSmokePrompt, "Smoke prompt to send")
+
+	agentsCmd.AddCommand(agentsListCmd)
+	agentsCmd.AddCommand(agentsShowCmd)
+	agentsCmd.AddCommand(agentsTestCmd)
+	rootCmd.AddCommand(agentsCmd)
+}
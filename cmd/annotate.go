@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/instructions"
+	"github.com/spf13/cobra"
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate <name>",
+	Short: "Edit a context's task, notes, tags and metadata",
+	Long: `Edit the annotations of a context: its task, free-form notes, tags and
+key=value metadata. Without flags, print them.
+
+Tags and metadata can be searched with wiz list --tag and --filter, and from
+the picker.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		untags, _ := cmd.Flags().GetStringSlice("untag")
+		sets, _ := cmd.Flags().GetStringArray("set")
+		unsets, _ := cmd.Flags().GetStringSlice("unset")
+		task, _ := cmd.Flags().GetString("task")
+		notes, _ := cmd.Flags().GetString("notes")
+		setTask, setNotes := cmd.Flags().Changed("task"), cmd.Flags().Changed("notes")
+
+		for _, tag := range tags {
+			if tag == "" || strings.ContainsAny(tag, " \t\n") {
+				return fmt.Errorf("invalid tag %q", tag)
+			}
+		}
+		meta := make(map[string]string)
+		for _, spec := range sets {
+			k, v, ok := strings.Cut(spec, "=")
+			if !ok {
+				return fmt.Errorf("invalid --set %q; want key=value", spec)
+			}
+			if err := wizctx.ValidateMetaKey(k); err != nil {
+				return err
+			}
+			meta[k] = v
+		}
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+		c, err := store.Get(args[0])
+		if err != nil {
+			return fmt.Errorf("context %q not found; run 'wiz list' to see available contexts", args[0])
+		}
+
+		if !setTask && !setNotes && len(tags)+len(untags)+len(meta)+len(unsets) == 0 {
+			printAnnotations(cmd, c)
+			return nil
+		}
+
+		var updated wizctx.Context
+		if err := store.Update(cmd.Context(), c.Name, func(c *wizctx.Context) {
+			if setTask {
+				c.Task = task
+			}
+			if setNotes {
+				c.Notes = notes
+			}
+			for _, tag := range tags {
+				if !c.HasTags(tag) {
+					c.Tags = append(c.Tags, tag)
+				}
+			}
+			c.Tags = slices.DeleteFunc(c.Tags, func(t string) bool {
+				return slices.ContainsFunc(untags, func(u string) bool { return strings.EqualFold(t, u) })
+			})
+			if len(c.Tags) == 0 {
+				c.Tags = nil
+			}
+			if c.Meta == nil {
+				c.Meta = make(map[string]string)
+			}
+			maps.Copy(c.Meta, meta)
+			for _, k := range unsets {
+				delete(c.Meta, k)
+			}
+			if len(c.Meta) == 0 {
+				c.Meta = nil
+			}
+			updated = *c
+		}); err != nil {
+			return err
+		}
+
+		if setTask && task != c.Task && !updated.Archived {
+			// The agent instructions quote the task.
+			if cfg := config.Load(repo); cfg.Instructions.Enabled {
+				if _, err := instructions.Apply(cmd.Context(), repo, cfg, &updated); err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: instructions: %v\n", err)
+				}
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Annotated %s\n", c.Name)
+		return nil
+	},
+}
+
+func printAnnotations(cmd *cobra.Command, c *wizctx.Context) {
+	out := cmd.OutOrStdout()
+	if c.Task == "" && c.Notes == "" && len(c.Tags) == 0 && len(c.Meta) == 0 {
+		fmt.Fprintf(out, "No annotations. Add some with: wiz annotate %s --task/--notes/--tag/--set\n", c.Name)
+		return
+	}
+	if c.Task != "" {
+		fmt.Fprintf(out, "task:  %s\n", c.Task)
+	}
+	if len(c.Tags) > 0 {
+		fmt.Fprintf(out, "tags:  %s\n", strings.Join(c.Tags, ", "))
+	}
+	for _, k := range slices.Sorted(maps.Keys(c.Meta)) {
+		fmt.Fprintf(out, "%s: %s\n", k, c.Meta[k])
+	}
+	if c.Notes != "" {
+		fmt.Fprintf(out, "notes:\n%s\n", c.Notes)
+	}
+}
+
+func init() {
+	annotateCmd.Flags().String("task", "", "Set the task")
+	annotateCmd.Flags().String("notes", "", "Set the notes")
+	annotateCmd.Flags().StringSlice("tag", nil, "Add a tag (repeatable)")
+	annotateCmd.Flags().StringSlice("untag", nil, "Remove a tag (repeatable)")
+	annotateCmd.Flags().StringArray("set", nil, "Set metadata (key=value, repeatable)")
+	annotateCmd.Flags().StringSlice("unset", nil, "Remove metadata (repeatable)")
+	rootCmd.AddCommand(annotateCmd)
+}
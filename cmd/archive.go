@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/instructions"
+	"github.com/buck3000/wiz/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <name>",
+	Short: "Park a context without keeping its working directory",
+	Long: `Archive a context: uncommitted work (untracked files included) is saved as a
+snapshot, the worktree or clone is removed, and the context stays in the
+registry with its branch, task, agent and other metadata. Archived contexts
+don't count against the context limit. Bring one back with wiz unarchive.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+		c, err := activeContext(store, args[0])
+		if err != nil {
+			return err
+		}
+
+		id := 0
+		if st, err := gitx.StatusAt(cmd.Context(), c.Path); err == nil && st.Dirty {
+			s, err := snapshot.Take(cmd.Context(), c.Path, c.Name, "wiz archive")
+			if err != nil {
+				return fmt.Errorf("save uncommitted work: %w", err)
+			}
+			id = s.ID
+		}
+		if c.Strategy == wizctx.StrategyClone {
+			// The branch and snapshots live in the clone; keep them in the
+			// main repository, where unarchive provisions the clone from. The
+			// branch goes under its own ref so that a branch of the main
+			// repository with the same name is left alone.
+			if _, err := repo.Run(cmd.Context(), "fetch", "--quiet", "--no-tags", c.Path,
+				"+refs/heads/"+c.Branch+":"+archiveRef(c.Name), snapshot.Refspec(c.Name)); err != nil {
+				return fmt.Errorf("save branch %s: %w", c.Branch, err)
+			}
+		}
+
+		prov := wizctx.NewProvisioner(c.Strategy, repo)
+		if err := prov.Destroy(cmd.Context(), c.Path, true); err != nil {
+			return fmt.Errorf("remove %s: %w", c.Path, err)
+		}
+		if err := store.Update(cmd.Context(), c.Name, func(c *wizctx.Context) {
+			c.Archived, c.ArchivedAt, c.ArchiveSnapshot = true, time.Now(), id
+		}); err != nil {
+			return err
+		}
+
+		if id != 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Archived %s (uncommitted work in snapshot %d)\n", c.Name, id)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Archived %s\n", c.Name)
+		}
+		return nil
+	},
+}
+
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive <name>",
+	Short: "Bring back an archived context",
+	Long: `Provision an archived context's worktree or clone again on its branch and
+restore the uncommitted work saved when it was archived.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+		c, err := store.Get(args[0])
+		if err != nil {
+			return fmt.Errorf("context %q not found; run 'wiz list' to see available contexts", args[0])
+		}
+		if !c.Archived {
+			return fmt.Errorf("context %q is not archived", c.Name)
+		}
+		existing, err := store.List()
+		if err != nil {
+			return err
+		}
+		if err := checkContextLimit(existing); err != nil {
+			return err
+		}
+		saved := c.Strategy == wizctx.StrategyClone && refExists(cmd, repo, archiveRef(c.Name))
+		if !saved && !repo.BranchExists(cmd.Context(), c.Branch) {
+			return fmt.Errorf("branch %q of context %q no longer exists", c.Branch, c.Name)
+		}
+
+		prov := wizctx.NewProvisioner(c.Strategy, repo)
+		path, err := prov.Create(cmd.Context(), wizctx.CreateOpts{
+			Name:       c.Name,
+			Branch:     c.Branch,
+			BaseBranch: c.BaseBranch,
+			Repo:       repo,
+		})
+		if err != nil {
+			return err
+		}
+		c.Path = path
+		if err := restoreArchive(cmd, repo, c, saved); err != nil {
+			prov.Destroy(cmd.Context(), path, true)
+			return err
+		}
+
+		cfg := config.Load(repo)
+		if cfg.Instructions.Enabled {
+			if _, err := instructions.Apply(cmd.Context(), repo, cfg, c); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: instructions: %v\n", err)
+			}
+		}
+		if err := store.Update(cmd.Context(), c.Name, func(c *wizctx.Context) {
+			c.Path = path
+			c.Archived, c.ArchivedAt, c.ArchiveSnapshot = false, time.Time{}, 0
+		}); err != nil {
+			return err
+		}
+		if saved {
+			repo.Run(cmd.Context(), "update-ref", "-d", archiveRef(c.Name))
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Unarchived %s at %s\n", c.Name, path)
+		return nil
+	},
+}
+
+// restoreArchive brings the branch (if saved) and snapshots archive saved
+// into c's new working directory and restores the uncommitted work from
+// c.ArchiveSnapshot.
+func restoreArchive(cmd *cobra.Command, repo *gitx.Repo, c *wizctx.Context, saved bool) error {
+	dir, err := gitx.Discover(c.Path)
+	if err != nil {
+		return err
+	}
+	if saved {
+		if _, err := dir.Run(cmd.Context(), "fetch", "--quiet", "--no-tags", repo.WorkDir, archiveRef(c.Name)); err != nil {
+			return fmt.Errorf("restore branch %s: %w", c.Branch, err)
+		}
+		if _, err := dir.Run(cmd.Context(), "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+			return fmt.Errorf("restore branch %s: %w", c.Branch, err)
+		}
+	}
+	if c.Strategy == wizctx.StrategyClone {
+		if _, err := dir.Run(cmd.Context(), "fetch", "--quiet", "--no-tags", repo.WorkDir, snapshot.Refspec(c.Name)); err != nil {
+			return fmt.Errorf("restore snapshots: %w", err)
+		}
+		snapshot.Delete(cmd.Context(), repo.WorkDir, c.Name)
+	}
+	if c.ArchiveSnapshot == 0 {
+		return nil
+	}
+
+	s, err := snapshot.Get(cmd.Context(), c.Path, c.Name, c.ArchiveSnapshot)
+	if err != nil {
+		return err
+	}
+	if head, err := dir.Run(cmd.Context(), "rev-parse", "HEAD"); err != nil || head != s.Head {
+		// The branch moved on while the context was archived; applying the
+		// snapshot would silently revert those commits.
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s moved since %s was archived; restore its uncommitted work with 'wiz restore %s %d'\n",
+			c.Branch, c.Name, c.Name, s.ID)
+		return nil
+	}
+	if err := snapshot.Apply(cmd.Context(), c.Path, s.Commit); err != nil {
+		return fmt.Errorf("restore uncommitted work: %w", err)
+	}
+	return nil
+}
+
+// archiveRef is where archive keeps an archived clone context's branch in
+// the main repository.
+func archiveRef(name string) string {
+	return "refs/wiz/archive/" + wizctx.SafeDirName(name)
+}
+
+func refExists(cmd *cobra.Command, repo *gitx.Repo, ref string) bool {
+	_, err := repo.Run(cmd.Context(), "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// activeContext returns the named context, which must not be archived.
+func activeContext(store *wizctx.Store, name string) (*wizctx.Context, error) {
+	c, err := store.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("context %q not found; run 'wiz list' to see available contexts", name)
+	}
+	if c.Archived {
+		return nil, fmt.Errorf("context %q is archived; run 'wiz unarchive %s' first", name, name)
+	}
+	return c, nil
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+}
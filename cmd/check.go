@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check <name...>",
+	Short: "Run configured verification commands in contexts",
+	Long: `Run the checks configured under "checks" in the wiz config (or in the
+context's template) inside each context. Contexts are checked in parallel;
+a context's checks run one after another. Results, durations and log paths
+are stored with the context and shown by wiz list, wiz status and wiz watch.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+
+		var contexts []wizctx.Context
+		if all {
+			contexts, err = store.List()
+			if err != nil {
+				return err
+			}
+			contexts = wizctx.Active(contexts)
+		} else {
+			if len(args) == 0 {
+				return fmt.Errorf("usage: wiz check <name...> or wiz check --all")
+			}
+			for _, name := range args {
+				c, err := activeContext(store, name)
+				if err != nil {
+					return err
+				}
+				contexts = append(contexts, *c)
+			}
+		}
+
+		var jobs []check.Job
+		for _, c := range contexts {
+			checks, err := check.ForTemplate(repo, c.Template)
+			if err != nil {
+				return err
+			}
+			if len(checks) == 0 {
+				continue
+			}
+			jobs = append(jobs, check.Job{Context: c.Name, Dir: c.Path, Checks: checks})
+		}
+		if len(jobs) == 0 {
+			return fmt.Errorf("no checks configured; add \"checks\" to %s/config.json", config.WizDir(repo))
+		}
+
+		failed := 0
+		results := check.RunAll(cmd.Context(), jobs, config.ChecksDir(repo), parallel, func(job check.Job, rs []check.Result) {
+			if err := store.Update(cmd.Context(), job.Context, func(c *wizctx.Context) {
+				c.Checks = rs
+			}); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: save results for %s: %v\n", job.Context, err)
+			}
+			if !check.Passed(rs) {
+				failed++
+			}
+			if !asJSON {
+				printCheckResults(cmd, job.Context, rs)
+			}
+		})
+
+		if asJSON {
+			out := make(map[string][]check.Result, len(jobs))
+			for i, job := range jobs {
+				out[job.Context] = results[i]
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(out); err != nil {
+				return err
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("checks failed in %d of %d contexts", failed, len(jobs))
+		}
+		return nil
+	},
+}
+
+func printCheckResults(cmd *cobra.Command, name string, rs []check.Result) {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "\033[1;35m%s\033[0m %s\n", name, check.Summary(rs))
+	for _, r := range rs {
+		if r.Passed {
+			fmt.Fprintf(out, "  \033[32m✓\033[0m %s (%s)\n", r.Name, r.Duration)
+		} else {
+			fmt.Fprintf(out, "  \033[31m✗\033[0m %s (%s, exit %d)  log: %s\n", r.Name, r.Duration, r.ExitCode, r.Log)
+		}
+	}
+}
+
+// checkMarks renders results compactly, e.g. "✓ build ✗ test".
+func checkMarks(rs []check.Result) string {
+	s := ""
+	for i, r := range rs {
+		if i > 0 {
+			s += " "
+		}
+		if r.Passed {
+			s += "✓ " + r.Name
+		} else {
+			s += "✗ " + r.Name
+		}
+	}
+	return s
+}
+
+func init() {
+	checkCmd.Flags().Bool("all", false, "Check all contexts")
+	checkCmd.Flags().IntP("parallel", "j", runtime.NumCPU(), "Maximum contexts checked at once")
+	checkCmd.Flags().Bool("json", false, "Output results as JSON")
+	rootCmd.AddCommand(checkCmd)
+}
@@ -2,11 +2,20 @@ package cmd_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/buck3000/wiz/internal/license"
 )
 
 // buildWiz builds the wiz binary and returns its path.
@@ -197,6 +206,79 @@ func TestListJSON(t *testing.T) {
 	runWiz(t, bin, repo, "delete", "json-test", "--force")
 }
 
+func TestCreateBranchNaming(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+	t.Setenv("USER", "ada")
+
+	branchOf := func(name string) string {
+		t.Helper()
+		stdout, _, err := runWiz(t, bin, repo, "list", "--json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var contexts []struct {
+			Name   string `json:"name"`
+			Branch string `json:"branch"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &contexts); err != nil {
+			t.Fatalf("list --json: %v\n%s", err, stdout)
+		}
+		for _, c := range contexts {
+			if c.Name == name {
+				return c.Branch
+			}
+		}
+		t.Fatalf("context %s not listed: %s", name, stdout)
+		return ""
+	}
+
+	if stdout, stderr, err := runWiz(t, bin, repo, "create", "plain"); err != nil {
+		t.Fatalf("create: %v\n%s%s", err, stdout, stderr)
+	}
+	if got := branchOf("plain"); got != "plain" {
+		t.Errorf("default branch = %q, want plain", got)
+	}
+
+	stdout, stderr, err := runWiz(t, bin, repo, "create", "explicit", "--branch", "team/explicit")
+	if err != nil {
+		t.Fatalf("create --branch: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "(branch team/explicit)") {
+		t.Errorf("create output = %q", stdout)
+	}
+	if got := branchOf("explicit"); got != "team/explicit" {
+		t.Errorf("explicit branch = %q, want team/explicit", got)
+	}
+
+	cfg := `{"branch_template": "{{.User}}/{{.Name}}"}`
+	if err := os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if stdout, stderr, err := runWiz(t, bin, repo, "create", "feature-xyz"); err != nil {
+		t.Fatalf("create with branch_template: %v\n%s%s", err, stdout, stderr)
+	}
+	if got := branchOf("feature-xyz"); got != "ada/feature-xyz" {
+		t.Errorf("templated branch = %q, want ada/feature-xyz", got)
+	}
+
+	// A template's branch_template overrides the config.
+	if _, _, err := runWiz(t, bin, repo, "template", "save", "agent", "--branch-template", "wiz/{{.Name}}"); err != nil {
+		t.Fatal(err)
+	}
+	if stdout, stderr, err := runWiz(t, bin, repo, "create", "bot", "--template", "agent"); err != nil {
+		t.Fatalf("create --template: %v\n%s%s", err, stdout, stderr)
+	}
+	if got := branchOf("bot"); got != "wiz/bot" {
+		t.Errorf("template branch = %q, want wiz/bot", got)
+	}
+
+	// Rendered names must be valid branch names.
+	if _, _, err := runWiz(t, bin, repo, "create", "bad", "--branch", "bad..name"); err == nil {
+		t.Error("create accepted an invalid branch name")
+	}
+}
+
 func TestRunExecutesInCorrectDir(t *testing.T) {
 	bin := buildWiz(t)
 	repo := setupTestRepo(t)
@@ -384,3 +466,1412 @@ func TestStatusPorcelain(t *testing.T) {
 
 	runWiz(t, bin, repo, "delete", "status-test", "--force")
 }
+
+func TestRename(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	for _, args := range [][]string{{"create", "old"}, {"create", "kid", "--on", "old"}} {
+		if stdout, stderr, err := runWiz(t, bin, repo, args...); err != nil {
+			t.Fatalf("%v: %v\n%s%s", args, err, stdout, stderr)
+		}
+	}
+	oldPath, _, _ := runWiz(t, bin, repo, "path", "old")
+	oldPath = strings.TrimSpace(oldPath)
+
+	if stdout, stderr, err := runWiz(t, bin, repo, "rename", "old", "new", "--branch", "--move"); err != nil {
+		t.Fatalf("rename: %v\n%s%s", err, stdout, stderr)
+	}
+
+	stdout, _, err := runWiz(t, bin, repo, "list", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contexts []struct {
+		Name       string   `json:"name"`
+		Branch     string   `json:"branch"`
+		Path       string   `json:"path"`
+		BaseBranch string   `json:"base_branch"`
+		Parent     string   `json:"parent"`
+		Aliases    []string `json:"aliases"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &contexts); err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]int{}
+	for i, c := range contexts {
+		byName[c.Name] = i
+	}
+	renamed, kid := contexts[byName["new"]], contexts[byName["kid"]]
+	if renamed.Name != "new" || renamed.Branch != "new" || filepath.Base(renamed.Path) != "new" {
+		t.Errorf("renamed context = %+v", renamed)
+	}
+	if len(renamed.Aliases) != 1 || renamed.Aliases[0] != "old" {
+		t.Errorf("aliases = %v, want [old]", renamed.Aliases)
+	}
+	if kid.Parent != "new" || kid.BaseBranch != "new" {
+		t.Errorf("child = %+v, want it stacked on new", kid)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("old directory %s still exists", oldPath)
+	}
+	if out, err := exec.Command("git", "-C", renamed.Path, "branch", "--show-current").Output(); err != nil || strings.TrimSpace(string(out)) != "new" {
+		t.Errorf("worktree branch = %q, %v", out, err)
+	}
+
+	// A shell still carrying the old name and directory is told the new ones.
+	cmd := exec.Command(bin, "status", "--porcelain")
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(), "WIZ_CTX=old", "WIZ_REPO=test", "WIZ_DIR="+oldPath, "WIZ_BRANCH=old")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 7 || fields[0] != "new" || fields[2] != "new" || fields[6] != renamed.Path {
+		t.Errorf("porcelain status = %q", out)
+	}
+
+	// Without flags only the context name changes.
+	if _, _, err := runWiz(t, bin, repo, "rename", "kid", "child"); err != nil {
+		t.Fatal(err)
+	}
+	if p, _, _ := runWiz(t, bin, repo, "path", "child"); filepath.Base(strings.TrimSpace(p)) != "kid" {
+		t.Errorf("path after plain rename = %q", p)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	runWiz(t, bin, repo, "create", "snap")
+	path, _, _ := runWiz(t, bin, repo, "path", "snap")
+	path = strings.TrimSpace(path)
+	os.WriteFile(filepath.Join(path, "work.txt"), []byte("good"), 0o644)
+
+	stdout, stderr, err := runWiz(t, bin, repo, "snapshot", "snap", "-m", "before refactor")
+	if err != nil {
+		t.Fatalf("snapshot: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "Snapshot 1 of snap") {
+		t.Errorf("snapshot output = %q", stdout)
+	}
+
+	os.WriteFile(filepath.Join(path, "work.txt"), []byte("wrecked"), 0o644)
+	stdout, stderr, err = runWiz(t, bin, repo, "restore", "snap", "1")
+	if err != nil {
+		t.Fatalf("restore: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "Saved current state as snapshot 2") {
+		t.Errorf("restore output = %q", stdout)
+	}
+	if data, _ := os.ReadFile(filepath.Join(path, "work.txt")); string(data) != "good" {
+		t.Errorf("work.txt = %q after restore", data)
+	}
+
+	// Snapshots follow a rename and go away with the context.
+	if _, _, err := runWiz(t, bin, repo, "rename", "snap", "snap2"); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = runWiz(t, bin, repo, "snapshots", "snap2", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snaps []struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &snaps); err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 || snaps[0].Message != "before refactor" || snaps[1].Message != "before restoring snapshot 1" {
+		t.Errorf("snapshots = %+v", snaps)
+	}
+
+	runWiz(t, bin, repo, "delete", "snap2", "--force")
+	if out, _ := exec.Command("git", "-C", repo, "for-each-ref", "refs/wiz/snapshots/").Output(); len(out) != 0 {
+		t.Errorf("snapshot refs left after delete: %s", out)
+	}
+}
+
+func TestFork(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	gitIn := func(t *testing.T, dir string, args ...string) string {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	for _, strategy := range []string{"worktree", "clone"} {
+		t.Run(strategy, func(t *testing.T) {
+			src, alt := "src-"+strategy, "alt-"+strategy
+			if stdout, stderr, err := runWiz(t, bin, repo, "create", src, "--strategy", strategy, "--task", "Try it"); err != nil {
+				t.Fatalf("create: %v\n%s%s", err, stdout, stderr)
+			}
+			srcPath, _, _ := runWiz(t, bin, repo, "path", src)
+			srcPath = strings.TrimSpace(srcPath)
+			os.WriteFile(filepath.Join(srcPath, "done.txt"), []byte("done"), 0o644)
+			gitIn(t, srcPath, "add", "done.txt")
+			gitIn(t, srcPath, "commit", "-m", "half done")
+			os.WriteFile(filepath.Join(srcPath, "done.txt"), []byte("more"), 0o644)
+			os.WriteFile(filepath.Join(srcPath, "draft.txt"), []byte("draft"), 0o644)
+			srcStatus := gitIn(t, srcPath, "status", "--porcelain")
+
+			stdout, stderr, err := runWiz(t, bin, repo, "fork", src, alt)
+			if err != nil {
+				t.Fatalf("fork: %v\n%s%s", err, stdout, stderr)
+			}
+			altPath, _, _ := runWiz(t, bin, repo, "path", alt)
+			altPath = strings.TrimSpace(altPath)
+
+			if got, want := gitIn(t, altPath, "rev-parse", "HEAD"), gitIn(t, srcPath, "rev-parse", "HEAD"); got != want {
+				t.Errorf("fork HEAD = %s, want the source's %s", got, want)
+			}
+			if got := gitIn(t, altPath, "branch", "--show-current"); got != alt {
+				t.Errorf("fork branch = %q", got)
+			}
+			if got := gitIn(t, altPath, "status", "--porcelain"); got != srcStatus {
+				t.Errorf("fork status = %q, want %q", got, srcStatus)
+			}
+			if data, _ := os.ReadFile(filepath.Join(altPath, "draft.txt")); string(data) != "draft" {
+				t.Errorf("draft.txt = %q", data)
+			}
+			if got := gitIn(t, srcPath, "status", "--porcelain"); got != srcStatus {
+				t.Errorf("source status changed to %q", got)
+			}
+
+			stdout, _, _ = runWiz(t, bin, repo, "list", "--json")
+			var contexts []struct {
+				Name       string `json:"name"`
+				Task       string `json:"task"`
+				ForkedFrom string `json:"forked_from"`
+				ForkPoint  string `json:"fork_point"`
+			}
+			json.Unmarshal([]byte(stdout), &contexts)
+			found := false
+			for _, c := range contexts {
+				if c.Name == alt {
+					found = true
+					if c.ForkedFrom != src || c.ForkPoint != gitIn(t, srcPath, "rev-parse", "HEAD") || c.Task != "Try it" {
+						t.Errorf("fork context = %+v", c)
+					}
+				}
+			}
+			if !found {
+				t.Fatalf("fork not listed: %s", stdout)
+			}
+
+			if _, _, err := runWiz(t, bin, repo, "fork", src, alt); err == nil {
+				t.Error("forking onto an existing context should fail")
+			}
+
+			// A fork that fails after creating its branch removes it, so the
+			// same name can be used again.
+			failed := "failed-" + strategy
+			cfgPath := filepath.Join(repo, ".git", "wiz", "config.json")
+			os.WriteFile(cfgPath, []byte(`{"instructions": {"enabled": true, "template": "missing.tmpl"}}`), 0o644)
+			if _, _, err := runWiz(t, bin, repo, "fork", src, failed); err == nil {
+				t.Error("fork with a missing instructions template should fail")
+			}
+			os.Remove(cfgPath)
+			if stdout, stderr, err := runWiz(t, bin, repo, "fork", src, failed); err != nil {
+				t.Errorf("fork after a failed fork: %v\n%s%s", err, stdout, stderr)
+			}
+		})
+	}
+}
+
+func TestMoveChanges(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	runWiz(t, bin, repo, "create", "wrong")
+	runWiz(t, bin, repo, "create", "right")
+	wrong, _, _ := runWiz(t, bin, repo, "path", "wrong")
+	right, _, _ := runWiz(t, bin, repo, "path", "right")
+	wrong, right = strings.TrimSpace(wrong), strings.TrimSpace(right)
+
+	os.WriteFile(filepath.Join(wrong, "README.md"), []byte("# edited\n"), 0o644)
+	os.WriteFile(filepath.Join(wrong, "new.txt"), []byte("new"), 0o644)
+
+	// --check reports without touching either side.
+	stdout, stderr, err := runWiz(t, bin, repo, "move-changes", "wrong", "right", "-u", "--check")
+	if err != nil {
+		t.Fatalf("move-changes --check: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "2 file(s) would move") {
+		t.Errorf("--check output = %q", stdout)
+	}
+	if _, err := os.Stat(filepath.Join(right, "new.txt")); err == nil {
+		t.Error("--check changed the target")
+	}
+
+	// A conflicting target leaves both contexts alone.
+	os.WriteFile(filepath.Join(right, "README.md"), []byte("# theirs\n"), 0o644)
+	if _, _, err := runWiz(t, bin, repo, "move-changes", "wrong", "right", "-u"); err == nil {
+		t.Fatal("move-changes into a conflicting target should fail")
+	}
+	if data, _ := os.ReadFile(filepath.Join(wrong, "README.md")); string(data) != "# edited\n" {
+		t.Errorf("source README.md = %q after a failed move", data)
+	}
+	run(t, right, "git", "checkout", "README.md")
+
+	stdout, stderr, err = runWiz(t, bin, repo, "move-changes", "wrong", "right", "-u")
+	if err != nil {
+		t.Fatalf("move-changes: %v\n%s%s", err, stdout, stderr)
+	}
+	if data, _ := os.ReadFile(filepath.Join(right, "README.md")); string(data) != "# edited\n" {
+		t.Errorf("target README.md = %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(right, "new.txt")); string(data) != "new" {
+		t.Errorf("target new.txt = %q", data)
+	}
+	if out, _ := exec.Command("git", "-C", wrong, "status", "--porcelain").Output(); len(out) != 0 {
+		t.Errorf("source not cleaned: %s", out)
+	}
+	if !strings.Contains(stdout, "Saved wrong as snapshot 1") {
+		t.Errorf("move-changes output = %q", stdout)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	gitIn := func(t *testing.T, dir string, args ...string) string {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	for _, strategy := range []string{"worktree", "clone"} {
+		t.Run(strategy, func(t *testing.T) {
+			name := "park-" + strategy
+			if stdout, stderr, err := runWiz(t, bin, repo, "create", name, "--strategy", strategy, "--task", "Later"); err != nil {
+				t.Fatalf("create: %v\n%s%s", err, stdout, stderr)
+			}
+			path, _, _ := runWiz(t, bin, repo, "path", name)
+			path = strings.TrimSpace(path)
+			os.WriteFile(filepath.Join(path, "done.txt"), []byte("done"), 0o644)
+			gitIn(t, path, "add", "done.txt")
+			gitIn(t, path, "commit", "-m", "committed work")
+			head := gitIn(t, path, "rev-parse", "HEAD")
+			os.WriteFile(filepath.Join(path, "README.md"), []byte("# edited\n"), 0o644)
+			os.WriteFile(filepath.Join(path, "draft.txt"), []byte("draft"), 0o644)
+			status := gitIn(t, path, "status", "--porcelain")
+
+			// A main repository branch of the same name is left alone.
+			if strategy == "clone" {
+				run(t, repo, "git", "branch", name, "main")
+			}
+			stdout, stderr, err := runWiz(t, bin, repo, "archive", name)
+			if err != nil {
+				t.Fatalf("archive: %v\n%s%s", err, stdout, stderr)
+			}
+			if strategy == "clone" && gitIn(t, repo, "rev-parse", name) != gitIn(t, repo, "rev-parse", "main") {
+				t.Errorf("archive moved the main repository's %s branch", name)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("%s still exists after archive", path)
+			}
+			stdout, _, _ = runWiz(t, bin, repo, "list")
+			if !strings.Contains(stdout, "archived") {
+				t.Errorf("list output = %q", stdout)
+			}
+			if _, stderr, err := runWiz(t, bin, repo, "path", name); err == nil || !strings.Contains(stderr, "unarchive") {
+				t.Errorf("path of an archived context: err=%v stderr=%q", err, stderr)
+			}
+
+			stdout, stderr, err = runWiz(t, bin, repo, "unarchive", name)
+			if err != nil {
+				t.Fatalf("unarchive: %v\n%s%s", err, stdout, stderr)
+			}
+			path, _, _ = runWiz(t, bin, repo, "path", name)
+			path = strings.TrimSpace(path)
+			if got := gitIn(t, path, "rev-parse", "HEAD"); got != head {
+				t.Errorf("HEAD = %s after unarchive, want %s", got, head)
+			}
+			if got := gitIn(t, path, "branch", "--show-current"); got != name {
+				t.Errorf("branch = %q after unarchive", got)
+			}
+			if got := gitIn(t, path, "status", "--porcelain"); got != status {
+				t.Errorf("status = %q after unarchive, want %q", got, status)
+			}
+
+			stdout, _, _ = runWiz(t, bin, repo, "list", "--json")
+			var contexts []struct {
+				Name     string `json:"name"`
+				Task     string `json:"task"`
+				Archived bool   `json:"archived"`
+			}
+			json.Unmarshal([]byte(stdout), &contexts)
+			for _, c := range contexts {
+				if c.Name == name && (c.Archived || c.Task != "Later") {
+					t.Errorf("context after unarchive = %+v", c)
+				}
+			}
+		})
+	}
+}
+
+func TestAnnotateAndFilter(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	runWiz(t, bin, repo, "create", "auth", "--task", "Add login")
+	runWiz(t, bin, repo, "create", "ui")
+
+	stdout, stderr, err := runWiz(t, bin, repo, "annotate", "auth", "--task", "Add OAuth login", "--notes", "blocked on keys",
+		"--tag", "backend,urgent", "--set", "owner=alice")
+	if err != nil {
+		t.Fatalf("annotate: %v\n%s%s", err, stdout, stderr)
+	}
+	runWiz(t, bin, repo, "annotate", "ui", "--tag", "frontend", "--set", "owner=bob")
+	if _, _, err := runWiz(t, bin, repo, "annotate", "ui", "--set", "branch=x"); err == nil {
+		t.Error("--set with a context field name should fail")
+	}
+
+	stdout, _, _ = runWiz(t, bin, repo, "annotate", "auth")
+	for _, want := range []string{"task:  Add OAuth login", "tags:  backend, urgent", "owner: alice", "blocked on keys"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("annotations = %q, missing %q", stdout, want)
+		}
+	}
+
+	listed := func(args ...string) []string {
+		t.Helper()
+		stdout, stderr, err := runWiz(t, bin, repo, append([]string{"list", "--json"}, args...)...)
+		if err != nil {
+			t.Fatalf("list %v: %v\n%s", args, err, stderr)
+		}
+		var contexts []struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal([]byte(stdout), &contexts)
+		var names []string
+		for _, c := range contexts {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "auth ui"},
+		{[]string{"--tag", "backend"}, "auth"},
+		{[]string{"--tag", "backend", "--tag", "frontend"}, ""},
+		{[]string{"--filter", "owner=bob"}, "ui"},
+		{[]string{"--filter", "oauth"}, "auth"},
+		{[]string{"--filter", "tag!=urgent"}, "ui"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(listed(tt.args...), " "); got != tt.want {
+			t.Errorf("list %v = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	runWiz(t, bin, repo, "annotate", "auth", "--untag", "urgent", "--unset", "owner", "--notes", "")
+	stdout, _, _ = runWiz(t, bin, repo, "annotate", "auth")
+	if strings.Contains(stdout, "urgent") || strings.Contains(stdout, "owner") || strings.Contains(stdout, "notes") {
+		t.Errorf("annotations after removal = %q", stdout)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+	other := filepath.Join(t.TempDir(), "other")
+	run(t, repo, "git", "clone", "--quiet", repo, other)
+
+	gitIn := func(t *testing.T, dir string, args ...string) string {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimRight(string(out), "\n")
+	}
+
+	runWiz(t, bin, repo, "create", "feat", "--task", "Ship it")
+	runWiz(t, bin, repo, "annotate", "feat", "--tag", "handoff", "--set", "owner=alice")
+	path, _, _ := runWiz(t, bin, repo, "path", "feat")
+	path = strings.TrimSpace(path)
+	os.WriteFile(filepath.Join(path, "done.txt"), []byte("done"), 0o644)
+	gitIn(t, path, "add", "done.txt")
+	gitIn(t, path, "commit", "-m", "committed work")
+	os.WriteFile(filepath.Join(path, "README.md"), []byte("# edited\n"), 0o644)
+	os.WriteFile(filepath.Join(path, "draft.txt"), []byte("draft"), 0o644)
+	status := gitIn(t, path, "status", "--porcelain")
+
+	file := filepath.Join(t.TempDir(), "feat.wizbundle")
+	stdout, stderr, err := runWiz(t, bin, repo, "export", "feat", "-o", file)
+	if err != nil {
+		t.Fatalf("export: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "2 uncommitted file(s)") {
+		t.Errorf("export output = %q", stdout)
+	}
+	if got := gitIn(t, path, "status", "--porcelain"); got != status {
+		t.Errorf("export changed the context: %q", got)
+	}
+
+	stdout, stderr, err = runWiz(t, bin, other, "import", file)
+	if err != nil {
+		t.Fatalf("import: %v\n%s%s", err, stdout, stderr)
+	}
+	imported, _, _ := runWiz(t, bin, other, "path", "feat")
+	imported = strings.TrimSpace(imported)
+	if got, want := gitIn(t, imported, "rev-parse", "HEAD"), gitIn(t, path, "rev-parse", "HEAD"); got != want {
+		t.Errorf("imported HEAD = %s, want %s", got, want)
+	}
+	if got := gitIn(t, imported, "branch", "--show-current"); got != "feat" {
+		t.Errorf("imported branch = %q", got)
+	}
+	if got := gitIn(t, imported, "status", "--porcelain"); got != status {
+		t.Errorf("imported status = %q, want %q", got, status)
+	}
+	stdout, _, _ = runWiz(t, bin, other, "list", "--json", "--filter", "owner=alice tag=handoff")
+	if !strings.Contains(stdout, `"task": "Ship it"`) {
+		t.Errorf("imported context = %s", stdout)
+	}
+
+	// The name is taken now; --name and --branch import a second copy.
+	if _, _, err := runWiz(t, bin, other, "import", file); err == nil {
+		t.Error("importing over an existing context should fail")
+	}
+	if stdout, stderr, err := runWiz(t, bin, other, "import", file, "--name", "feat2", "--branch", "feat2"); err != nil {
+		t.Fatalf("import --name: %v\n%s%s", err, stdout, stderr)
+	}
+}
+
+func TestAgentsListShowTest(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	cfg := `{"agents": {"echoer": {"command": "echo", "headless_args": ["headless:"]}}}`
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+
+	stdout, _, err := runWiz(t, bin, repo, "agents", "list", "--json")
+	if err != nil {
+		t.Fatalf("agents list: %v", err)
+	}
+	if !strings.Contains(stdout, `"echoer"`) || !strings.Contains(stdout, `"custom"`) {
+		t.Errorf("agents list missing custom agent: %s", stdout)
+	}
+	if !strings.Contains(stdout, `"claude"`) {
+		t.Errorf("agents list missing builtin: %s", stdout)
+	}
+
+	stdout, _, err = runWiz(t, bin, repo, "agents", "show", "echoer")
+	if err != nil {
+		t.Fatalf("agents show: %v", err)
+	}
+	if !strings.Contains(stdout, "echo headless: '<prompt>'") {
+		t.Errorf("agents show missing headless command: %s", stdout)
+	}
+
+	stdout, stderr, err := runWiz(t, bin, repo, "agents", "test", "echoer", "--prompt", "OK")
+	if err != nil {
+		t.Fatalf("agents test: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "headless: OK") {
+		t.Errorf("agents test output: %s", stdout)
+	}
+
+	// The throwaway context must not linger.
+	stdout, _, _ = runWiz(t, bin, repo, "list")
+	if strings.Contains(stdout, "wiz-agent-test") {
+		t.Errorf("throwaway context left behind: %s", stdout)
+	}
+	cmd := exec.Command("git", "branch", "--list", "wiz-agent-test-*")
+	cmd.Dir = repo
+	if out, _ := cmd.Output(); len(strings.TrimSpace(string(out))) > 0 {
+		t.Errorf("throwaway branch left behind: %s", out)
+	}
+}
+
+func TestCreateWithPromptFile(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	os.MkdirAll(filepath.Join(repo, ".wiz", "prompts"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".wiz", "prompts", "bugfix.md"),
+		[]byte("Fix {{.Inputs.ticket}} on branch {{.Branch}} in {{.Repo}}"), 0o644)
+
+	_, stderr, err := runWiz(t, bin, repo, "create", "pf-test", "--prompt-file", "bugfix", "--input", "ticket=BUG-1")
+	if err != nil {
+		t.Fatalf("create: %v\n%s", err, stderr)
+	}
+
+	stdout, _, _ := runWiz(t, bin, repo, "list", "--json")
+	want := "Fix BUG-1 on branch pf-test in " + filepath.Base(repo)
+	if !strings.Contains(stdout, want) {
+		t.Errorf("rendered task missing %q: %s", want, stdout)
+	}
+
+	// A missing input is an error and leaves nothing behind.
+	_, _, err = runWiz(t, bin, repo, "create", "pf-bad", "--prompt-file", "bugfix")
+	if err == nil {
+		t.Error("expected error for missing template input")
+	}
+	stdout, _, _ = runWiz(t, bin, repo, "list")
+	if strings.Contains(stdout, "pf-bad") {
+		t.Errorf("failed create left a context: %s", stdout)
+	}
+
+	runWiz(t, bin, repo, "delete", "pf-test", "--force")
+
+	// Inline tasks are only templates when inputs are given.
+	if _, stderr, err := runWiz(t, bin, repo, "create", "literal", "--task", "Handle {{ in input"); err != nil {
+		t.Fatalf("create with braces: %v\n%s", err, stderr)
+	}
+	stdout, _, _ = runWiz(t, bin, repo, "list", "--json")
+	if !strings.Contains(stdout, `"Handle {{ in input"`) {
+		t.Errorf("inline task was rendered: %s", stdout)
+	}
+}
+
+func TestCreateWithInstructions(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	cfg := `{"ports_per_context": 2, "port_base": 5000}`
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+
+	_, stderr, err := runWiz(t, bin, repo, "create", "instr", "--agent", "claude", "--task", "Ship it", "--instructions")
+	if err != nil {
+		t.Fatalf("create: %v\n%s", err, stderr)
+	}
+	ctxPath, _, _ := runWiz(t, bin, repo, "path", "instr")
+	ctxPath = strings.TrimSpace(ctxPath)
+
+	data, err := os.ReadFile(filepath.Join(ctxPath, "CLAUDE.local.md"))
+	if err != nil {
+		t.Fatalf("instructions file missing: %v", err)
+	}
+	for _, want := range []string{"Ship it", "`instr`", "5000, 5001"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("instructions missing %q:\n%s", want, data)
+		}
+	}
+
+	// The generated file must not dirty the context.
+	stdout, _, _ := runWiz(t, bin, repo, "run", "instr", "--", "git", "status", "--porcelain")
+	if strings.TrimSpace(stdout) != "" {
+		t.Errorf("context dirty after instructions: %s", stdout)
+	}
+
+	stdout, _, _ = runWiz(t, bin, repo, "run", "instr", "--", "sh", "-c", "echo $WIZ_PORTS")
+	if strings.TrimSpace(stdout) != "5000,5001" {
+		t.Errorf("WIZ_PORTS = %q", stdout)
+	}
+
+	runWiz(t, bin, repo, "delete", "instr", "--force")
+}
+
+func TestReview(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	// A fake reviewer that ignores the prompt and answers with a fixed review.
+	script := filepath.Join(t.TempDir(), "reviewer.sh")
+	os.WriteFile(script, []byte(`#!/bin/sh
+echo 'Sure, here is the review:'
+echo '{"verdict": "request_changes", "summary": "Needs a test.", "issues": [{"file": "feature.txt", "line": 1, "severity": "warning", "message": "no newline"}]}'
+`), 0o755)
+	cfg := `{"reviewer": "fake", "agents": {"fake": {"command": "` + script + `"}}}`
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+
+	if _, stderr, err := runWiz(t, bin, repo, "create", "rev", "--base", "main", "--task", "Add a feature"); err != nil {
+		t.Fatalf("create: %v\n%s", err, stderr)
+	}
+	runWiz(t, bin, repo, "run", "rev", "--", "sh", "-c", "echo hi > feature.txt && git add . && git commit -qm feature")
+
+	// Free tier is refused.
+	t.Setenv("WIZ_LICENSE_KEY", "")
+	if _, stderr, err := runWiz(t, bin, repo, "review", "rev"); err == nil || !strings.Contains(stderr, "Wiz Team") {
+		t.Errorf("expected tier error, got %v: %s", err, stderr)
+	}
+
+	t.Setenv("WIZ_LICENSE_KEY", license.GenerateKey("test@example.com", license.TierTeam, time.Now().Add(time.Hour)))
+	stdout, stderr, err := runWiz(t, bin, repo, "review", "rev")
+	if err != nil {
+		t.Fatalf("review: %v\n%s", err, stderr)
+	}
+	for _, want := range []string{"**Verdict:** request_changes", "`feature.txt:1`", "Needs a test."} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("review missing %q:\n%s", want, stdout)
+		}
+	}
+
+	// The review is stored with the context.
+	out := filepath.Join(t.TempDir(), "review.json")
+	if _, stderr, err := runWiz(t, bin, repo, "review", "rev", "--show", "-o", out); err != nil {
+		t.Fatalf("review --show: %v\n%s", err, stderr)
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.Contains(string(data), `"verdict": "request_changes"`) || !strings.Contains(string(data), `"reviewer": "fake"`) {
+		t.Errorf("exported review: %s", data)
+	}
+
+	runWiz(t, bin, repo, "delete", "rev", "--force")
+}
+
+func TestCompare(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	cfg := `{"checks": [{"name": "fix", "run": "test -f fix.txt"}]}`
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+
+	runWiz(t, bin, repo, "create", "try-a", "--base", "main")
+	runWiz(t, bin, repo, "create", "try-b", "--base", "main", "--strategy", "clone")
+	runWiz(t, bin, repo, "run", "try-a", "--", "sh", "-c", "echo a > fix.txt && git add . && git commit -qm 'fix a'")
+	runWiz(t, bin, repo, "run", "try-b", "--", "sh", "-c", "echo b > other.txt && git add . && git -c user.name=t -c user.email=t@example.com commit -qm 'fix b'")
+
+	stdout, stderr, err := runWiz(t, bin, repo, "compare", "try-a", "try-b", "--json")
+	if err != nil {
+		t.Fatalf("compare: %v\n%s", err, stderr)
+	}
+	var result struct {
+		Attempts []struct {
+			Name    string
+			Commits []string
+			Files   []struct{ Path string }
+			Checks  []struct{ Passed bool }
+		}
+		Pairs []struct{ A, B, Stat string }
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	if len(result.Attempts) != 2 || len(result.Pairs) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	a, b := result.Attempts[0], result.Attempts[1]
+	if len(a.Commits) != 1 || len(a.Files) != 1 || a.Files[0].Path != "fix.txt" || len(a.Checks) != 1 || !a.Checks[0].Passed {
+		t.Errorf("try-a = %+v", a)
+	}
+	if len(b.Files) != 1 || b.Files[0].Path != "other.txt" || len(b.Checks) != 1 || b.Checks[0].Passed {
+		t.Errorf("try-b = %+v", b)
+	}
+	if !strings.Contains(result.Pairs[0].Stat, "fix.txt") || !strings.Contains(result.Pairs[0].Stat, "other.txt") {
+		t.Errorf("pair stat = %q", result.Pairs[0].Stat)
+	}
+
+	// No temporary refs for the clone context are left behind.
+	cmd := exec.Command("git", "for-each-ref", "refs/wiz")
+	cmd.Dir = repo
+	if out, _ := cmd.Output(); len(out) > 0 {
+		t.Errorf("compare refs left behind: %s", out)
+	}
+
+	if _, _, err := runWiz(t, bin, repo, "compare", "try-a", "try-b", "--pick", "nope"); err == nil {
+		t.Error("expected error picking a context that wasn't compared")
+	}
+
+	// --pick takes an alias and, with --local, needs no forge.
+	runWiz(t, bin, repo, "rename", "try-a", "try-a2")
+	stdout, stderr, err = runWiz(t, bin, repo, "compare", "try-a2", "try-b", "--pick", "try-a", "--local", "--squash")
+	if err != nil {
+		t.Fatalf("compare --pick --local: %v\n%s%s", err, stdout, stderr)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "fix.txt")); string(data) != "a\n" {
+		t.Errorf("fix.txt on main = %q", data)
+	}
+	if stdout, _, _ = runWiz(t, bin, repo, "list", "--json"); strings.Contains(stdout, "try-") {
+		t.Errorf("contexts left after --pick: %s", stdout)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	cfg := `{"checks": [{"name": "readme", "run": "test -f README.md"}, {"name": "fix", "run": "echo looking; test -f fix.txt"}]}`
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+
+	runWiz(t, bin, repo, "create", "chk-a")
+	runWiz(t, bin, repo, "create", "chk-b")
+	runWiz(t, bin, repo, "run", "chk-a", "--", "sh", "-c", "echo a > fix.txt")
+
+	stdout, _, err := runWiz(t, bin, repo, "check", "--all", "-j", "2")
+	if err == nil {
+		t.Error("expected failure when a context fails its checks")
+	}
+	if !strings.Contains(stdout, "chk-a") || !strings.Contains(stdout, "2/2 passed") || !strings.Contains(stdout, "1/2 passed") {
+		t.Errorf("check output:\n%s", stdout)
+	}
+
+	// Results and logs are persisted and shown by list.
+	stdout, _, _ = runWiz(t, bin, repo, "list")
+	if !strings.Contains(stdout, "checks: ✓ readme ✗ fix") {
+		t.Errorf("list missing check results:\n%s", stdout)
+	}
+	stdout, _, _ = runWiz(t, bin, repo, "list", "--json")
+	var contexts []struct {
+		Name   string
+		Checks []struct {
+			Name   string
+			Passed bool
+			Log    string
+		}
+	}
+	json.Unmarshal([]byte(stdout), &contexts)
+	for _, c := range contexts {
+		if c.Name != "chk-b" {
+			continue
+		}
+		if len(c.Checks) != 2 || c.Checks[1].Passed {
+			t.Fatalf("chk-b checks = %+v", c.Checks)
+		}
+		data, _ := os.ReadFile(c.Checks[1].Log)
+		if !strings.Contains(string(data), "looking") {
+			t.Errorf("log %s = %q", c.Checks[1].Log, data)
+		}
+	}
+
+	if _, stderr, err := runWiz(t, bin, repo, "check", "chk-a"); err != nil {
+		t.Errorf("check chk-a: %v\n%s", err, stderr)
+	}
+
+	// Logs follow a rename and go away with the context.
+	if _, stderr, err := runWiz(t, bin, repo, "rename", "chk-b", "chk-c"); err != nil {
+		t.Fatalf("rename: %v\n%s", err, stderr)
+	}
+	stdout, _, _ = runWiz(t, bin, repo, "list", "--json")
+	json.Unmarshal([]byte(stdout), &contexts)
+	var logs string
+	for _, c := range contexts {
+		if c.Name != "chk-c" {
+			continue
+		}
+		logs = filepath.Dir(c.Checks[1].Log)
+		if filepath.Base(logs) != "chk-c" {
+			t.Errorf("log after rename = %s", c.Checks[1].Log)
+		}
+		if data, _ := os.ReadFile(c.Checks[1].Log); !strings.Contains(string(data), "looking") {
+			t.Errorf("log %s after rename = %q", c.Checks[1].Log, data)
+		}
+	}
+
+	runWiz(t, bin, repo, "delete", "chk-a", "--force")
+	runWiz(t, bin, repo, "delete", "chk-c", "--force")
+	if _, err := os.Stat(logs); !os.IsNotExist(err) {
+		t.Errorf("logs %s left after delete", logs)
+	}
+}
+
+func TestSync(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	for _, name := range []string{"sync-ok", "sync-dirty", "sync-conflict"} {
+		if _, stderr, err := runWiz(t, bin, repo, "create", name, "--base", "main"); err != nil {
+			t.Fatalf("create %s: %v\n%s", name, err, stderr)
+		}
+	}
+	runWiz(t, bin, repo, "run", "sync-ok", "--", "sh", "-c", "echo ok > ok.txt && git add . && git commit -qm ok")
+	runWiz(t, bin, repo, "run", "sync-dirty", "--", "sh", "-c", "echo wip > wip.txt")
+	runWiz(t, bin, repo, "run", "sync-conflict", "--", "sh", "-c", "echo mine > README.md && git commit -qam mine")
+
+	// Move main ahead.
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("# updated\n"), 0o644)
+	run(t, repo, "git", "commit", "-qam", "update readme")
+
+	stdout, _, err := runWiz(t, bin, repo, "sync", "--all", "--json")
+	if err == nil {
+		t.Error("expected error when a context conflicts")
+	}
+	var results []struct {
+		Context   string
+		Result    string
+		Conflicts []string
+		Before    [2]int
+		After     [2]int
+	}
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.Context] = r.Result
+		switch r.Context {
+		case "sync-ok":
+			if r.Before != [2]int{1, 1} || r.After != [2]int{1, 0} {
+				t.Errorf("sync-ok before %v after %v", r.Before, r.After)
+			}
+		case "sync-conflict":
+			if len(r.Conflicts) != 1 || r.Conflicts[0] != "README.md" {
+				t.Errorf("sync-conflict conflicts = %v", r.Conflicts)
+			}
+		}
+	}
+	want := map[string]string{"sync-ok": "synced", "sync-dirty": "skipped", "sync-conflict": "conflict"}
+	for name, result := range want {
+		if got[name] != result {
+			t.Errorf("%s: result %q, want %q", name, got[name], result)
+		}
+	}
+
+	// Autostash syncs the dirty context and keeps its changes.
+	if _, stderr, err := runWiz(t, bin, repo, "sync", "sync-dirty", "--autostash"); err != nil {
+		t.Fatalf("sync --autostash: %v\n%s", err, stderr)
+	}
+	stdout, _, _ = runWiz(t, bin, repo, "run", "sync-dirty", "--", "sh", "-c", "cat README.md wip.txt")
+	if stdout != "# updated\nwip\n" {
+		t.Errorf("after autostash sync: %q", stdout)
+	}
+
+	for name := range want {
+		runWiz(t, bin, repo, "delete", name, "--force")
+	}
+}
+
+func TestStacks(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	commit := func(name, file, content string) {
+		t.Helper()
+		script := "echo " + content + " > " + file + " && git add . && git commit -qm " + file
+		if _, stderr, err := runWiz(t, bin, repo, "run", name, "--", "sh", "-c", script); err != nil {
+			t.Fatalf("commit in %s: %v\n%s", name, err, stderr)
+		}
+	}
+
+	runWiz(t, bin, repo, "create", "schema", "--base", "main")
+	commit("schema", "schema.txt", "v1")
+	if _, stderr, err := runWiz(t, bin, repo, "create", "api", "--on", "schema"); err != nil {
+		t.Fatalf("create --on: %v\n%s", err, stderr)
+	}
+	commit("api", "api.txt", "api")
+	runWiz(t, bin, repo, "create", "ui", "--on", "api")
+	commit("ui", "ui.txt", "ui")
+
+	if _, _, err := runWiz(t, bin, repo, "create", "bad", "--on", "schema", "--base", "main"); err == nil {
+		t.Error("expected --on and --base to conflict")
+	}
+
+	stdout, _, _ := runWiz(t, bin, repo, "list")
+	if !strings.Contains(stdout, "└─ \033[1;35mapi") || !strings.Contains(stdout, "   └─ \033[1;35mui") {
+		t.Errorf("list does not render the stack:\n%s", stdout)
+	}
+
+	// Rewrite the parent's commit; a plain rebase of api would now conflict.
+	runWiz(t, bin, repo, "run", "schema", "--", "sh", "-c", "echo v2 > schema.txt && git commit -qa --amend -m schema")
+
+	stdout, stderr, err := runWiz(t, bin, repo, "sync", "schema", "--no-fetch")
+	if err != nil {
+		t.Fatalf("sync: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "api") || !strings.Contains(stdout, "ui") {
+		t.Errorf("sync did not restack children:\n%s", stdout)
+	}
+	stdout, _, _ = runWiz(t, bin, repo, "run", "ui", "--", "sh", "-c", "cat schema.txt api.txt ui.txt && git log --format=%s main..HEAD")
+	if stdout != "v2\napi\nui\nui.txt\napi.txt\nschema\n" {
+		t.Errorf("ui after restack:\n%s", stdout)
+	}
+
+	for _, name := range []string{"ui", "api", "schema"} {
+		runWiz(t, bin, repo, "delete", name, "--force")
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	runWiz(t, bin, repo, "create", "left", "--base", "main")
+	runWiz(t, bin, repo, "create", "right", "--base", "main", "--strategy", "clone")
+	runWiz(t, bin, repo, "create", "docs", "--base", "main")
+	runWiz(t, bin, repo, "run", "left", "--", "sh", "-c", "echo left > README.md && git add . && git commit -qm left")
+	runWiz(t, bin, repo, "run", "right", "--", "sh", "-c", "echo right > README.md && git add . && git -c user.name=t -c user.email=t@example.com commit -qm right")
+	runWiz(t, bin, repo, "run", "docs", "--", "sh", "-c", "echo docs > DOCS.md && git add . && git commit -qm docs")
+
+	stdout, stderr, err := runWiz(t, bin, repo, "conflicts", "--json")
+	if err != nil {
+		t.Fatalf("conflicts: %v\n%s", err, stderr)
+	}
+	var pairs []struct {
+		A, B      string
+		Overlap   []string
+		Conflicts []string
+	}
+	if err := json.Unmarshal([]byte(stdout), &pairs); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	if len(pairs) != 1 {
+		t.Fatalf("pairs = %+v, want only left/right", pairs)
+	}
+	p := pairs[0]
+	if p.A != "left" || p.B != "right" || len(p.Conflicts) != 1 || p.Conflicts[0] != "README.md" {
+		t.Errorf("pair = %+v", p)
+	}
+
+	stdout, stderr, err = runWiz(t, bin, repo, "conflicts")
+	if err != nil {
+		t.Fatalf("conflicts: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "left ↔ right would conflict") || !strings.Contains(stderr, "Warning") {
+		t.Errorf("stdout = %q, stderr = %q", stdout, stderr)
+	}
+	if _, _, err := runWiz(t, bin, repo, "conflicts", "--exit-code"); err == nil {
+		t.Error("expected --exit-code to fail when contexts conflict")
+	}
+	if _, _, err := runWiz(t, bin, repo, "conflicts", "left", "docs", "--exit-code"); err != nil {
+		t.Errorf("left and docs should not conflict: %v", err)
+	}
+
+	for _, name := range []string{"left", "right", "docs"} {
+		runWiz(t, bin, repo, "delete", name, "--force")
+	}
+}
+
+func TestLand(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+
+	cfg := `{"checks": [{"name": "sane", "run": "test ! -f broken.txt"}]}`
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+
+	runWiz(t, bin, repo, "create", "schema", "--base", "main")
+	runWiz(t, bin, repo, "create", "api", "--on", "schema")
+	runWiz(t, bin, repo, "create", "bad", "--base", "main")
+	runWiz(t, bin, repo, "run", "schema", "--", "sh", "-c", "echo s > schema.txt && git add . && git commit -qm schema")
+	runWiz(t, bin, repo, "run", "api", "--", "sh", "-c", "echo a > api.txt && git add . && git commit -qm api")
+	runWiz(t, bin, repo, "run", "bad", "--", "sh", "-c", "echo x > broken.txt && git add . && git commit -qm bad")
+
+	type report struct {
+		Culprit string
+		Steps   []struct{ Context, Result string }
+	}
+	land := func(args ...string) (report, error) {
+		t.Helper()
+		stdout, stderr, err := runWiz(t, bin, repo, append([]string{"land", "--json"}, args...)...)
+		var r report
+		if jerr := json.Unmarshal([]byte(stdout), &r); jerr != nil {
+			t.Fatalf("parse: %v\n%s\n%s", jerr, stdout, stderr)
+		}
+		return r, err
+	}
+
+	// Parents land before the contexts stacked on them.
+	r, err := land("api", "schema", "--into", "integ-ok", "--cherry-pick")
+	if err != nil {
+		t.Fatalf("land: %v", err)
+	}
+	if len(r.Steps) != 2 || r.Steps[0].Context != "schema" || r.Steps[1].Result != "landed" {
+		t.Errorf("report = %+v", r)
+	}
+	integ, _, _ := runWiz(t, bin, repo, "path", "integ-ok")
+	if _, err := os.Stat(filepath.Join(strings.TrimSpace(integ), "api.txt")); err != nil {
+		t.Errorf("api.txt not landed: %v", err)
+	}
+
+	// Landing stops at the first failing check.
+	r, err = land("bad", "schema", "api", "--into", "integ-stop")
+	if err == nil {
+		t.Fatal("expected land to fail")
+	}
+	if r.Steps[0].Result != "checks-failed" || r.Steps[1].Result != "pending" {
+		t.Errorf("report = %+v", r)
+	}
+
+	// --bisect finds the context that broke the checks.
+	r, err = land("schema", "bad", "api", "--into", "integ-bisect", "--bisect")
+	if err == nil {
+		t.Fatal("expected land --bisect to fail")
+	}
+	if r.Culprit != "bad" {
+		t.Errorf("culprit = %q, report = %+v", r.Culprit, r)
+	}
+
+	if _, _, err := runWiz(t, bin, repo, "land", "schema", "--into", "integ-ok"); err == nil {
+		t.Error("expected error landing into an existing context")
+	}
+	for _, name := range []string{"integ-ok", "integ-stop", "integ-bisect", "api", "schema", "bad"} {
+		runWiz(t, bin, repo, "delete", name, "--force")
+	}
+}
+
+func TestFinishLocal(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	cfg := `{"checks": [{"name": "sane", "run": "test ! -f broken.txt"}]}`
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+
+	runWiz(t, bin, repo, "create", "feat", "--base", "main")
+	runWiz(t, bin, repo, "create", "sq", "--base", "main", "--strategy", "clone")
+	runWiz(t, bin, repo, "create", "bad", "--base", "main")
+	runWiz(t, bin, repo, "run", "feat", "--", "sh", "-c", "echo f > feat.txt && git add . && git commit -qm feat")
+	runWiz(t, bin, repo, "run", "sq", "--", "sh", "-c", "echo 1 > sq1.txt && git add . && git -c user.name=t -c user.email=t@example.com commit -qm sq1 && echo 2 > sq2.txt && git add . && git -c user.name=t -c user.email=t@example.com commit -qm sq2")
+	runWiz(t, bin, repo, "run", "bad", "--", "sh", "-c", "echo x > broken.txt && git add . && git commit -qm bad")
+
+	// A dirty base worktree is refused.
+	os.WriteFile(filepath.Join(repo, "scratch.txt"), []byte("wip\n"), 0o644)
+	if _, stderr, err := runWiz(t, bin, repo, "finish", "feat", "--local"); err == nil || !strings.Contains(stderr, "uncommitted") {
+		t.Fatalf("expected dirty base refusal, got err=%v stderr=%s", err, stderr)
+	}
+	os.Remove(filepath.Join(repo, "scratch.txt"))
+
+	if _, stderr, err := runWiz(t, bin, repo, "finish", "feat", "--local"); err != nil {
+		t.Fatalf("finish --local: %v\n%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "feat.txt")); err != nil {
+		t.Error("feat.txt not merged into main")
+	}
+	if branches := git("branch", "--list", "feat"); branches != "" {
+		t.Errorf("branch feat not deleted: %q", branches)
+	}
+	if _, _, err := runWiz(t, bin, repo, "path", "feat"); err == nil {
+		t.Error("context feat should be gone")
+	}
+
+	// Fast-forward fails once main has moved on; squash works.
+	if _, _, err := runWiz(t, bin, repo, "finish", "sq", "--local", "--ff-only"); err == nil {
+		t.Error("expected --ff-only to fail on a diverged branch")
+	}
+	before := git("rev-list", "--count", "HEAD")
+	if _, stderr, err := runWiz(t, bin, repo, "finish", "sq", "--local", "--squash"); err != nil {
+		t.Fatalf("finish --local --squash: %v\n%s", err, stderr)
+	}
+	if after := git("rev-list", "--count", "HEAD"); after != strconv.Itoa(mustAtoi(t, before)+1) {
+		t.Errorf("squash added %s-%s commits, want 1", after, before)
+	}
+	if git("log", "-1", "--format=%s") != "sq" {
+		t.Errorf("squash subject = %q", git("log", "-1", "--format=%s"))
+	}
+
+	// Failing checks block the merge.
+	if _, _, err := runWiz(t, bin, repo, "finish", "bad", "--local"); err == nil {
+		t.Error("expected failing checks to block finish --local")
+	}
+	if _, err := os.Stat(filepath.Join(repo, "broken.txt")); err == nil {
+		t.Error("bad was merged despite failing checks")
+	}
+	runWiz(t, bin, repo, "delete", "bad", "--force")
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// fakeGitHub serves the parts of the GitHub pulls and issues API wiz uses,
+// recording the last request body per method and path.
+func fakeGitHub(t *testing.T) (*httptest.Server, map[string]map[string]any) {
+	t.Helper()
+	requests := map[string]map[string]any{}
+	var pulls []map[string]any
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		requests[r.Method+" "+r.URL.Path] = body
+		const prefix = "/repos/acme/widgets/pulls"
+		rest := strings.TrimPrefix(r.URL.Path, prefix)
+		var pull map[string]any
+		if n, err := strconv.Atoi(strings.Split(strings.TrimPrefix(rest, "/"), "/")[0]); err == nil && n <= len(pulls) {
+			pull = pulls[n-1]
+		}
+		var resp any = map[string]any{}
+		switch {
+		case r.Method == "POST" && r.URL.Path == prefix:
+			pull = map[string]any{
+				"number": len(pulls) + 1, "html_url": fmt.Sprintf("https://github.com/acme/widgets/pull/%d", len(pulls)+1),
+				"title": body["title"], "state": "open", "draft": body["draft"],
+				"head": map[string]any{"ref": body["head"]}, "base": map[string]any{"ref": body["base"]},
+			}
+			pulls = append(pulls, pull)
+			resp = pull
+		case r.Method == "GET" && r.URL.Path == prefix:
+			open := []any{}
+			for _, p := range pulls {
+				if p["state"] == "open" && "acme:"+p["head"].(map[string]any)["ref"].(string) == r.URL.Query().Get("head") {
+					open = append(open, p)
+				}
+			}
+			resp = open
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/repos/acme/widgets/issues/"):
+			n := strings.TrimPrefix(r.URL.Path, "/repos/acme/widgets/issues/")
+			number, _ := strconv.Atoi(n)
+			resp = map[string]any{
+				"number": number, "html_url": "https://github.com/acme/widgets/issues/" + n,
+				"title": "Fix login crash", "body": "Crashes on empty {{password}}.", "state": "open",
+			}
+		case pull != nil && strings.HasSuffix(rest, "/reviews"):
+			resp = []any{}
+		case pull != nil && strings.HasSuffix(rest, "/merge"):
+			pull["merged"] = true
+		case pull != nil && r.Method == "PATCH":
+			for k, v := range body {
+				pull[k] = v
+			}
+			resp = pull
+		case pull != nil && r.Method == "GET":
+			resp = pull
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestCreateFromForge(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+	bare := filepath.Join(t.TempDir(), "widgets.git")
+	run(t, repo, "git", "init", "--bare", bare)
+	run(t, repo, "git", "remote", "add", "origin", "https://github.com/acme/widgets.git")
+	run(t, repo, "git", "config", "url."+bare+".insteadOf", "https://github.com/acme/widgets.git")
+
+	// A contributor's branch, published both as a branch and as the PR ref.
+	run(t, repo, "git", "checkout", "-q", "-b", "contrib")
+	os.WriteFile(filepath.Join(repo, "contrib.txt"), []byte("contributed\n"), 0o644)
+	run(t, repo, "git", "add", ".")
+	run(t, repo, "git", "commit", "-qm", "contribution")
+	run(t, repo, "git", "push", "-q", "origin", "main", "contrib", "contrib:refs/pull/1/head")
+	run(t, repo, "git", "checkout", "-q", "main")
+	run(t, repo, "git", "branch", "-q", "-D", "contrib")
+
+	srv, _ := fakeGitHub(t)
+	cfg := fmt.Sprintf(`{"forge": {"type": "github", "api_url": %q}}`, srv.URL)
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+	t.Setenv("GITHUB_TOKEN", "secret")
+	resp, err := http.Post(srv.URL+"/repos/acme/widgets/pulls", "application/json",
+		strings.NewReader(`{"title": "Add contribution", "head": "contrib", "base": "main"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// A create that fails after fetching the PR leaves no branch behind.
+	if _, _, err := runWiz(t, bin, repo, "create", "--from-pr", "1", "--task", "{{.Inputs.missing}}", "--input", "a=b"); err == nil {
+		t.Fatal("expected create with a bad prompt to fail")
+	}
+	if exec.Command("git", "-C", repo, "rev-parse", "--verify", "refs/heads/contrib").Run() == nil {
+		t.Fatal("failed create left the fetched branch behind")
+	}
+
+	stdout, stderr, err := runWiz(t, bin, repo, "create", "--from-pr", "https://github.com/acme/widgets/pull/1")
+	if err != nil {
+		t.Fatalf("create --from-pr: %v\n%s%s", err, stdout, stderr)
+	}
+	path, _, _ := runWiz(t, bin, repo, "path", "contrib")
+	if _, err := os.Stat(filepath.Join(strings.TrimSpace(path), "contrib.txt")); err != nil {
+		t.Errorf("context is not at the PR head: %v", err)
+	}
+	out, _ := exec.Command("git", "-C", repo, "rev-parse", "--abbrev-ref", "contrib@{upstream}").Output()
+	if upstream := strings.TrimSpace(string(out)); upstream != "origin/contrib" {
+		t.Errorf("upstream = %q", upstream)
+	}
+
+	stdout, stderr, err = runWiz(t, bin, repo, "create", "--from-issue", "42")
+	if err != nil {
+		t.Fatalf("create --from-issue: %v\n%s%s", err, stdout, stderr)
+	}
+	stdout, _, _ = runWiz(t, bin, repo, "list", "--json")
+	var contexts []struct {
+		Name, Branch, Task string
+		BaseBranch         string `json:"base_branch"`
+		PR                 *struct{ Number int }
+	}
+	json.Unmarshal([]byte(stdout), &contexts)
+	if len(contexts) != 2 {
+		t.Fatalf("contexts = %s", stdout)
+	}
+	pr, issue := contexts[0], contexts[1]
+	if pr.Name != "contrib" || pr.BaseBranch != "main" || pr.PR == nil || pr.PR.Number != 1 || pr.Task != "Add contribution" {
+		t.Errorf("PR context = %+v", pr)
+	}
+	if issue.Name != "42-fix-login-crash" || issue.Branch != "42-fix-login-crash" ||
+		issue.Task != "Fix login crash\n\nCrashes on empty {{password}}.\n\nhttps://github.com/acme/widgets/issues/42" {
+		t.Errorf("issue context = %+v", issue)
+	}
+
+	// A PR whose head is named like an existing local branch, as for a fork's
+	// main, gets a branch of its own and leaves the local one alone.
+	mainHead, _ := exec.Command("git", "-C", repo, "rev-parse", "main").Output()
+	run(t, repo, "git", "push", "-q", "origin", "origin/contrib:refs/pull/2/head")
+	resp, err = http.Post(srv.URL+"/repos/acme/widgets/pulls", "application/json",
+		strings.NewReader(`{"title": "Fork contribution", "head": "main", "base": "main"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	stdout, stderr, err = runWiz(t, bin, repo, "create", "--from-pr", "2")
+	if err != nil {
+		t.Fatalf("create --from-pr 2: %v\n%s%s", err, stdout, stderr)
+	}
+	if after, _ := exec.Command("git", "-C", repo, "rev-parse", "main").Output(); string(after) != string(mainHead) {
+		t.Error("create --from-pr moved the local main branch")
+	}
+	path, _, _ = runWiz(t, bin, repo, "path", "pr-2")
+	if _, err := os.Stat(filepath.Join(strings.TrimSpace(path), "contrib.txt")); err != nil {
+		t.Errorf("pr-2 is not at the PR head: %v", err)
+	}
+	if out, err := exec.Command("git", "-C", repo, "rev-parse", "--abbrev-ref", "pr-2@{upstream}").Output(); err == nil {
+		t.Errorf("pr-2 tracks %s", out)
+	}
+
+	if _, _, err := runWiz(t, bin, repo, "create"); err == nil {
+		t.Error("expected create without a name to fail")
+	}
+}
+
+func TestFinishForge(t *testing.T) {
+	bin := buildWiz(t)
+	repo := setupTestRepo(t)
+	bare := filepath.Join(t.TempDir(), "widgets.git")
+	run(t, repo, "git", "init", "--bare", bare)
+	run(t, repo, "git", "remote", "add", "origin", "https://github.com/acme/widgets.git")
+	run(t, repo, "git", "config", "remote.origin.pushurl", bare)
+
+	srv, requests := fakeGitHub(t)
+	cfg := fmt.Sprintf(`{"forge": {"type": "github", "api_url": %q}}`, srv.URL)
+	os.MkdirAll(filepath.Join(repo, ".git", "wiz"), 0o755)
+	os.WriteFile(filepath.Join(repo, ".git", "wiz", "config.json"), []byte(cfg), 0o644)
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	runWiz(t, bin, repo, "create", "feat", "--base", "main", "--task", "Add the feature")
+	runWiz(t, bin, repo, "create", "cl", "--base", "main", "--strategy", "clone")
+	runWiz(t, bin, repo, "run", "feat", "--", "sh", "-c", "echo f > feat.txt && git add . && git commit -qm feat")
+	runWiz(t, bin, repo, "run", "cl", "--", "sh", "-c", "echo c > cl.txt && git add . && git -c user.name=t -c user.email=t@example.com commit -qm cl")
+
+	stdout, stderr, err := runWiz(t, bin, repo, "finish", "feat")
+	if err != nil {
+		t.Fatalf("finish: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "https://github.com/acme/widgets/pull/1") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	req := requests["POST /repos/acme/widgets/pulls"]
+	if req["head"] != "feat" || req["base"] != "main" || req["title"] != "feat" || req["body"] != "Add the feature" {
+		t.Errorf("create PR request = %v", req)
+	}
+
+	// The context is kept with its PR recorded.
+	stdout, _, _ = runWiz(t, bin, repo, "list", "--json")
+	if !strings.Contains(stdout, `"url": "https://github.com/acme/widgets/pull/1"`) {
+		t.Errorf("PR not recorded on the context: %s", stdout)
+	}
+
+	// wiz pr polls the PR and shows it in wiz list.
+	stdout, stderr, err = runWiz(t, bin, repo, "pr", "feat")
+	if err != nil || !strings.Contains(stdout, "feat: #1 open") {
+		t.Errorf("pr: %v\n%s%s", err, stdout, stderr)
+	}
+	if stdout, _, _ := runWiz(t, bin, repo, "list"); !strings.Contains(stdout, "pr:     #1 open") {
+		t.Errorf("list:\n%s", stdout)
+	}
+	stdout, _, err = runWiz(t, bin, repo, "pr", "--all", "--cached", "--json")
+	var prs []struct {
+		Context string
+		PR      *struct{ Number int }
+	}
+	if err := json.Unmarshal([]byte(stdout), &prs); err != nil || len(prs) != 2 {
+		t.Fatalf("pr --all --json: %v\n%s", err, stdout)
+	}
+	for _, p := range prs {
+		if (p.PR != nil) != (p.Context == "feat") {
+			t.Errorf("pr --all --json: %s has PR %v", p.Context, p.PR)
+		}
+	}
+
+	// A second finish updates the open PR instead of opening another.
+	runWiz(t, bin, repo, "run", "feat", "--", "sh", "-c", "echo g > fix.txt && git add . && git commit -qm fix")
+	stdout, stderr, err = runWiz(t, bin, repo, "finish", "feat", "--title", "Feature", "--label", "wiz", "--reviewer", "bob")
+	if err != nil {
+		t.Fatalf("finish again: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "PR updated") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if req := requests["PATCH /repos/acme/widgets/pulls/1"]; req["title"] != "Feature" {
+		t.Errorf("update PR request = %v", req)
+	}
+	if req := requests["POST /repos/acme/widgets/issues/1/labels"]; req == nil {
+		t.Errorf("labels not added; requests = %v", requests)
+	}
+
+	// --dry-run changes nothing.
+	delete(requests, "POST /repos/acme/widgets/pulls")
+	stdout, _, err = runWiz(t, bin, repo, "finish", "cl", "--draft", "--dry-run")
+	if err != nil || !strings.Contains(stdout, "open a github draft PR cl → main: cl") {
+		t.Errorf("dry run: %v\n%s", err, stdout)
+	}
+	if _, ok := requests["POST /repos/acme/widgets/pulls"]; ok {
+		t.Error("dry run opened a PR")
+	}
+	out, _ := exec.Command("git", "--git-dir", bare, "branch", "--list").Output()
+	if strings.Contains(string(out), "cl") {
+		t.Errorf("dry run pushed: %q", out)
+	}
+
+	// Clone contexts are pushed from the main repository, without touching
+	// its branch of the same name.
+	run(t, repo, "git", "branch", "cl", "main")
+	mainHead, _ := exec.Command("git", "-C", repo, "rev-parse", "main").Output()
+	if _, stderr, err := runWiz(t, bin, repo, "finish", "cl", "--merge", "--squash"); err != nil {
+		t.Fatalf("finish cl: %v\n%s", err, stderr)
+	}
+	if head, _ := exec.Command("git", "-C", repo, "rev-parse", "cl").Output(); string(head) != string(mainHead) {
+		t.Error("finish moved the main repository's cl branch")
+	}
+	if refs, _ := exec.Command("git", "-C", repo, "for-each-ref", "refs/wiz/tmp").Output(); len(refs) > 0 {
+		t.Errorf("temporary refs left: %s", refs)
+	}
+	if req := requests["PUT /repos/acme/widgets/pulls/2/merge"]; req["merge_method"] != "squash" {
+		t.Errorf("merge request = %v", req)
+	}
+	if _, _, err := runWiz(t, bin, repo, "path", "cl"); err == nil {
+		t.Error("merged context was kept")
+	}
+	out, _ = exec.Command("git", "--git-dir", bare, "branch", "--list").Output()
+	if !strings.Contains(string(out), "feat") {
+		t.Errorf("pushed branches = %q, want feat", out)
+	}
+
+	if _, _, err := runWiz(t, bin, repo, "finish", "feat", "--squash"); err == nil {
+		t.Error("expected --squash without --merge to fail")
+	}
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/compare"
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/spf13/cobra"
+)
+
+// comparePair is the diff between two attempts' trees.
+type comparePair struct {
+	A    string `json:"a"`
+	B    string `json:"b"`
+	Stat string `json:"stat"`
+}
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <name> <name> [name...]",
+	Short: "Compare competing attempts at the same task",
+	Long: `Show commits, diffstat, files touched and check results for several contexts
+side by side, followed by a pairwise diff between their trees.
+
+The checks configured for wiz check are run in each context and their results
+stored as by wiz check. Use --pick to finish the winning context (see wiz
+finish) and delete the others; --merge, --local, --squash and --rebase choose
+how, as for wiz finish.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		noChecks, _ := cmd.Flags().GetBool("no-checks")
+		pick, _ := cmd.Flags().GetString("pick")
+		merge, _ := cmd.Flags().GetBool("merge")
+		local, _ := cmd.Flags().GetBool("local")
+		squash, _ := cmd.Flags().GetBool("squash")
+		rebase, _ := cmd.Flags().GetBool("rebase")
+
+		if pick == "" && (merge || local || squash || rebase) {
+			return fmt.Errorf("--merge, --local, --squash and --rebase require --pick")
+		}
+		if local && (merge || rebase) {
+			return fmt.Errorf("--merge and --rebase apply to PRs, not --local")
+		}
+		if !local && !merge && (squash || rebase) {
+			return fmt.Errorf("--squash and --rebase choose how --merge (or --local) merges")
+		}
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+
+		var f forge.Forge
+		contexts := make([]*wizctx.Context, len(args))
+		for i, name := range args {
+			c, err := activeContext(store, name)
+			if err != nil {
+				return err
+			}
+			contexts[i] = c
+		}
+		if pick != "" {
+			// --pick may name the winner by an alias.
+			if c, err := store.Resolve(pick); err == nil {
+				pick = c.Name
+			}
+			found := false
+			for _, c := range contexts {
+				found = found || c.Name == pick
+			}
+			if !found {
+				return fmt.Errorf("--pick %q is not one of the compared contexts", pick)
+			}
+			if !local {
+				if f, err = openForge(cmd, repo); err != nil {
+					return fmt.Errorf("%w\n\n  Or merge locally: add --local", err)
+				}
+			}
+		}
+
+		current, _ := repo.CurrentBranch(cmd.Context())
+		tips := make([]string, len(contexts))
+		attempts := make([]*compare.Attempt, len(contexts))
+		for i, c := range contexts {
+			tip, err := wizctx.Tip(cmd.Context(), repo, c)
+			if err != nil {
+				return err
+			}
+			tips[i] = tip
+
+			base := c.BaseBranch
+			if base == "" {
+				base = current
+			}
+			a, err := compare.Collect(cmd.Context(), repo.WorkDir, c.Name, base, tip)
+			if err != nil {
+				return fmt.Errorf("compare %s: %w", c.Name, err)
+			}
+			a.Branch = c.Branch
+			attempts[i] = a
+		}
+		if !noChecks {
+			if err := compareChecks(cmd, store, repo, contexts, attempts); err != nil {
+				return err
+			}
+		}
+
+		var pairs []comparePair
+		for i := range contexts {
+			for j := i + 1; j < len(contexts); j++ {
+				stat, err := compare.DiffStat(cmd.Context(), repo.WorkDir, tips[i], tips[j])
+				if err != nil {
+					return err
+				}
+				pairs = append(pairs, comparePair{A: contexts[i].Name, B: contexts[j].Name, Stat: stat})
+			}
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(struct {
+				Attempts []*compare.Attempt `json:"attempts"`
+				Pairs    []comparePair      `json:"pairs"`
+			}{attempts, pairs}); err != nil {
+				return err
+			}
+		} else {
+			printComparison(cmd, attempts, pairs)
+		}
+
+		if pick == "" {
+			return nil
+		}
+		winner, err := store.Get(pick)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\n\U0001f9d9 Picking %s\n", pick)
+		if local {
+			mode := "merge"
+			if squash {
+				mode = "squash"
+			}
+			err = finishLocal(cmd, store, repo, winner, localFinish{Mode: mode, RunChecks: !noChecks})
+		} else {
+			err = finishContext(cmd, store, repo, f, winner, prFinish{Merge: merge, Method: mergeMethod(squash, rebase)})
+		}
+		if err != nil {
+			return err
+		}
+		for _, c := range contexts {
+			if c.Name == pick {
+				continue
+			}
+			if err := deleteContext(cmd, store, repo, c.Name, true); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "FAIL %s: %v\n", c.Name, err)
+				continue
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Deleted context: %s\n", c.Name)
+		}
+		return nil
+	},
+}
+
+// compareChecks runs each context's configured checks, in parallel across
+// contexts, and stores the results with the contexts as wiz check does.
+func compareChecks(cmd *cobra.Command, store *wizctx.Store, repo *gitx.Repo, contexts []*wizctx.Context, attempts []*compare.Attempt) error {
+	var jobs []check.Job
+	idx := make(map[string]int)
+	for i, c := range contexts {
+		checks, err := check.ForTemplate(repo, c.Template)
+		if err != nil {
+			return err
+		}
+		if len(checks) == 0 {
+			continue
+		}
+		idx[c.Name] = i
+		jobs = append(jobs, check.Job{Context: c.Name, Dir: c.Path, Checks: checks})
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "\U0001f9d9 Running checks in %d context(s)...\n", len(jobs))
+	check.RunAll(cmd.Context(), jobs, config.ChecksDir(repo), runtime.NumCPU(), func(job check.Job, rs []check.Result) {
+		attempts[idx[job.Context]].Checks = rs
+		if err := store.Update(cmd.Context(), job.Context, func(c *wizctx.Context) {
+			c.Checks = rs
+		}); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: save results for %s: %v\n", job.Context, err)
+		}
+	})
+	return nil
+}
+
+func printComparison(cmd *cobra.Command, attempts []*compare.Attempt, pairs []comparePair) {
+	out := cmd.OutOrStdout()
+	tw := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	row := func(label string, cell func(a *compare.Attempt) string) {
+		cells := []string{label}
+		for _, a := range attempts {
+			cells = append(cells, cell(a))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	row("", func(a *compare.Attempt) string { return a.Name })
+	row("branch", func(a *compare.Attempt) string { return a.Branch })
+	row("base", func(a *compare.Attempt) string { return a.Base })
+	row("head", func(a *compare.Attempt) string { return a.Head })
+	row("commits", func(a *compare.Attempt) string { return fmt.Sprint(len(a.Commits)) })
+	row("files", func(a *compare.Attempt) string { return fmt.Sprint(len(a.Files)) })
+	row("lines", func(a *compare.Attempt) string { return fmt.Sprintf("+%d -%d", a.Added, a.Deleted) })
+	if slices.ContainsFunc(attempts, func(a *compare.Attempt) bool { return len(a.Checks) > 0 }) {
+		row("checks", func(a *compare.Attempt) string { return check.Summary(a.Checks) })
+	}
+	tw.Flush()
+
+	for _, a := range attempts {
+		fmt.Fprintf(out, "\n\033[1;35m%s\033[0m (%s)\n", a.Name, a.Branch)
+		if len(a.Commits) == 0 {
+			fmt.Fprintf(out, "  no commits\n")
+		}
+		for _, c := range a.Commits {
+			fmt.Fprintf(out, "  %s\n", c)
+		}
+		for _, f := range a.Files {
+			if f.Binary {
+				fmt.Fprintf(out, "    %s (binary)\n", f.Path)
+			} else {
+				fmt.Fprintf(out, "    %s (+%d -%d)\n", f.Path, f.Added, f.Deleted)
+			}
+		}
+		for _, r := range a.Checks {
+			if !r.Passed {
+				fmt.Fprintf(out, "  \033[31m✗\033[0m %s (exit %d)  log: %s\n", r.Name, r.ExitCode, r.Log)
+			}
+		}
+	}
+
+	for _, p := range pairs {
+		fmt.Fprintf(out, "\n\033[1m%s ↔ %s\033[0m\n", p.A, p.B)
+		if p.Stat == "" {
+			fmt.Fprintf(out, "  identical trees\n")
+			continue
+		}
+		for _, line := range strings.Split(p.Stat, "\n") {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+}
+
+func init() {
+	compareCmd.Flags().Bool("json", false, "Output as JSON")
+	compareCmd.Flags().Bool("no-checks", false, "Skip the configured checks")
+	compareCmd.Flags().String("pick", "", "Finish this context and delete the others")
+	compareCmd.Flags().Bool("merge", false, "With --pick, also merge the winner's PR")
+	compareCmd.Flags().Bool("local", false, "With --pick, merge the winner into its base branch locally instead of opening a PR")
+	compareCmd.Flags().Bool("squash", false, "With --pick, squash the winner into one commit when merging")
+	compareCmd.Flags().Bool("rebase", false, "With --pick and --merge, rebase-merge the PR")
+	rootCmd.AddCommand(compareCmd)
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buck3000/wiz/internal/conflict"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/spf13/cobra"
+)
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts [name...]",
+	Short: "Forecast merge conflicts between contexts",
+	Long: `For every pair of contexts (all of them by default), list the files both
+change and trial-merge their branches with git merge-tree to predict
+whether merging them would conflict. Nothing in any context is modified.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		exitCode, _ := cmd.Flags().GetBool("exit-code")
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+
+		var contexts []wizctx.Context
+		if len(args) == 0 {
+			contexts, err = store.List()
+			if err != nil {
+				return err
+			}
+			contexts = wizctx.Active(contexts)
+		} else {
+			for _, name := range args {
+				c, err := activeContext(store, name)
+				if err != nil {
+					return err
+				}
+				contexts = append(contexts, *c)
+			}
+		}
+
+		branches, err := forecastBranches(cmd, repo, contexts)
+		if err != nil {
+			return err
+		}
+		pairs, err := conflict.NewForecaster(repo.WorkDir).Forecast(cmd.Context(), branches)
+		if err != nil {
+			return err
+		}
+
+		conflicting := 0
+		for _, p := range pairs {
+			if p.Conflicting() {
+				conflicting++
+			}
+		}
+
+		if asJSON {
+			if pairs == nil {
+				pairs = []conflict.Pair{}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(pairs); err != nil {
+				return err
+			}
+		} else {
+			printForecast(cmd, pairs, len(contexts))
+		}
+
+		if conflicting > 0 && exitCode {
+			return fmt.Errorf("%d pairs of contexts would conflict", conflicting)
+		}
+		return nil
+	},
+}
+
+// forecastBranches resolves each context's tip and base for forecasting.
+func forecastBranches(cmd *cobra.Command, repo *gitx.Repo, contexts []wizctx.Context) ([]conflict.Branch, error) {
+	current, _ := repo.CurrentBranch(cmd.Context())
+	branches := make([]conflict.Branch, 0, len(contexts))
+	for i := range contexts {
+		c := &contexts[i]
+		tip, err := wizctx.Tip(cmd.Context(), repo, c)
+		if err != nil {
+			return nil, err
+		}
+		base := c.BaseBranch
+		if base == "" {
+			base = current
+		}
+		branches = append(branches, conflict.Branch{Name: c.Name, Tip: tip, Base: base})
+	}
+	return branches, nil
+}
+
+func printForecast(cmd *cobra.Command, pairs []conflict.Pair, n int) {
+	out := cmd.OutOrStdout()
+	conflicting := 0
+	for _, p := range pairs {
+		if !p.Conflicting() {
+			continue
+		}
+		conflicting++
+		fmt.Fprintf(out, "\033[31m⚠ %s ↔ %s would conflict in %d file(s):\033[0m\n", p.A, p.B, len(p.Conflicts))
+		for _, f := range p.Conflicts {
+			fmt.Fprintf(out, "    %s\n", f)
+		}
+	}
+	for _, p := range pairs {
+		if p.Conflicting() {
+			continue
+		}
+		fmt.Fprintf(out, "~ %s ↔ %s both change %s (merges cleanly)\n", p.A, p.B, strings.Join(p.Overlap, ", "))
+	}
+	if len(pairs) == 0 {
+		fmt.Fprintf(out, "\U0001f9d9 No overlapping changes among %d contexts.\n", n)
+	} else if conflicting > 0 {
+		fmt.Fprintf(cmd.ErrOrStderr(), "\nWarning: %d pair(s) of contexts would conflict if merged.\n", conflicting)
+	}
+}
+
+func init() {
+	conflictsCmd.Flags().Bool("json", false, "Output as JSON")
+	conflictsCmd.Flags().Bool("exit-code", false, "Exit with an error when any pair would conflict")
+	rootCmd.AddCommand(conflictsCmd)
+}
@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/buck3000/wiz/internal/config"
 	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
 	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/instructions"
 	"github.com/buck3000/wiz/internal/license"
+	"github.com/buck3000/wiz/internal/prompt"
 	"github.com/buck3000/wiz/internal/template"
 	"github.com/spf13/cobra"
 )
@@ -14,32 +20,84 @@ import (
 var createCmd = &cobra.Command{
 	Use:   "create <name>",
 	Short: "Create a new context",
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new context: a worktree or clone on its own branch.
+
+--from-pr checks out a pull request's head branch (fork PRs included) in
+the new context, based on the PR's base branch. --from-issue derives the
+context name, branch and task from an issue's number, title and body. The
+name argument is optional with either; the forge is the one wiz finish
+uses.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		name := args[0]
-		if err := wizctx.ValidateName(name); err != nil {
-			return err
+		fromPR, _ := cmd.Flags().GetString("from-pr")
+		fromIssue, _ := cmd.Flags().GetString("from-issue")
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		} else if fromPR == "" && fromIssue == "" {
+			return fmt.Errorf("give the new context a name (or use --from-pr / --from-issue)")
 		}
 
 		base, _ := cmd.Flags().GetString("base")
 		strategyStr, _ := cmd.Flags().GetString("strategy")
 		task, _ := cmd.Flags().GetString("task")
+		promptFile, _ := cmd.Flags().GetString("prompt-file")
+		inputs, _ := cmd.Flags().GetStringToString("input")
 		agent, _ := cmd.Flags().GetString("agent")
 		tmplName, _ := cmd.Flags().GetString("template")
+		on, _ := cmd.Flags().GetString("on")
+		branchFlag, _ := cmd.Flags().GetString("branch")
+		if on != "" && base != "" {
+			return fmt.Errorf("--on and --base are mutually exclusive; a stacked context is based on its parent's branch")
+		}
 
 		repo, err := gitx.Discover(".")
 		if err != nil {
 			return err
 		}
 
+		// Look up the PR or issue the context starts from. Its text becomes
+		// the task verbatim, without prompt template rendering.
+		var src *contextSource
+		if fromPR != "" || fromIssue != "" {
+			if on != "" {
+				return fmt.Errorf("--on cannot be combined with --from-pr or --from-issue")
+			}
+			if fromPR != "" && branchFlag != "" {
+				return fmt.Errorf("--branch cannot be combined with --from-pr; the context uses the PR's branch")
+			}
+			f, err := openForge(cmd, repo)
+			if err != nil {
+				return err
+			}
+			if fromPR != "" {
+				src, err = sourceFromPR(cmd, repo, f, fromPR)
+			} else {
+				src, err = sourceFromIssue(cmd, f, fromIssue)
+			}
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				name = src.Name
+			}
+			if base == "" {
+				base = src.Base
+			}
+		}
+		if err := wizctx.ValidateName(name); err != nil {
+			return err
+		}
+
 		// Apply template defaults (explicit flags override).
+		cfg := config.Load(repo)
 		if tmplName != "" {
 			tmplStore := template.NewStore(repo)
 			tmpl, err := tmplStore.Get(tmplName)
 			if err != nil {
 				return err
 			}
-			if base == "" {
+			if base == "" && on == "" {
 				base = tmpl.Base
 			}
 			if strategyStr == "" || strategyStr == "auto" {
@@ -52,28 +110,72 @@ var createCmd = &cobra.Command{
 			}
 		}
 
+		task, err = promptText(repo, task, promptFile)
+		if err != nil {
+			return err
+		}
+
 		if !repo.HasCommits(cmd.Context()) {
 			return fmt.Errorf("repository has no commits; create an initial commit before using wiz")
 		}
 
 		store := wizctx.NewStore(repo)
 
-		// Enforce context limit based on license tier.
-		tier, _ := license.CheckLicense()
 		existing, err := store.List()
 		if err != nil {
 			return err
 		}
-		if err := license.CheckContextLimit(tier, len(existing)); err != nil {
-			limErr := err.(*license.ContextLimitErr)
-			return fmt.Errorf("%s\n\n  Upgrade to Wiz Pro for unlimited contexts:\n    https://wiz.dev/pro\n\n  Or free up a slot:\n    wiz delete <name>\n    wiz gc --merged\n\n  Current contexts: %d/%d",
-				limErr, limErr.Current, limErr.Max)
+		if err := checkContextLimit(existing); err != nil {
+			return err
+		}
+
+		// Stack on a parent context: branch from its branch and remember
+		// which parent commit we started from so sync can restack.
+		var parentHead string
+		if on != "" {
+			parent, err := store.Get(on)
+			if err != nil {
+				return fmt.Errorf("parent context %q not found; run 'wiz list' to see available contexts", on)
+			}
+			if parent.Strategy == wizctx.StrategyClone {
+				return fmt.Errorf("cannot stack on %q: clone contexts keep their branch outside the main repository", on)
+			}
+			base = parent.Branch
+			parentHead, err = repo.Run(cmd.Context(), "rev-parse", parent.Branch)
+			if err != nil {
+				return err
+			}
+		}
+
+		branch := ""
+		if src != nil && src.Branch != "" {
+			branch = src.Branch
+		} else if branch, err = wizctx.BranchName(cmd.Context(), repo, branchFlag, branchTemplate(repo, cfg, tmplName), name); err != nil {
+			return err
+		}
+		fetched := false
+		if src != nil && src.PR != nil {
+			if err := fetchPRHead(cmd, repo, src); err != nil {
+				return err
+			}
+			fetched = true
 		}
 
 		strategy := wizctx.ParseStrategy(strategyStr)
 		prov := wizctx.NewProvisioner(strategy, repo)
 
-		branch := name
+		// fail undoes what this run created: the working directory, if
+		// any, and the branch fetched for a PR.
+		fail := func(path string, err error) error {
+			if path != "" {
+				prov.Destroy(cmd.Context(), path, true)
+			}
+			if fetched {
+				repo.Run(cmd.Context(), "branch", "-D", branch)
+			}
+			return err
+		}
+
 		path, err := prov.Create(cmd.Context(), wizctx.CreateOpts{
 			Name:       name,
 			Branch:     branch,
@@ -81,35 +183,234 @@ var createCmd = &cobra.Command{
 			Repo:       repo,
 		})
 		if err != nil {
-			return err
+			return fail("", err)
 		}
 
-		err = store.Add(cmd.Context(), wizctx.Context{
+		c := wizctx.Context{
 			Name:       name,
 			Branch:     branch,
 			Path:       path,
 			Strategy:   prov.Strategy(),
 			CreatedAt:  time.Now(),
 			BaseBranch: base,
-			Task:       task,
 			Agent:      agent,
-		})
+			Template:   tmplName,
+			Parent:     on,
+			ParentHead: parentHead,
+		}
+		c.Task, err = renderPrompt(cmd, repo, &c, task, promptFile, inputs)
+		if err != nil {
+			return fail(path, err)
+		}
+		if src != nil {
+			c.PR = src.PR
+			if c.Task == "" {
+				c.Task = src.Task
+			}
+		}
+
+		err = store.AddWithPorts(cmd.Context(), &c, cfg.PortBase, cfg.PortsPerContext)
 		if err != nil {
 			// Clean up on store failure.
-			prov.Destroy(cmd.Context(), path, true)
-			return err
+			return fail(path, err)
+		}
+
+		writeInstructions := cfg.Instructions.Enabled
+		if cmd.Flags().Changed("instructions") {
+			writeInstructions, _ = cmd.Flags().GetBool("instructions")
+		}
+		if writeInstructions {
+			if _, err := instructions.Apply(cmd.Context(), repo, cfg, &c); err != nil {
+				store.Remove(cmd.Context(), name)
+				return fail(path, err)
+			}
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Created context: %s\n", name)
+		if branch != name {
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Created context: %s (branch %s)\n", name, branch)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Created context: %s\n", name)
+		}
 		return nil
 	},
 }
 
+// checkContextLimit enforces the context limit of the license tier.
+func checkContextLimit(existing []wizctx.Context) error {
+	tier, _ := license.CheckLicense()
+	if err := license.CheckContextLimit(tier, len(wizctx.Active(existing))); err != nil {
+		limErr := err.(*license.ContextLimitErr)
+		return fmt.Errorf("%s\n\n  Upgrade to Wiz Pro for unlimited contexts:\n    https://wiz.dev/pro\n\n  Or free up a slot:\n    wiz delete <name>\n    wiz archive <name>\n    wiz gc --merged\n\n  Current contexts: %d/%d",
+			limErr, limErr.Current, limErr.Max)
+	}
+	return nil
+}
+
+// branchTemplate returns the branch_template for contexts from the named
+// template: the template's own if it has one, otherwise the configured one.
+func branchTemplate(repo *gitx.Repo, cfg config.Config, tmplName string) string {
+	if tmplName != "" {
+		if t, err := template.NewStore(repo).Get(tmplName); err == nil && t.BranchTemplate != "" {
+			return t.BranchTemplate
+		}
+	}
+	return cfg.BranchTemplate
+}
+
+// contextSource is what a context created from a PR or issue starts from.
+type contextSource struct {
+	Name   string
+	Branch string // for a PR, the new local branch for its head
+	Base   string
+	Task   string
+	PR     *forge.PR
+	kind   string // forge kind, for the PR's head ref
+}
+
+// sourceFromPR looks up a PR by number or URL. The context's branch is named
+// after the PR's head branch, or "pr-<number>" if the PR comes from a fork or
+// a local branch of that name already exists.
+func sourceFromPR(cmd *cobra.Command, repo *gitx.Repo, f forge.Forge, ref string) (*contextSource, error) {
+	n, err := forge.ParseNumber(ref)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := f.GetPR(cmd.Context(), n)
+	if err != nil {
+		return nil, fmt.Errorf("look up PR #%d: %w", n, err)
+	}
+	if pr.Head == "" {
+		return nil, fmt.Errorf("PR #%d has no head branch", n)
+	}
+	branch := pr.Head
+	if pr.Fork || repo.BranchExists(cmd.Context(), branch) {
+		branch = fmt.Sprintf("pr-%d", n)
+		if repo.BranchExists(cmd.Context(), branch) {
+			return nil, fmt.Errorf("branch %q already exists; delete it to check out PR #%d", branch, n)
+		}
+	}
+	name := branch
+	if wizctx.ValidateName(name) != nil {
+		name = wizctx.Slug(name, 64)
+	}
+	return &contextSource{
+		Name:   name,
+		Branch: branch,
+		Base:   pr.Base,
+		Task:   pr.Title,
+		PR:     pr,
+		kind:   f.Kind(),
+	}, nil
+}
+
+// sourceFromIssue looks up an issue and derives a context name from its
+// number and title, e.g. "42-fix-login-crash".
+func sourceFromIssue(cmd *cobra.Command, f forge.Forge, ref string) (*contextSource, error) {
+	n, err := forge.ParseNumber(ref)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := f.GetIssue(cmd.Context(), n)
+	if err != nil {
+		return nil, fmt.Errorf("look up issue #%d: %w", n, err)
+	}
+	task := issue.Title
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		task += "\n\n" + body
+	}
+	if issue.URL != "" {
+		task += "\n\n" + issue.URL
+	}
+	return &contextSource{
+		Name: wizctx.Slug(fmt.Sprintf("%d %s", n, issue.Title), 48),
+		Task: task,
+	}, nil
+}
+
+// fetchPRHead fetches a PR's head commit into the new local branch
+// src.Branch. The branch tracks the PR's head branch only if the PR isn't
+// from a fork and the remote branch is at the fetched commit.
+func fetchPRHead(cmd *cobra.Command, repo *gitx.Repo, src *contextSource) error {
+	remote := forgeRemote(repo)
+	head := src.PR.Head
+	ref := forge.HeadRef(src.kind, src.PR.Number)
+	if ref == "" {
+		if src.PR.Fork {
+			return fmt.Errorf("PR #%d is from a fork, which this forge has no head ref for", src.PR.Number)
+		}
+		ref = "refs/heads/" + head
+	}
+	// Without "+", the fetch refuses to touch a branch that appeared since
+	// sourceFromPR checked.
+	if _, err := repo.Run(cmd.Context(), "fetch", "--quiet", remote, ref+":refs/heads/"+src.Branch); err != nil {
+		return fmt.Errorf("fetch PR #%d into branch %s: %w", src.PR.Number, src.Branch, err)
+	}
+	if src.PR.Fork || src.Branch != head {
+		return nil
+	}
+	tracking := "refs/remotes/" + remote + "/" + head
+	if _, err := repo.Run(cmd.Context(), "fetch", "--quiet", remote, "+refs/heads/"+head+":"+tracking); err != nil {
+		return nil
+	}
+	local, _ := repo.Run(cmd.Context(), "rev-parse", "refs/heads/"+src.Branch)
+	if upstream, _ := repo.Run(cmd.Context(), "rev-parse", tracking); upstream == local {
+		repo.Run(cmd.Context(), "branch", "--quiet", "--set-upstream-to="+remote+"/"+head, src.Branch)
+	}
+	return nil
+}
+
+// promptText returns the inline prompt, or the contents of promptFile looked
+// up in the current directory, the repo root and the .wiz/prompts library.
+func promptText(repo *gitx.Repo, inline, promptFile string) (string, error) {
+	if promptFile == "" {
+		return inline, nil
+	}
+	if inline != "" {
+		return "", fmt.Errorf("--prompt-file cannot be combined with an inline prompt")
+	}
+	cwd, _ := os.Getwd()
+	return prompt.Load(promptFile, cwd, repo.WorkDir, prompt.LibraryDir(repo.WorkDir))
+}
+
+// renderPrompt renders text as a prompt template if it came from a prompt
+// file or --input was given. Inline prompts are otherwise taken verbatim, so
+// that they may contain "{{".
+func renderPrompt(cmd *cobra.Command, repo *gitx.Repo, c *wizctx.Context, text, promptFile string, inputs map[string]string) (string, error) {
+	if promptFile == "" && len(inputs) == 0 {
+		return text, nil
+	}
+	return prompt.Render(text, c.Path, promptVars(cmd, repo, c, inputs))
+}
+
+// promptVars returns the prompt template variables for a context.
+func promptVars(cmd *cobra.Command, repo *gitx.Repo, c *wizctx.Context, inputs map[string]string) prompt.Vars {
+	base := c.BaseBranch
+	if base == "" {
+		base, _ = repo.CurrentBranch(cmd.Context())
+	}
+	return prompt.Vars{
+		Name:   c.Name,
+		Branch: c.Branch,
+		Base:   base,
+		Repo:   repo.RepoName(),
+		Agent:  c.Agent,
+		Inputs: inputs,
+	}
+}
+
 func init() {
 	createCmd.Flags().String("base", "", "Base branch (default: current HEAD)")
+	createCmd.Flags().String("on", "", "Stack on another context (base on its branch)")
+	createCmd.Flags().String("branch", "", "Branch name (default: from branch_template, else the context name)")
 	createCmd.Flags().String("strategy", "auto", "Strategy: auto, worktree, clone")
 	createCmd.Flags().String("task", "", "Task description for this context")
-	createCmd.Flags().String("agent", "", "Agent to associate (e.g. claude, gemini, codex)")
+	createCmd.Flags().String("prompt-file", "", "Read the task from a prompt file (e.g. from .wiz/prompts/)")
+	createCmd.Flags().StringToString("input", nil, "Prompt template input (key=value, repeatable)")
+	createCmd.Flags().String("agent", "", "Agent to associate (e.g. claude, codex, aider; see: wiz agents list)")
 	createCmd.Flags().String("template", "", "Apply a saved template")
+	createCmd.Flags().String("from-pr", "", "Check out a pull request's branch (number or URL)")
+	createCmd.Flags().String("from-issue", "", "Derive name, branch and task from an issue (number or URL)")
+	createCmd.MarkFlagsMutuallyExclusive("from-pr", "from-issue")
+	createCmd.Flags().Bool("instructions", false, "Write an agent instructions file into the context (default from config)")
 	rootCmd.AddCommand(createCmd)
 }
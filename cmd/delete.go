@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/config"
 	wizctx "github.com/buck3000/wiz/internal/context"
 	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/snapshot"
 	"github.com/spf13/cobra"
 )
 
@@ -69,16 +73,27 @@ func deleteContext(cmd *cobra.Command, store *wizctx.Store, repo *gitx.Repo, nam
 
 	if !force {
 		st, err := gitx.StatusAt(cmd.Context(), ctx.Path)
-		if err == nil && st.Dirty {
+		if (err == nil && st.Dirty) || (ctx.Archived && ctx.ArchiveSnapshot != 0) {
 			return fmt.Errorf("context %q has uncommitted changes; use --force to delete anyway", name)
 		}
 	}
 
-	prov := wizctx.NewProvisioner(ctx.Strategy, repo)
-	if err := prov.Destroy(cmd.Context(), ctx.Path, force); err != nil {
-		return fmt.Errorf("destroy context: %w", err)
+	if !ctx.Archived {
+		prov := wizctx.NewProvisioner(ctx.Strategy, repo)
+		if err := prov.Destroy(cmd.Context(), ctx.Path, force); err != nil {
+			return fmt.Errorf("destroy context: %w", err)
+		}
 	}
 
+	if ctx.Strategy != wizctx.StrategyClone || ctx.Archived {
+		// A clone's snapshots went with it; a worktree's, and an archived
+		// context's, are in the main repo.
+		snapshot.Delete(cmd.Context(), repo.WorkDir, name)
+	}
+	if ctx.Archived && ctx.Strategy == wizctx.StrategyClone {
+		repo.Run(cmd.Context(), "update-ref", "-d", archiveRef(name))
+	}
+	os.RemoveAll(check.LogDir(config.ChecksDir(repo), name))
 	return store.Remove(cmd.Context(), name)
 }
 
@@ -31,7 +31,7 @@ var diffCmd = &cobra.Command{
 			if err != nil {
 				return err
 			}
-			for _, ctx := range contexts {
+			for _, ctx := range wizctx.Active(contexts) {
 				fmt.Fprintf(cmd.OutOrStdout(), "\033[1;35m%s\033[0m (%s)\n", ctx.Name, ctx.Branch)
 				printDiffStat(cmd, &ctx, repo)
 				fmt.Fprintln(cmd.OutOrStdout())
@@ -44,9 +44,9 @@ var diffCmd = &cobra.Command{
 		}
 
 		name := args[0]
-		ctx, err := store.Get(name)
+		ctx, err := activeContext(store, name)
 		if err != nil {
-			return fmt.Errorf("context %q not found; run 'wiz list' to see available contexts", name)
+			return err
 		}
 
 		gitArgs := []string{"diff"}
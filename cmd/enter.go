@@ -22,7 +22,7 @@ var enterCmd = &cobra.Command{
 		}
 
 		store := wizctx.NewStore(repo)
-		ctx, err := store.Get(name)
+		ctx, err := activeContext(store, name)
 		if err != nil {
 			return err
 		}
@@ -35,6 +35,9 @@ var enterCmd = &cobra.Command{
 		fmt.Fprintf(cmd.OutOrStdout(), "export WIZ_REPO=%q\n", repoName)
 		fmt.Fprintf(cmd.OutOrStdout(), "export WIZ_DIR=%q\n", ctx.Path)
 		fmt.Fprintf(cmd.OutOrStdout(), "export WIZ_BRANCH=%q\n", ctx.Branch)
+		if len(ctx.Ports) > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "export WIZ_PORTS=%q\n", portList(ctx.Ports))
+		}
 		// Set terminal title.
 		fmt.Fprintf(cmd.OutOrStdout(), "printf '\\033]0;\\U0001f9d9 %%s \\u2014 %%s\\007' %q %q\n", ctx.Name, repoName)
 
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/buck3000/wiz/internal/bundle"
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/instructions"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <name>",
+	Short: "Pack a context into a portable bundle file",
+	Long: `Write a context to a .wizbundle file: a git bundle of its branch, a patch of
+its uncommitted changes (untracked files included, ignored files left out)
+and its metadata, such as the task, agent, notes and tags. Hand the file to a
+teammate or copy it to another machine and recreate the context there with
+wiz import.
+
+The context itself is not changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		c, err := activeContext(wizctx.NewStore(repo), args[0])
+		if err != nil {
+			return err
+		}
+		if output == "" {
+			output = wizctx.SafeDirName(c.Name) + bundle.Ext
+		}
+
+		b, err := bundle.Export(cmd.Context(), c.Path, *c)
+		if err != nil {
+			return err
+		}
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		if err := bundle.Write(f, b); err != nil {
+			f.Close()
+			os.Remove(output)
+			return fmt.Errorf("write %s: %w", output, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Exported %s to %s\n", c.Name, output)
+		fmt.Fprintf(cmd.OutOrStdout(), "   Branch:  %s at %s\n", b.Manifest.Branch, shortSHA(b.Manifest.Head))
+		if n := len(b.Manifest.Files); n > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "   Changes: %d uncommitted file(s)\n", n)
+		}
+		return nil
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Recreate a context from a bundle file",
+	Long: `Recreate a context exported with wiz export: its branch is fetched from the
+bundle, a worktree or clone is provisioned on it as by wiz create, and the
+uncommitted changes and metadata are restored.
+
+The repository must have the commits the branch builds on, e.g. be a clone
+of the same remote. The context keeps its name and branch unless --name or
+--branch say otherwise; neither may exist yet.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		branch, _ := cmd.Flags().GetString("branch")
+		strategyStr, _ := cmd.Flags().GetString("strategy")
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := bundle.Read(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		if name == "" {
+			name = b.Manifest.Name
+		}
+		if branch == "" {
+			branch = b.Manifest.Branch
+		}
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+		c, err := importContext(cmd, store, repo, b, name, branch, strategyStr)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Imported %s (branch %s)\n", c.Name, c.Branch)
+		fmt.Fprintf(cmd.OutOrStdout(), "   Path:    %s\n", c.Path)
+		if n := len(b.Manifest.Files); n > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "   Changes: %d uncommitted file(s)\n", n)
+		}
+		return nil
+	},
+}
+
+// importContext provisions the context name on branch from b.
+func importContext(cmd *cobra.Command, store *wizctx.Store, repo *gitx.Repo, b *bundle.Bundle, name, branch, strategyStr string) (*wizctx.Context, error) {
+	if err := wizctx.ValidateName(name); err != nil {
+		return nil, err
+	}
+	existing, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range existing {
+		if c.Name == name {
+			return nil, fmt.Errorf("context %q already exists; pick another with --name", name)
+		}
+	}
+	if err := checkContextLimit(existing); err != nil {
+		return nil, err
+	}
+	if repo.BranchExists(cmd.Context(), branch) {
+		return nil, fmt.Errorf("branch %q already exists; pick another with --branch", branch)
+	}
+
+	cfg := config.Load(repo)
+	strategy := b.Context.Strategy
+	if strategyStr != "" {
+		strategy = wizctx.ParseStrategy(strategyStr)
+	}
+
+	if err := b.FetchBranch(cmd.Context(), repo, branch); err != nil {
+		return nil, err
+	}
+	prov := wizctx.NewProvisioner(strategy, repo)
+	path, err := prov.Create(cmd.Context(), wizctx.CreateOpts{
+		Name:   name,
+		Branch: branch,
+		Repo:   repo,
+	})
+	if err != nil {
+		repo.Run(cmd.Context(), "branch", "-D", branch)
+		return nil, err
+	}
+	cleanup := func() {
+		prov.Destroy(cmd.Context(), path, true)
+		repo.Run(cmd.Context(), "branch", "-D", branch)
+	}
+	if err := b.ApplyChanges(cmd.Context(), path); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("restore uncommitted changes: %w", err)
+	}
+
+	c := b.Context
+	c.Name, c.Branch, c.Path, c.Strategy = name, branch, path, prov.Strategy()
+	c.CreatedAt = time.Now()
+	// The PR belongs to the exported branch name.
+	if branch != b.Manifest.Branch {
+		c.PR, c.PRChecks, c.PRPolledAt = nil, nil, time.Time{}
+	}
+	// Stacking only carries over if the parent was imported as well.
+	if _, err := store.Get(c.Parent); c.Parent != "" && err != nil {
+		c.Parent, c.ParentHead = "", ""
+	}
+	if c.BaseBranch != "" && !repo.BranchExists(cmd.Context(), c.BaseBranch) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: base branch %q does not exist here\n", c.BaseBranch)
+	}
+
+	if err := store.AddWithPorts(cmd.Context(), &c, cfg.PortBase, cfg.PortsPerContext); err != nil {
+		cleanup()
+		return nil, err
+	}
+	if cfg.Instructions.Enabled {
+		if _, err := instructions.Apply(cmd.Context(), repo, cfg, &c); err != nil {
+			store.Remove(cmd.Context(), name)
+			cleanup()
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+func init() {
+	exportCmd.Flags().StringP("output", "o", "", "Bundle file to write (default: <name>.wizbundle)")
+	importCmd.Flags().String("name", "", "Context name (default: the exported name)")
+	importCmd.Flags().String("branch", "", "Branch name (default: the exported branch)")
+	importCmd.Flags().String("strategy", "", "Strategy: auto, worktree, clone (default: the exported context's)")
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+}
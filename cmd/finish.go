@@ -1,104 +1,536 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
 
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/config"
 	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
 	"github.com/buck3000/wiz/internal/gitx"
 	"github.com/spf13/cobra"
 )
 
 var finishCmd = &cobra.Command{
 	Use:   "finish <name>",
-	Short: "Create a PR, optionally merge, then delete the context",
-	Args:  cobra.ExactArgs(1),
+	Short: "Open or update a PR for a context, or merge it locally",
+	Long: `Push the context's branch and open a pull request against its base
+branch, or update the PR already open for the branch. The PR is recorded on
+the context, which is kept for follow-up review fixes: rerun wiz finish to
+push them. With --merge the PR is merged (--squash or --rebase choose how)
+and the context is deleted, unless --keep is given.
+
+The forge (GitHub, GitLab, Gitea/Forgejo or Bitbucket) is detected from the
+origin remote's URL, or set under "forge" in the wiz config. Its API token
+is read from GITHUB_TOKEN (or gh's login), GITLAB_TOKEN, GITEA_TOKEN or
+BITBUCKET_TOKEN.
+
+With --local, no remote is involved: the branch is merged into its base
+branch in the worktree that has the base checked out (a merge commit, or
+--squash / --ff-only), after the context's checks pass. The branch and
+the context are then deleted.
+
+--dry-run prints what would happen without pushing, merging or deleting
+anything.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		merge, _ := cmd.Flags().GetBool("merge")
 		title, _ := cmd.Flags().GetString("title")
 		body, _ := cmd.Flags().GetString("body")
+		local, _ := cmd.Flags().GetBool("local")
+		squash, _ := cmd.Flags().GetBool("squash")
+		rebase, _ := cmd.Flags().GetBool("rebase")
+		ffOnly, _ := cmd.Flags().GetBool("ff-only")
+		noCheck, _ := cmd.Flags().GetBool("no-check")
+		draft, _ := cmd.Flags().GetBool("draft")
+		labels, _ := cmd.Flags().GetStringSlice("label")
+		reviewers, _ := cmd.Flags().GetStringSlice("reviewer")
+		keep, _ := cmd.Flags().GetBool("keep")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if !local && (ffOnly || noCheck) {
+			return fmt.Errorf("--ff-only and --no-check require --local")
+		}
+		if !local && !merge && (squash || rebase) {
+			return fmt.Errorf("--squash and --rebase choose how --merge (or --local) merges")
+		}
+		if local && (draft || len(labels) > 0 || len(reviewers) > 0) {
+			return fmt.Errorf("--draft, --label and --reviewer apply to PRs, not --local")
+		}
 
 		repo, err := gitx.Discover(".")
 		if err != nil {
 			return err
 		}
 
-		// Check for gh CLI before doing any work.
-		if _, err := exec.LookPath("gh"); err != nil {
-			return fmt.Errorf("GitHub CLI (gh) not found in PATH; install it from https://cli.github.com")
+		// Resolve the forge and its token before doing any work.
+		var f forge.Forge
+		if !local {
+			if f, err = openForge(cmd, repo); err != nil {
+				return fmt.Errorf("%w\n\n  Or merge locally: wiz finish %s --local", err, name)
+			}
 		}
 
 		store := wizctx.NewStore(repo)
-		ctx, err := store.Get(name)
+		ctx, err := activeContext(store, name)
 		if err != nil {
-			return fmt.Errorf("context %q not found; run 'wiz list' to see available contexts", name)
+			return err
+		}
+
+		if local {
+			mode := "merge"
+			if squash {
+				mode = "squash"
+			} else if ffOnly {
+				mode = "ff-only"
+			}
+			return finishLocal(cmd, store, repo, ctx, localFinish{
+				Mode: mode, Title: title, Body: body, RunChecks: !noCheck, Keep: keep, DryRun: dryRun,
+			})
 		}
+		return finishContext(cmd, store, repo, f, ctx, prFinish{
+			Title: title, Body: body, Draft: draft, Labels: labels, Reviewers: reviewers,
+			Merge: merge, Method: mergeMethod(squash, rebase), Keep: keep, DryRun: dryRun,
+		})
+	},
+}
+
+// mergeMethod returns the forge merge method chosen by --squash and --rebase.
+func mergeMethod(squash, rebase bool) forge.MergeMethod {
+	switch {
+	case squash:
+		return forge.MergeSquash
+	case rebase:
+		return forge.MergeRebase
+	}
+	return forge.MergeCommit
+}
+
+// prFinish holds the options of a PR-based wiz finish. An empty Title or
+// Body defaults to the context's name and task on a new PR and leaves an
+// existing PR's unchanged.
+type prFinish struct {
+	Title, Body       string
+	Draft             bool
+	Labels, Reviewers []string
+	Merge             bool
+	Method            forge.MergeMethod
+	Keep              bool // keep the context after merging
+	DryRun            bool
+}
 
+// finishContext pushes the context's branch and opens a PR on f, or updates
+// the one already open for the branch, recording it on the context. If the
+// PR is merged the context is deleted unless opts.Keep is set.
+func finishContext(cmd *cobra.Command, store *wizctx.Store, repo *gitx.Repo, f forge.Forge, ctx *wizctx.Context, opts prFinish) error {
+	out := cmd.OutOrStdout()
+	name := ctx.Name
+
+	// A stacked context's PR targets its parent's branch, which must be
+	// pushed first.
+	base := ctx.BaseBranch
+	var parent *wizctx.Context
+	if ctx.Parent != "" {
+		if p, err := store.Get(ctx.Parent); err == nil {
+			parent = p
+			base = parent.Branch
+		}
+	}
+	if base == "" {
+		base = defaultBranch(cmd, repo)
+	}
+
+	existing, err := f.FindPR(cmd.Context(), ctx.Branch)
+	if err != nil {
+		return fmt.Errorf("look up PR for %s: %w", ctx.Branch, err)
+	}
+
+	if opts.DryRun {
+		printFinishPlan(cmd, f, ctx, parent, base, existing, opts)
+		return nil
+	}
+
+	if parent != nil {
+		fmt.Fprintf(out, "\U0001f9d9 Pushing parent %s...\n", parent.Branch)
+		if err := pushContext(cmd, repo, f, parent); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "\U0001f9d9 Pushing %s...\n", ctx.Branch)
+	if err := pushContext(cmd, repo, f, ctx); err != nil {
+		return err
+	}
+
+	// From here on the branch is pushed; failures keep the context so that
+	// rerunning wiz finish picks up where this left off.
+	retry := fmt.Sprintf("%s was pushed and the context kept; rerun 'wiz finish %s' to retry", ctx.Branch, name)
+	var pr *forge.PR
+	if existing != nil {
+		fmt.Fprintf(out, "\U0001f9d9 Updating PR #%d...\n", existing.Number)
+		pr, err = f.UpdatePR(cmd.Context(), existing.Number, forge.CreateOpts{
+			Title:     opts.Title,
+			Body:      opts.Body,
+			Labels:    opts.Labels,
+			Reviewers: opts.Reviewers,
+		})
+		if err != nil {
+			return fmt.Errorf("update PR: %w\n\n  %s", err, retry)
+		}
+		fmt.Fprintf(out, "\U0001f9d9 PR updated: %s\n", pr.URL)
+	} else {
+		title, body := opts.Title, opts.Body
 		if title == "" {
 			title = name
 		}
-		if body == "" && ctx.Task != "" {
+		if body == "" {
 			body = ctx.Task
 		}
+		fmt.Fprintf(out, "\U0001f9d9 Creating PR...\n")
+		pr, err = f.CreatePR(cmd.Context(), forge.CreateOpts{
+			Title:     title,
+			Body:      body,
+			Head:      ctx.Branch,
+			Base:      base,
+			Draft:     opts.Draft,
+			Labels:    opts.Labels,
+			Reviewers: opts.Reviewers,
+		})
+		if pr == nil {
+			return fmt.Errorf("create PR: %w\n\n  %s", err, retry)
+		}
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+		}
+		fmt.Fprintf(out, "\U0001f9d9 PR created: %s\n", pr.URL)
+	}
+	if err := store.Update(cmd.Context(), name, func(c *wizctx.Context) { c.PR = pr }); err != nil {
+		return err
+	}
+
+	if !opts.Merge {
+		fmt.Fprintf(out, "\U0001f9d9 Kept %s for review fixes; rerun 'wiz finish %s' to push them.\n", name, name)
+		return nil
+	}
+
+	fmt.Fprintf(out, "\U0001f9d9 Merging PR (%s)...\n", opts.Method)
+	if err := f.MergePR(cmd.Context(), pr.Number, opts.Method); err != nil {
+		return fmt.Errorf("merge PR: %w\n\n  The PR stays open and the context is kept.", err)
+	}
+	pr.State = forge.StateMerged
+	if err := store.Update(cmd.Context(), name, func(c *wizctx.Context) { c.PR = pr }); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: record merged PR: %v\n", err)
+	}
+	if _, err := repo.Run(cmd.Context(), "push", "--quiet", forgeRemote(repo), "--delete", ctx.Branch); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: delete remote branch: %v\n", err)
+	}
+	fmt.Fprintf(out, "\U0001f9d9 PR merged.\n")
 
-		// Step 1: Push the branch.
-		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Pushing %s...\n", ctx.Branch)
-		pushCmd := exec.CommandContext(cmd.Context(), "git", "push", "-u", "origin", ctx.Branch)
-		pushCmd.Dir = ctx.Path
-		if out, err := pushCmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("git push: %w\n%s", err, out)
+	if opts.Keep {
+		fmt.Fprintf(out, "\U0001f9d9 Kept context: %s\n", name)
+		return nil
+	}
+	prov := wizctx.NewProvisioner(ctx.Strategy, repo)
+	if err := prov.Destroy(cmd.Context(), ctx.Path, true); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: destroy context: %v\n", err)
+	}
+	if err := store.Remove(cmd.Context(), name); err != nil {
+		return err
+	}
+	reparentChildren(cmd, store, ctx, true)
+	fmt.Fprintf(out, "\U0001f9d9 Finished: %s\n", name)
+	return nil
+}
+
+// printFinishPlan describes what finishContext would do.
+func printFinishPlan(cmd *cobra.Command, f forge.Forge, ctx, parent *wizctx.Context, base string, existing *forge.PR, opts prFinish) {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Dry run: wiz finish %s would\n", ctx.Name)
+	if parent != nil {
+		fmt.Fprintf(out, "  push %s (parent %s)\n", parent.Branch, parent.Name)
+	}
+	fmt.Fprintf(out, "  push %s\n", ctx.Branch)
+	if existing != nil {
+		fmt.Fprintf(out, "  update %s PR #%d: %s\n", f.Kind(), existing.Number, existing.URL)
+		if opts.Title != "" {
+			fmt.Fprintf(out, "    title: %s\n", opts.Title)
 		}
+	} else {
+		title := opts.Title
+		if title == "" {
+			title = ctx.Name
+		}
+		kind := "PR"
+		if opts.Draft {
+			kind = "draft PR"
+		}
+		fmt.Fprintf(out, "  open a %s %s %s → %s: %s\n", f.Kind(), kind, ctx.Branch, base, title)
+	}
+	if len(opts.Labels) > 0 {
+		fmt.Fprintf(out, "    labels: %s\n", strings.Join(opts.Labels, ", "))
+	}
+	if len(opts.Reviewers) > 0 {
+		fmt.Fprintf(out, "    reviewers: %s\n", strings.Join(opts.Reviewers, ", "))
+	}
+	switch {
+	case !opts.Merge:
+		fmt.Fprintf(out, "  keep the context\n")
+	case opts.Keep:
+		fmt.Fprintf(out, "  merge the PR (%s) and keep the context\n", opts.Method)
+	default:
+		fmt.Fprintf(out, "  merge the PR (%s) and delete the context\n", opts.Method)
+	}
+}
+
+// openForge returns the forge hosting the repository's push remote, as
+// detected from its URL or set under "forge" in the wiz config.
+func openForge(cmd *cobra.Command, repo *gitx.Repo) (forge.Forge, error) {
+	fc := config.Load(repo).Forge
+	name := forgeRemote(repo)
+	// The configured URL names the forge even if insteadOf rewrites it.
+	url, err := repo.Run(cmd.Context(), "config", "--get", "remote."+name+".url")
+	if err != nil {
+		return nil, fmt.Errorf("no %q remote to open a PR on; add one, or merge locally with --local", name)
+	}
+	remote, err := forge.ParseRemote(name, url)
+	if err != nil {
+		return nil, err
+	}
+	return forge.New(remote, forge.Options{Kind: fc.Type, APIURL: fc.APIURL})
+}
 
-		// Step 2: Create PR.
-		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Creating PR...\n")
-		ghArgs := []string{"pr", "create",
-			"--title", title,
-			"--head", ctx.Branch,
+// forgeRemote returns the name of the git remote PRs are opened against.
+func forgeRemote(repo *gitx.Repo) string {
+	if r := config.Load(repo).Forge.Remote; r != "" {
+		return r
+	}
+	return "origin"
+}
+
+// pushContext pushes a context's branch. A clone context's own origin is the
+// main repository, so its branch is fetched there into a temporary ref and
+// pushed from there, leaving the main repository's branches alone.
+func pushContext(cmd *cobra.Command, repo *gitx.Repo, f forge.Forge, c *wizctx.Context) error {
+	if c.Strategy != wizctx.StrategyClone {
+		return f.Push(cmd.Context(), c.Path, c.Branch)
+	}
+	tmp := "refs/wiz/tmp/push/" + wizctx.SafeDirName(c.Name)
+	if _, err := repo.Run(cmd.Context(), "fetch", "--quiet", "--no-tags", c.Path, "+refs/heads/"+c.Branch+":"+tmp); err != nil {
+		return err
+	}
+	defer repo.Run(cmd.Context(), "update-ref", "-d", tmp)
+	if _, err := repo.Run(cmd.Context(), "push", "--quiet", forgeRemote(repo), tmp+":refs/heads/"+c.Branch); err != nil {
+		return fmt.Errorf("git push: %w", err)
+	}
+	return nil
+}
+
+// defaultBranch returns the remote's default branch, falling back to the
+// main repository's current branch.
+func defaultBranch(cmd *cobra.Command, repo *gitx.Repo) string {
+	remote := forgeRemote(repo)
+	if ref, err := repo.Run(cmd.Context(), "symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD"); err == nil {
+		return strings.TrimPrefix(ref, remote+"/")
+	}
+	branch, _ := repo.CurrentBranch(cmd.Context())
+	return branch
+}
+
+// localFinish holds the options of wiz finish --local. Mode is "merge",
+// "squash" or "ff-only".
+type localFinish struct {
+	Mode, Title, Body string
+	RunChecks         bool
+	Keep              bool // keep the branch and context after merging
+	DryRun            bool
+}
+
+// finishLocal merges the context's branch into its base branch in the
+// worktree that has the base checked out, then deletes the branch and the
+// context.
+func finishLocal(cmd *cobra.Command, store *wizctx.Store, repo *gitx.Repo, ctx *wizctx.Context, opts localFinish) error {
+	out := cmd.OutOrStdout()
+	mode, title, body := opts.Mode, opts.Title, opts.Body
+
+	st, err := gitx.StatusAt(cmd.Context(), ctx.Path)
+	if err != nil {
+		return err
+	}
+	if st.Dirty {
+		return fmt.Errorf("context %q has uncommitted changes; commit or stash them first", ctx.Name)
+	}
+
+	base := ctx.BaseBranch
+	if base == "" {
+		if base, err = repo.CurrentBranch(cmd.Context()); err != nil {
+			return err
+		}
+	}
+	trees, err := repo.WorktreeList(cmd.Context())
+	if err != nil {
+		return err
+	}
+	baseDir := ""
+	for _, wt := range trees {
+		if wt.Branch == "refs/heads/"+base {
+			baseDir = wt.Path
+			break
 		}
-		if ctx.BaseBranch != "" {
-			ghArgs = append(ghArgs, "--base", ctx.BaseBranch)
+	}
+	if baseDir == "" {
+		return fmt.Errorf("base branch %q is not checked out in any worktree; check it out in %s first", base, repo.WorkDir)
+	}
+	if bst, err := gitx.StatusAt(cmd.Context(), baseDir); err != nil {
+		return err
+	} else if bst.Dirty {
+		return fmt.Errorf("worktree %s (branch %s) has uncommitted changes; commit or stash them first", baseDir, base)
+	}
+
+	if opts.DryRun {
+		fmt.Fprintf(out, "Dry run: wiz finish %s --local would\n", ctx.Name)
+		if opts.RunChecks {
+			fmt.Fprintf(out, "  run the context's checks\n")
 		}
-		if body != "" {
-			ghArgs = append(ghArgs, "--body", body)
+		fmt.Fprintf(out, "  merge %s into %s in %s (%s)\n", ctx.Branch, base, baseDir, mode)
+		if opts.Keep {
+			fmt.Fprintf(out, "  keep the branch and the context\n")
+		} else {
+			fmt.Fprintf(out, "  delete the branch and the context\n")
 		}
-		ghCmd := exec.CommandContext(cmd.Context(), "gh", ghArgs...)
-		ghCmd.Dir = ctx.Path
-		prOut, err := ghCmd.CombinedOutput()
+		return nil
+	}
+
+	// Clone contexts keep their commits in the clone; Tip fetches them.
+	tip, err := wizctx.Tip(cmd.Context(), repo, ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.RunChecks {
+		checks, err := check.ForTemplate(repo, ctx.Template)
 		if err != nil {
-			return fmt.Errorf("gh pr create: %w\n%s", err, prOut)
-		}
-		prURL := strings.TrimSpace(string(prOut))
-		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 PR created: %s\n", prURL)
-
-		// Step 3: Optionally merge.
-		if merge {
-			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Merging PR...\n")
-			mergeCmd := exec.CommandContext(cmd.Context(), "gh", "pr", "merge", prURL, "--merge", "--delete-branch")
-			mergeCmd.Dir = ctx.Path
-			if out, err := mergeCmd.CombinedOutput(); err != nil {
-				return fmt.Errorf("gh pr merge: %w\n%s", err, out)
+			return err
+		}
+		if len(checks) > 0 && !checksPassedAt(ctx.Checks, tip) {
+			fmt.Fprintf(out, "\U0001f9d9 Running checks in %s...\n", ctx.Name)
+			rs := check.RunAll(cmd.Context(), []check.Job{{Context: ctx.Name, Dir: ctx.Path, Checks: checks}}, config.ChecksDir(repo), 1, nil)[0]
+			printCheckResults(cmd, ctx.Name, rs)
+			if !check.Passed(rs) {
+				store.Update(cmd.Context(), ctx.Name, func(c *wizctx.Context) { c.Checks = rs })
+				return fmt.Errorf("checks failed in %s; fix them or pass --no-check", ctx.Name)
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 PR merged.\n")
 		}
+	}
 
-		// Step 4: Delete the context.
-		prov := wizctx.NewProvisioner(ctx.Strategy, repo)
-		if err := prov.Destroy(cmd.Context(), ctx.Path, true); err != nil {
-			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: destroy context: %v\n", err)
-		}
-		if err := store.Remove(cmd.Context(), name); err != nil {
-			return err
+	if title == "" && mode == "squash" {
+		title = ctx.Name
+	} else if title == "" {
+		title = fmt.Sprintf("Merge branch '%s'", ctx.Branch)
+	}
+	message := title
+	if body != "" {
+		message += "\n\n" + body
+	} else if mode == "squash" && ctx.Task != "" {
+		message += "\n\n" + ctx.Task
+	}
+
+	fmt.Fprintf(out, "\U0001f9d9 Merging %s into %s (%s)...\n", ctx.Branch, base, mode)
+	switch mode {
+	case "squash":
+		err = gitx.SquashMerge(cmd.Context(), baseDir, tip, message)
+	case "ff-only":
+		if err = gitx.FastForward(cmd.Context(), baseDir, tip); err != nil {
+			err = fmt.Errorf("cannot fast-forward %s to %s; run 'wiz sync %s' first\n%w", base, ctx.Branch, ctx.Name, err)
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Finished: %s\n", name)
+	default:
+		err = gitx.MergeCommit(cmd.Context(), baseDir, tip, message)
+	}
+	var conflict *gitx.ConflictError
+	if errors.As(err, &conflict) {
+		return fmt.Errorf("merging %s into %s conflicts in %s; the merge was aborted. Run 'wiz sync %s' and resolve the conflicts first",
+			ctx.Branch, base, strings.Join(conflict.Files, ", "), ctx.Name)
+	} else if err != nil {
+		return err
+	}
+
+	if opts.Keep {
+		fmt.Fprintf(out, "\U0001f9d9 Merged %s into %s; kept context: %s\n", ctx.Branch, base, ctx.Name)
 		return nil
-	},
+	}
+	prov := wizctx.NewProvisioner(ctx.Strategy, repo)
+	if err := prov.Destroy(cmd.Context(), ctx.Path, true); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: destroy context: %v\n", err)
+	}
+	if repo.BranchExists(cmd.Context(), ctx.Branch) {
+		if _, err := repo.Run(cmd.Context(), "branch", "-D", ctx.Branch); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: delete branch: %v\n", err)
+		}
+	}
+	if err := store.Remove(cmd.Context(), ctx.Name); err != nil {
+		return err
+	}
+	reparentChildren(cmd, store, ctx, true)
+	fmt.Fprintf(out, "\U0001f9d9 Finished: %s merged into %s\n", ctx.Name, base)
+	return nil
+}
+
+// checksPassedAt reports whether stored check results all passed at head.
+func checksPassedAt(rs []check.Result, head string) bool {
+	for _, r := range rs {
+		if r.Head != head {
+			return false
+		}
+	}
+	return check.Passed(rs)
+}
+
+// reparentChildren detaches contexts stacked on a finished context. Once its
+// PR is merged they move onto its own parent and base; otherwise they keep
+// its branch, which their PRs still target, as their base. ParentHead is
+// kept so the next wiz sync replays only the children's own commits.
+func reparentChildren(cmd *cobra.Command, store *wizctx.Store, ctx *wizctx.Context, merged bool) {
+	contexts, err := store.List()
+	if err != nil {
+		return
+	}
+	for _, c := range contexts {
+		if c.Parent != ctx.Name {
+			continue
+		}
+		err := store.Update(cmd.Context(), c.Name, func(c *wizctx.Context) {
+			c.Parent = ""
+			if merged {
+				c.Parent = ctx.Parent
+				c.BaseBranch = ctx.BaseBranch
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: reparent %s: %v\n", c.Name, err)
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Restack %s with: wiz sync %s\n", c.Name, c.Name)
+	}
 }
 
 func init() {
-	finishCmd.Flags().Bool("merge", false, "Also merge the PR after creation")
+	finishCmd.Flags().Bool("merge", false, "Merge the PR and delete the context")
 	finishCmd.Flags().String("title", "", "PR title (default: context name)")
 	finishCmd.Flags().String("body", "", "PR body (default: task description)")
+	finishCmd.Flags().Bool("draft", false, "Open the PR as a draft")
+	finishCmd.Flags().StringSlice("label", nil, "Add a label to the PR (repeatable)")
+	finishCmd.Flags().StringSlice("reviewer", nil, "Request a review from a user (repeatable)")
+	finishCmd.Flags().Bool("keep", false, "Keep the context after merging")
+	finishCmd.Flags().Bool("dry-run", false, "Print what would happen without changing anything")
+	finishCmd.Flags().Bool("local", false, "Merge into the base branch locally instead of opening a PR")
+	finishCmd.Flags().Bool("squash", false, "Squash the context into one commit when merging")
+	finishCmd.Flags().Bool("rebase", false, "With --merge, rebase-merge the PR")
+	finishCmd.Flags().Bool("ff-only", false, "With --local, fast-forward the base branch instead of merging")
+	finishCmd.Flags().Bool("no-check", false, "With --local, merge without running checks")
+	finishCmd.MarkFlagsMutuallyExclusive("local", "merge")
+	finishCmd.MarkFlagsMutuallyExclusive("local", "rebase")
+	finishCmd.MarkFlagsMutuallyExclusive("draft", "merge")
+	finishCmd.MarkFlagsMutuallyExclusive("squash", "rebase", "ff-only")
 	rootCmd.AddCommand(finishCmd)
 }
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/instructions"
+	"github.com/buck3000/wiz/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var forkCmd = &cobra.Command{
+	Use:   "fork <name> <new>",
+	Short: "Create a context from another one's current state",
+	Long: `Create a new context whose branch starts at the source context's HEAD and
+carry over the source's uncommitted and untracked changes (ignored files are
+left behind), e.g. to try an alternative approach from an agent's
+half-finished work. The source context is not modified.
+
+The new context takes over the source's base, task and agent, and records
+which context and commit it was forked from.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		branchFlag, _ := cmd.Flags().GetString("branch")
+		strategyStr, _ := cmd.Flags().GetString("strategy")
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+		src, err := activeContext(store, args[0])
+		if err != nil {
+			return err
+		}
+		strategy := src.Strategy
+		if strategyStr != "" {
+			strategy = wizctx.ParseStrategy(strategyStr)
+		}
+
+		c, err := forkContext(cmd, store, repo, src, args[1], branchFlag, strategy)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Forked %s into %s (branch %s)\n", src.Name, c.Name, c.Branch)
+		return nil
+	},
+}
+
+// forkContext creates the context name from src's HEAD and working tree.
+func forkContext(cmd *cobra.Command, store *wizctx.Store, repo *gitx.Repo, src *wizctx.Context, name, branch string, strategy wizctx.Strategy) (*wizctx.Context, error) {
+	if err := wizctx.ValidateName(name); err != nil {
+		return nil, err
+	}
+	existing, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range existing {
+		if c.Name == name {
+			return nil, fmt.Errorf("context %q already exists", name)
+		}
+	}
+	if err := checkContextLimit(existing); err != nil {
+		return nil, err
+	}
+
+	cfg := config.Load(repo)
+	if branch, err = wizctx.BranchName(cmd.Context(), repo, branch, branchTemplate(repo, cfg, src.Template), name); err != nil {
+		return nil, err
+	}
+	if repo.BranchExists(cmd.Context(), branch) {
+		return nil, fmt.Errorf("branch %q already exists; pick another with --branch", branch)
+	}
+
+	work, head, err := snapshot.Capture(cmd.Context(), src.Path, "wiz fork of "+src.Name)
+	if err != nil {
+		return nil, err
+	}
+	if head == "" {
+		return nil, fmt.Errorf("context %q has no commits to fork from", src.Name)
+	}
+	if src.Strategy == wizctx.StrategyClone {
+		// The captured commit lives in the clone; bring it into the main
+		// repository, which new contexts share objects with.
+		ref := "refs/wiz/tmp/fork-" + wizctx.SafeDirName(name)
+		if out, err := exec.CommandContext(cmd.Context(), "git", "-C", src.Path, "update-ref", ref, work).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("fork %s: %w\n%s", src.Name, err, out)
+		}
+		defer exec.Command("git", "-C", src.Path, "update-ref", "-d", ref).Run()
+		if _, err := repo.Run(cmd.Context(), "fetch", "--quiet", "--no-tags", src.Path, "+"+ref+":"+ref); err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", src.Name, err)
+		}
+		defer repo.Run(cmd.Context(), "update-ref", "-d", ref)
+	}
+
+	prov := wizctx.NewProvisioner(strategy, repo)
+	path, err := prov.Create(cmd.Context(), wizctx.CreateOpts{
+		Name:       name,
+		Branch:     branch,
+		BaseBranch: head,
+		Repo:       repo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// fail removes the new working directory and its branch, which did not
+	// exist before this fork.
+	fail := func(err error) (*wizctx.Context, error) {
+		prov.Destroy(cmd.Context(), path, true)
+		repo.Run(cmd.Context(), "branch", "-D", branch)
+		return nil, err
+	}
+	if err := snapshot.Apply(cmd.Context(), path, work); err != nil {
+		return fail(fmt.Errorf("carry over changes: %w", err))
+	}
+
+	c := wizctx.Context{
+		Name:       name,
+		Branch:     branch,
+		Path:       path,
+		Strategy:   prov.Strategy(),
+		CreatedAt:  time.Now(),
+		BaseBranch: src.BaseBranch,
+		Task:       src.Task,
+		Agent:      src.Agent,
+		Template:   src.Template,
+		Parent:     src.Parent,
+		ParentHead: src.ParentHead,
+		DependsOn:  src.DependsOn,
+		ForkedFrom: src.Name,
+		ForkPoint:  head,
+	}
+	if err := store.AddWithPorts(cmd.Context(), &c, cfg.PortBase, cfg.PortsPerContext); err != nil {
+		return fail(err)
+	}
+	if cfg.Instructions.Enabled {
+		if _, err := instructions.Apply(cmd.Context(), repo, cfg, &c); err != nil {
+			store.Remove(cmd.Context(), name)
+			return fail(err)
+		}
+	}
+	return &c, nil
+}
+
+func init() {
+	forkCmd.Flags().String("branch", "", "Branch name (default: from branch_template, else the new name)")
+	forkCmd.Flags().String("strategy", "", "Strategy: auto, worktree, clone (default: the source's)")
+	rootCmd.AddCommand(forkCmd)
+}
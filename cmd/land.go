@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/land"
+	"github.com/buck3000/wiz/internal/license"
+	"github.com/spf13/cobra"
+)
+
+var landCmd = &cobra.Command{
+	Use:   "land <name...> --into <branch>",
+	Short: "Integrate several contexts into a new integration context",
+	Long: `Create a new context on branch --into and land the given contexts into it one
+at a time, parents and dependencies first. Each context is merged, or with
+--cherry-pick its commits are replayed, and the configured checks run after
+every step. Landing stops at the first conflict or failing check.
+
+With --bisect, checks run once after everything has landed; if they fail,
+the steps are bisected to find the context that broke them.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		into, _ := cmd.Flags().GetString("into")
+		base, _ := cmd.Flags().GetString("base")
+		cherryPick, _ := cmd.Flags().GetBool("cherry-pick")
+		bisect, _ := cmd.Flags().GetBool("bisect")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		if err := wizctx.ValidateName(into); err != nil {
+			return err
+		}
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+		existing, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, name := range args {
+			if _, err := activeContext(store, name); err != nil {
+				return err
+			}
+		}
+		if _, err := store.Get(into); err == nil {
+			return fmt.Errorf("context %q already exists; choose another --into", into)
+		}
+		if _, err := repo.Run(cmd.Context(), "rev-parse", "--verify", "--quiet", "refs/heads/"+into); err == nil {
+			return fmt.Errorf("branch %q already exists; choose another --into", into)
+		}
+		order, err := wizctx.DependencyOrder(existing, args)
+		if err != nil {
+			return err
+		}
+
+		tier, _ := license.CheckLicense()
+		if err := license.CheckContextLimit(tier, len(wizctx.Active(existing))); err != nil {
+			return fmt.Errorf("%w; the integration context needs a free slot", err)
+		}
+
+		current, _ := repo.CurrentBranch(cmd.Context())
+		contextBase := func(c *wizctx.Context) string {
+			if c.BaseBranch != "" {
+				return c.BaseBranch
+			}
+			return current
+		}
+
+		report := &land.Report{Into: into, Base: base, Mode: "merge"}
+		if cherryPick {
+			report.Mode = "cherry-pick"
+		}
+		contexts := make([]*wizctx.Context, len(order))
+		for i, name := range order {
+			c, _ := store.Get(name)
+			contexts[i] = c
+			tip, err := wizctx.Tip(cmd.Context(), repo, c)
+			if err != nil {
+				return err
+			}
+			report.Steps = append(report.Steps, land.Step{Context: c.Name, Branch: c.Branch, Tip: tip, Result: land.Pending})
+		}
+		if report.Base == "" {
+			report.Base = contextBase(contexts[0])
+		}
+
+		checks, err := check.ForTemplate(repo, "")
+		if err != nil {
+			return err
+		}
+		if len(checks) == 0 {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: no checks configured; landing without verification\n")
+		}
+
+		prov := wizctx.NewProvisioner(wizctx.StrategyWorktree, repo)
+		path, err := prov.Create(cmd.Context(), wizctx.CreateOpts{
+			Name:       into,
+			Branch:     into,
+			BaseBranch: report.Base,
+			Repo:       repo,
+		})
+		if err != nil {
+			return err
+		}
+		cfg := config.Load(repo)
+		integration := wizctx.Context{
+			Name:       into,
+			Branch:     into,
+			Path:       path,
+			Strategy:   prov.Strategy(),
+			CreatedAt:  time.Now(),
+			BaseBranch: report.Base,
+			Task:       "Integration of " + strings.Join(order, ", "),
+		}
+		if err := store.AddWithPorts(cmd.Context(), &integration, cfg.PortBase, cfg.PortsPerContext); err != nil {
+			prov.Destroy(cmd.Context(), path, true)
+			return err
+		}
+		work, err := gitx.Discover(path)
+		if err != nil {
+			return err
+		}
+		if !asJSON {
+			fmt.Fprintf(cmd.ErrOrStderr(), "\U0001f9d9 Landing %d contexts into %s (from %s, %s)\n", len(order), into, report.Base, report.Mode)
+		}
+
+		runChecks := func() []check.Result {
+			rs := check.RunAll(cmd.Context(), []check.Job{{Context: into, Dir: path, Checks: checks}}, config.ChecksDir(repo), 1, nil)
+			return rs[0]
+		}
+
+		var last []check.Result
+		for i, c := range contexts {
+			s := &report.Steps[i]
+			if cherryPick {
+				s.Commits, err = gitx.CherryPick(cmd.Context(), path, contextBase(c), s.Tip)
+			} else {
+				err = gitx.MergeCommit(cmd.Context(), path, s.Tip, fmt.Sprintf("Land %s (%s)", c.Name, c.Branch))
+			}
+			var conflict *gitx.ConflictError
+			if errors.As(err, &conflict) {
+				s.Result, s.Detail, s.Conflicts = land.Conflict, conflict.Op+" aborted", conflict.Files
+				break
+			} else if err != nil {
+				s.Result, s.Detail = land.Error, err.Error()
+				break
+			}
+			s.Head, _ = work.Run(cmd.Context(), "rev-parse", "HEAD")
+
+			if len(checks) > 0 && !bisect {
+				last = runChecks()
+				s.Checks = last
+				if !check.Passed(last) {
+					s.Result, s.Detail = land.ChecksFailed, "checks "+check.Summary(last)
+					break
+				}
+			}
+			s.Result = land.Landed
+			if !asJSON {
+				fmt.Fprintf(cmd.ErrOrStderr(), "  landed %s\n", c.Name)
+			}
+		}
+
+		if bisect && len(checks) > 0 && report.Steps[len(report.Steps)-1].Result == land.Landed {
+			last = runChecks()
+			if !check.Passed(last) {
+				if !asJSON {
+					fmt.Fprintf(cmd.ErrOrStderr(), "\U0001f9d9 Checks failed after landing everything; bisecting...\n")
+				}
+				if err := bisectLanding(cmd, report, work, runChecks); err != nil {
+					return err
+				}
+			}
+		}
+
+		if last != nil {
+			if err := store.Update(cmd.Context(), into, func(c *wizctx.Context) {
+				c.Checks = last
+			}); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: save results for %s: %v\n", into, err)
+			}
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			printLandReport(cmd, report, path)
+		}
+		if !report.Landed() {
+			return fmt.Errorf("landing into %s stopped; see the report above", into)
+		}
+		return nil
+	},
+}
+
+// bisectLanding finds the step that first made the checks fail by checking
+// out intermediate integration commits, then returns to the branch tip.
+func bisectLanding(cmd *cobra.Command, report *land.Report, work *gitx.Repo, runChecks func() []check.Result) error {
+	defer work.Run(cmd.Context(), "checkout", "--quiet", report.Into)
+
+	fails := func(rev string) (bool, error) {
+		if _, err := work.Run(cmd.Context(), "checkout", "--quiet", "--detach", rev); err != nil {
+			return false, err
+		}
+		return !check.Passed(runChecks()), nil
+	}
+	i, err := land.Bisect(len(report.Steps), func(i int) (bool, error) {
+		return fails(report.Steps[i].Head)
+	})
+	if err != nil {
+		return err
+	}
+	if i == 0 {
+		// The first step may not be to blame if the base already fails.
+		bad, err := fails(report.Base)
+		if err != nil {
+			return err
+		}
+		if bad {
+			report.BaseFails = true
+			return nil
+		}
+	}
+	report.Culprit = report.Steps[i].Context
+	return nil
+}
+
+func printLandReport(cmd *cobra.Command, report *land.Report, path string) {
+	out := cmd.OutOrStdout()
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTEXT\tRESULT\tHEAD\tCHECKS")
+	for _, s := range report.Steps {
+		head := "-"
+		if s.Head != "" {
+			head = s.Head[:min(7, len(s.Head))]
+		}
+		checks := "-"
+		if len(s.Checks) > 0 {
+			checks = checkMarks(s.Checks)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Context, s.Result, head, checks)
+	}
+	tw.Flush()
+
+	for _, s := range report.Steps {
+		switch s.Result {
+		case land.Conflict:
+			fmt.Fprintf(out, "\nStopped at %s: %s; conflicts in:\n", s.Context, s.Detail)
+			for _, f := range s.Conflicts {
+				fmt.Fprintf(out, "  %s\n", f)
+			}
+		case land.ChecksFailed:
+			fmt.Fprintf(out, "\nStopped at %s: %s\n", s.Context, s.Detail)
+			for _, r := range s.Checks {
+				if !r.Passed {
+					fmt.Fprintf(out, "  ✗ %s (exit %d)  log: %s\n", r.Name, r.ExitCode, r.Log)
+				}
+			}
+		case land.Error:
+			fmt.Fprintf(out, "\nStopped at %s: %s\n", s.Context, s.Detail)
+		}
+	}
+	switch {
+	case report.BaseFails:
+		fmt.Fprintf(out, "\nChecks already fail on %s before anything is landed.\n", report.Base)
+	case report.Culprit != "":
+		fmt.Fprintf(out, "\nBisect: landing %s made the checks fail.\n", report.Culprit)
+	case report.Landed():
+		fmt.Fprintf(out, "\n\U0001f9d9 Landed %d contexts into %s\n", len(report.Steps), report.Into)
+	}
+	fmt.Fprintf(out, "Integration context: %s (%s)\n", report.Into, path)
+}
+
+func init() {
+	landCmd.Flags().String("into", "", "Integration branch (and context) to create")
+	landCmd.Flags().String("base", "", "Branch to start the integration from (default: the first context's base)")
+	landCmd.Flags().Bool("cherry-pick", false, "Cherry-pick each context's commits instead of merging")
+	landCmd.Flags().Bool("bisect", false, "Check once at the end and bisect to find the context that broke the checks")
+	landCmd.Flags().Bool("json", false, "Output the report as JSON")
+	landCmd.MarkFlagRequired("into")
+	rootCmd.AddCommand(landCmd)
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	wizctx "github.com/buck3000/wiz/internal/context"
 	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/prstatus"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +28,18 @@ var listCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		expr, _ := cmd.Flags().GetString("filter")
+		filter, err := wizctx.ParseFilter(expr)
+		if err != nil {
+			return err
+		}
+		filtered := len(tags) > 0 || len(filter) > 0
+		if filtered {
+			contexts = slices.DeleteFunc(contexts, func(c wizctx.Context) bool {
+				return !c.HasTags(tags...) || !filter.Match(&c)
+			})
+		}
 
 		asJSON, _ := cmd.Flags().GetBool("json")
 		if asJSON {
@@ -34,26 +49,60 @@ var listCmd = &cobra.Command{
 		}
 
 		if len(contexts) == 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "No contexts. Create one with: wiz create <name>")
+			if filtered {
+				fmt.Fprintln(cmd.OutOrStdout(), "No matching contexts.")
+			} else {
+				fmt.Fprintln(cmd.OutOrStdout(), "No contexts. Create one with: wiz create <name>")
+			}
 			return nil
 		}
 
 		showTasks, _ := cmd.Flags().GetBool("tasks")
 		current := os.Getenv("WIZ_CTX")
-		for _, c := range contexts {
+		for _, n := range wizctx.Tree(contexts) {
+			c := n.Context
 			marker := "  "
 			if c.Name == current {
 				marker = "\u25b8 "
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "%s\033[1;35m%s\033[0m (%s)\n", marker, c.Name, c.Strategy)
-			fmt.Fprintf(cmd.OutOrStdout(), "    branch: %s\n", c.Branch)
-			fmt.Fprintf(cmd.OutOrStdout(), "    path:   %s\n", c.Path)
+			// Stacked contexts are drawn under their parent.
+			branch, indent := "", ""
+			if n.Depth > 0 {
+				branch = strings.Repeat("   ", n.Depth-1) + "\u2514\u2500 "
+				indent = strings.Repeat("   ", n.Depth)
+			}
+			if c.Archived {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s%s\033[2m%s\033[0m (%s, archived)\n", marker, branch, c.Name, c.Strategy)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s%s\033[1;35m%s\033[0m (%s)\n", marker, branch, c.Name, c.Strategy)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s    branch: %s\n", indent, c.Branch)
+			if c.Archived {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s    archived: %s\n", indent, c.ArchivedAt.Format("2006-01-02 15:04"))
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s    path:   %s\n", indent, c.Path)
+			}
+			if c.ForkedFrom != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s    fork:   of %s at %s\n", indent, c.ForkedFrom, shortSHA(c.ForkPoint))
+			}
+			if len(c.Tags) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s    tags:   %s\n", indent, strings.Join(c.Tags, ", "))
+			}
+			if len(c.Checks) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s    checks: %s\n", indent, checkMarks(c.Checks))
+			}
+			if c.PR != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s    pr:     %s  %s\n", indent, prstatus.Summary(c.PR, c.PRChecks), c.PR.URL)
+			}
 			if showTasks {
 				if c.Task != "" {
-					fmt.Fprintf(cmd.OutOrStdout(), "    task:   %s\n", c.Task)
+					fmt.Fprintf(cmd.OutOrStdout(), "%s    task:   %s\n", indent, c.Task)
 				}
 				if c.Agent != "" {
-					fmt.Fprintf(cmd.OutOrStdout(), "    agent:  %s\n", c.Agent)
+					fmt.Fprintf(cmd.OutOrStdout(), "%s    agent:  %s\n", indent, c.Agent)
+				}
+				if c.Notes != "" {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s    notes:  %s\n", indent, strings.ReplaceAll(c.Notes, "\n", "\n"+indent+"            "))
 				}
 			}
 		}
@@ -63,6 +112,8 @@ var listCmd = &cobra.Command{
 
 func init() {
 	listCmd.Flags().Bool("json", false, "Output as JSON")
-	listCmd.Flags().Bool("tasks", false, "Show task and agent info")
+	listCmd.Flags().Bool("tasks", false, "Show task, agent and notes")
+	listCmd.Flags().StringSlice("tag", nil, "Only list contexts with this tag (repeatable)")
+	listCmd.Flags().String("filter", "", "Only list contexts matching a filter expression, e.g. 'agent=claude owner~ali login'")
 	rootCmd.AddCommand(listCmd)
 }
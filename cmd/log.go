@@ -24,9 +24,9 @@ var logCmd = &cobra.Command{
 		}
 
 		store := wizctx.NewStore(repo)
-		ctx, err := store.Get(name)
+		ctx, err := activeContext(store, name)
 		if err != nil {
-			return fmt.Errorf("context %q not found; run 'wiz list' to see available contexts", name)
+			return err
 		}
 
 		gitArgs := []string{"log", "--oneline", fmt.Sprintf("-%d", n)}
@@ -68,7 +68,7 @@ func init() {
 			if err != nil {
 				return err
 			}
-			for _, ctx := range contexts {
+			for _, ctx := range wizctx.Active(contexts) {
 				fmt.Fprintf(cmd.OutOrStdout(), "\033[1;35m%s\033[0m (%s)\n", ctx.Name, ctx.Branch)
 				gitArgs := []string{"log", "--oneline", fmt.Sprintf("-%d", n)}
 				if ctx.BaseBranch != "" {
@@ -89,9 +89,9 @@ func init() {
 		}
 
 		name := args[0]
-		ctx, err := store.Get(name)
+		ctx, err := activeContext(store, name)
 		if err != nil {
-			return fmt.Errorf("context %q not found; run 'wiz list' to see available contexts", name)
+			return err
 		}
 
 		gitArgs := []string{"log", "--oneline", fmt.Sprintf("-%d", n)}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/buck3000/wiz/internal/changes"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var moveChangesCmd = &cobra.Command{
+	Use:   "move-changes <from> <to> [paths...]",
+	Short: "Move uncommitted changes from one context to another",
+	Long: `Move the staged and unstaged changes in one context (limited to paths,
+relative to the context's root, if given) to another, as a patch against its
+working tree. With --untracked, untracked files move too.
+
+The patch is checked against the target first; if it doesn't apply, nothing
+is changed and the conflicting files are reported. Only once it has applied
+are the changes removed from the source, which is snapshotted beforehand
+(see wiz restore). The changes arrive unstaged.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		untracked, _ := cmd.Flags().GetBool("untracked")
+		checkOnly, _ := cmd.Flags().GetBool("check")
+		keep, _ := cmd.Flags().GetBool("keep")
+		if args[0] == args[1] {
+			return fmt.Errorf("source and target are the same context")
+		}
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+		from, err := activeContext(store, args[0])
+		if err != nil {
+			return err
+		}
+		to, err := activeContext(store, args[1])
+		if err != nil {
+			return err
+		}
+
+		patch, err := changes.Collect(cmd.Context(), from.Path, args[2:], untracked)
+		if err != nil {
+			return err
+		}
+		if len(patch.Files) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 No changes to move in %s\n", from.Name)
+			return nil
+		}
+		if err := changes.Check(cmd.Context(), to.Path, patch); err != nil {
+			return fmt.Errorf("%s → %s: %w", from.Name, to.Name, err)
+		}
+		if checkOnly {
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 %d file(s) would move cleanly from %s to %s:\n", len(patch.Files), from.Name, to.Name)
+			for _, f := range patch.Files {
+				fmt.Fprintf(cmd.OutOrStdout(), "   %s\n", f)
+			}
+			return nil
+		}
+
+		if !keep {
+			s, err := snapshot.TakeIfChanged(cmd.Context(), from.Path, from.Name, "before moving changes to "+to.Name)
+			if err != nil {
+				return fmt.Errorf("snapshot %s: %w", from.Name, err)
+			}
+			if s != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Saved %s as snapshot %d\n", from.Name, s.ID)
+			}
+		}
+		if err := changes.Apply(cmd.Context(), to.Path, patch); err != nil {
+			return fmt.Errorf("%s → %s: %w", from.Name, to.Name, err)
+		}
+		if !keep {
+			if err := changes.Discard(cmd.Context(), from.Path, patch); err != nil {
+				return fmt.Errorf("changes were applied to %s but not removed from %s: %w", to.Name, from.Name, err)
+			}
+		}
+
+		verb := "Moved"
+		if keep {
+			verb = "Copied"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 %s %d file(s) from %s to %s\n", verb, len(patch.Files), from.Name, to.Name)
+		for _, f := range patch.Files {
+			fmt.Fprintf(cmd.OutOrStdout(), "   %s\n", f)
+		}
+		return nil
+	},
+}
+
+func init() {
+	moveChangesCmd.Flags().BoolP("untracked", "u", false, "Also move untracked files")
+	moveChangesCmd.Flags().Bool("check", false, "Only check that the changes would apply")
+	moveChangesCmd.Flags().Bool("keep", false, "Copy the changes, leaving the source as it is")
+	rootCmd.AddCommand(moveChangesCmd)
+}
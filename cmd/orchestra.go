@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
 
+	wizctx "github.com/buck3000/wiz/internal/context"
 	"github.com/buck3000/wiz/internal/gitx"
 	"github.com/buck3000/wiz/internal/license"
 	"github.com/buck3000/wiz/internal/orchestra"
+	"github.com/buck3000/wiz/internal/snapshot"
 	"github.com/buck3000/wiz/internal/spawn"
 	"github.com/spf13/cobra"
 )
@@ -52,14 +56,33 @@ var orchestraCmd = &cobra.Command{
 		results := orchestra.Run(cmd.Context(), repo, plan, term)
 
 		var anyErr bool
+		var spawned []string
 		for _, r := range results {
 			if r.Error != nil {
 				fmt.Fprintf(cmd.ErrOrStderr(), "FAIL %s: %v\n", r.Name, r.Error)
 				anyErr = true
 			} else {
 				fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 %s: spawned\n", r.Name)
+				spawned = append(spawned, r.Name)
 			}
 		}
+
+		interval, _ := plan.SnapshotEvery()
+		if cmd.Flags().Changed("snapshot-interval") {
+			interval, _ = cmd.Flags().GetDuration("snapshot-interval")
+		}
+		if interval > 0 && len(spawned) > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Snapshotting every %s; press Ctrl-C to stop\n", interval)
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+			orchestra.AutoSnapshot(ctx, wizctx.NewStore(repo), spawned, interval, func(name string, s *snapshot.Snapshot, err error) {
+				if err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "FAIL %s: snapshot: %v\n", name, err)
+					return
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Snapshot %d of %s\n", s.ID, name)
+			})
+		}
 		if anyErr {
 			return fmt.Errorf("some tasks failed")
 		}
@@ -68,5 +91,6 @@ var orchestraCmd = &cobra.Command{
 }
 
 func init() {
+	orchestraCmd.Flags().Duration("snapshot-interval", 0, "Keep running and snapshot each task's context this often (overrides snapshot_interval)")
 	rootCmd.AddCommand(orchestraCmd)
 }
@@ -18,7 +18,7 @@ var pathCmd = &cobra.Command{
 			return err
 		}
 		store := wizctx.NewStore(repo)
-		ctx, err := store.Get(args[0])
+		ctx, err := activeContext(store, args[0])
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/prstatus"
+	"github.com/spf13/cobra"
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr [name]",
+	Short: "Show or open a context's pull request and CI status",
+	Long: `Poll the forge for the pull request of a context's branch (the current
+context by default) and print its state, review, mergeability and CI
+checks. The result is recorded on the context and shown by wiz list,
+wiz status --json and wiz watch.
+
+With --all every context is polled. --open opens the PR in a browser and
+--cached prints the last recorded state without contacting the forge.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		open, _ := cmd.Flags().GetBool("open")
+		cached, _ := cmd.Flags().GetBool("cached")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+
+		var contexts []wizctx.Context
+		switch {
+		case all && len(args) > 0:
+			return fmt.Errorf("give a context name or --all, not both")
+		case all:
+			if contexts, err = store.List(); err != nil {
+				return err
+			}
+		default:
+			name := os.Getenv("WIZ_CTX")
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if name == "" {
+				return fmt.Errorf("not in a wiz context; give a context name or --all")
+			}
+			c, err := store.Get(name)
+			if err != nil {
+				return fmt.Errorf("context %q not found; run 'wiz list' to see available contexts", name)
+			}
+			contexts = []wizctx.Context{*c}
+		}
+
+		if !cached {
+			f, err := openForge(cmd, repo)
+			if err != nil {
+				return err
+			}
+			errs := prstatus.PollAll(cmd.Context(), f, store, contexts, 4)
+			for _, c := range contexts {
+				if err := errs[c.Name]; err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+				}
+			}
+			for i := range contexts {
+				if c, err := store.Get(contexts[i].Name); err == nil {
+					contexts[i] = *c
+				}
+			}
+		}
+
+		if asJSON {
+			type prJSON struct {
+				Context  string        `json:"context"`
+				PR       *forge.PR     `json:"pr"`
+				Checks   []forge.Check `json:"checks,omitempty"`
+				PolledAt time.Time     `json:"polled_at,omitzero"`
+			}
+			out := make([]prJSON, len(contexts))
+			for i, c := range contexts {
+				out[i] = prJSON{Context: c.Name, PR: c.PR, Checks: c.PRChecks, PolledAt: c.PRPolledAt}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		}
+
+		for _, c := range contexts {
+			printPR(cmd, &c)
+			if open && c.PR != nil {
+				if err := openURL(c.PR.URL); err != nil {
+					return fmt.Errorf("open %s: %w", c.PR.URL, err)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// printPR prints a context's recorded PR and CI checks.
+func printPR(cmd *cobra.Command, c *wizctx.Context) {
+	out := cmd.OutOrStdout()
+	if c.PR == nil {
+		fmt.Fprintf(out, "\U0001f9d9 %s: no open PR; open one with: wiz finish %s\n", c.Name, c.Name)
+		return
+	}
+	fmt.Fprintf(out, "\U0001f9d9 %s: %s\n", c.Name, prstatus.Summary(c.PR, c.PRChecks))
+	fmt.Fprintf(out, "   %s\n", c.PR.URL)
+	for _, ch := range c.PRChecks {
+		mark := "✓"
+		switch ch.Status {
+		case forge.CheckPending:
+			mark = "…"
+		case forge.CheckFailure:
+			mark = "✗"
+		}
+		line := fmt.Sprintf("   %s %s", mark, ch.Name)
+		if ch.Status == forge.CheckFailure && ch.URL != "" {
+			line += "  " + ch.URL
+		}
+		fmt.Fprintln(out, line)
+	}
+}
+
+// openURL opens url in the user's browser.
+func openURL(url string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", url)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		c = exec.Command("xdg-open", url)
+	}
+	return c.Start()
+}
+
+func init() {
+	prCmd.Flags().Bool("all", false, "Poll every context")
+	prCmd.Flags().Bool("open", false, "Open the PR in a browser")
+	prCmd.Flags().Bool("cached", false, "Show the last recorded state without polling the forge")
+	prCmd.Flags().Bool("json", false, "Output as JSON")
+	rootCmd.AddCommand(prCmd)
+}
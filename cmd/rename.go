@@ -2,18 +2,29 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/config"
 	wizctx "github.com/buck3000/wiz/internal/context"
 	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/snapshot"
 	"github.com/spf13/cobra"
 )
 
 var renameCmd = &cobra.Command{
 	Use:   "rename <old> <new>",
 	Short: "Rename a context",
-	Args:  cobra.ExactArgs(2),
+	Long: `Rename a context. --branch also renames its branch (to what wiz create would
+name it, see branch_template) and --move moves its directory to match the new
+name. Shells inside the context pick up the new name on their next prompt.`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		oldName, newName := args[0], args[1]
+		renameBranch, _ := cmd.Flags().GetBool("branch")
+		move, _ := cmd.Flags().GetBool("move")
 
 		repo, err := gitx.Discover(".")
 		if err != nil {
@@ -21,15 +32,115 @@ var renameCmd = &cobra.Command{
 		}
 
 		store := wizctx.NewStore(repo)
+		c, err := store.Get(oldName)
+		if err != nil {
+			return err
+		}
+		if err := wizctx.ValidateName(newName); err != nil {
+			return err
+		}
+		if _, err := store.Get(newName); err == nil {
+			return fmt.Errorf("context %q already exists", newName)
+		}
+		if c.Archived && (renameBranch || move) {
+			return fmt.Errorf("context %q is archived; run 'wiz unarchive %s' before renaming its branch or directory", oldName, oldName)
+		}
+
+		branch := c.Branch
+		if renameBranch {
+			tmpl := branchTemplate(repo, config.Load(repo), c.Template)
+			if branch, err = wizctx.BranchName(cmd.Context(), repo, "", tmpl, newName); err != nil {
+				return err
+			}
+			if branch != c.Branch && repo.BranchExists(cmd.Context(), branch) {
+				return fmt.Errorf("branch %q already exists", branch)
+			}
+		}
+
+		prov := wizctx.NewProvisioner(c.Strategy, repo)
+		if branch != c.Branch {
+			if err := prov.RenameBranch(cmd.Context(), c.Path, c.Branch, branch); err != nil {
+				return err
+			}
+		}
+		path := c.Path
+		if move {
+			if path, err = prov.Move(cmd.Context(), c.Path, newName); err != nil {
+				if branch != c.Branch {
+					prov.RenameBranch(cmd.Context(), c.Path, branch, c.Branch)
+				}
+				return err
+			}
+		}
+
 		if err := store.Rename(cmd.Context(), oldName, newName); err != nil {
 			return err
 		}
+		snapshotDir := path
+		if c.Archived {
+			snapshotDir = repo.WorkDir
+		}
+		if err := snapshot.Rename(cmd.Context(), snapshotDir, oldName, newName); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+		}
+		if c.Archived && c.Strategy == wizctx.StrategyClone {
+			if tip, err := repo.Run(cmd.Context(), "rev-parse", "--verify", "--quiet", archiveRef(oldName)); err == nil {
+				repo.Run(cmd.Context(), "update-ref", archiveRef(newName), tip)
+				repo.Run(cmd.Context(), "update-ref", "-d", archiveRef(oldName))
+			}
+		}
+		// Check logs are kept per context name; take them along.
+		logRoot := config.ChecksDir(repo)
+		oldLogs, newLogs := check.LogDir(logRoot, oldName), check.LogDir(logRoot, newName)
+		logsMoved := oldLogs == newLogs
+		if !logsMoved {
+			os.RemoveAll(newLogs)
+			err := os.Rename(oldLogs, newLogs)
+			logsMoved = err == nil || os.IsNotExist(err)
+		}
+		if err := store.Update(cmd.Context(), newName, func(nc *wizctx.Context) {
+			nc.Branch, nc.Path = branch, path
+			if !logsMoved {
+				nc.Checks = nil
+			}
+			for i, r := range nc.Checks {
+				if strings.HasPrefix(r.Log, oldLogs+string(filepath.Separator)) {
+					nc.Checks[i].Log = newLogs + strings.TrimPrefix(r.Log, oldLogs)
+				}
+			}
+			if branch != c.Branch {
+				// The PR belongs to the old branch.
+				nc.PR, nc.PRChecks = nil, nil
+			}
+		}); err != nil {
+			return err
+		}
+		if branch != c.Branch {
+			// Stacked children are based on the renamed branch.
+			contexts, _ := store.List()
+			for _, child := range contexts {
+				if child.Parent == newName {
+					store.Update(cmd.Context(), child.Name, func(c *wizctx.Context) { c.BaseBranch = branch })
+				}
+			}
+		}
 
 		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Renamed: %s \u2192 %s\n", oldName, newName)
+		if branch != c.Branch {
+			fmt.Fprintf(cmd.OutOrStdout(), "   Branch:  %s \u2192 %s\n", c.Branch, branch)
+			if c.PR != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Note: PR #%d is for %s; 'wiz finish' will open a new one for %s\n", c.PR.Number, c.Branch, branch)
+			}
+		}
+		if path != c.Path {
+			fmt.Fprintf(cmd.OutOrStdout(), "   Dir:     %s\n", path)
+		}
 		return nil
 	},
 }
 
 func init() {
+	renameCmd.Flags().Bool("branch", false, "Also rename the context's branch")
+	renameCmd.Flags().Bool("move", false, "Also move the context's directory")
 	rootCmd.AddCommand(renameCmd)
 }
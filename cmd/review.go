@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/buck3000/wiz/internal/agent"
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/license"
+	"github.com/buck3000/wiz/internal/review"
+	"github.com/spf13/cobra"
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review <name>",
+	Short: "Have a reviewer agent judge a context's diff",
+	Long: `Send a context's diff against its base branch, together with its task, to a
+reviewer agent running headless. The structured review (verdict, issues and
+summary) is stored with the context and printed as Markdown or JSON.
+
+The reviewer defaults to claude; set "reviewer" in the wiz config or pass
+--agent to use another agent.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		agentName, _ := cmd.Flags().GetString("agent")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		show, _ := cmd.Flags().GetBool("show")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		if format == "" && output != "" && strings.EqualFold(filepath.Ext(output), ".json") {
+			format = "json"
+		}
+		if format == "" {
+			format = "markdown"
+		}
+		if format != "markdown" && format != "json" {
+			return fmt.Errorf("unknown format %q (want markdown or json)", format)
+		}
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+
+		store := wizctx.NewStore(repo)
+		ctx, err := activeContext(store, name)
+		if err != nil {
+			return err
+		}
+
+		if show {
+			if ctx.Review == nil {
+				return fmt.Errorf("context %q has not been reviewed; run 'wiz review %s'", name, name)
+			}
+			return writeReview(cmd, ctx.Name, ctx.Review, format, output)
+		}
+
+		tier, _ := license.CheckLicense()
+		if !license.LimitsForTier(tier).AIReview {
+			return fmt.Errorf("wiz review requires Wiz Team (current tier: %s)\n\n  Upgrade: https://wiz.dev/team", tier)
+		}
+
+		if agentName == "" {
+			agentName = config.Load(repo).Reviewer
+		}
+		ag, err := agent.Resolve(repo, agentName)
+		if err != nil {
+			return err
+		}
+
+		base := ctx.BaseBranch
+		if base == "" {
+			base, err = repo.CurrentBranch(cmd.Context())
+			if err != nil {
+				return err
+			}
+		}
+		gitDiff := exec.CommandContext(cmd.Context(), "git", "diff", fmt.Sprintf("%s...%s", base, ctx.Branch))
+		gitDiff.Dir = ctx.Path
+		diff, err := gitDiff.Output()
+		if err != nil {
+			return fmt.Errorf("diff %s against %s: %w", ctx.Branch, base, err)
+		}
+		if len(bytes.TrimSpace(diff)) == 0 {
+			return fmt.Errorf("context %q has no committed changes against %s", name, base)
+		}
+		gitHead := exec.CommandContext(cmd.Context(), "git", "rev-parse", "--short", ctx.Branch)
+		gitHead.Dir = ctx.Path
+		head, _ := gitHead.Output()
+
+		runCtx, cancel := gocontext.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Reviewing %s (%s...%s) with %s...\n", name, base, ctx.Branch, ag.Name)
+		c, cleanup, err := ag.Cmd(runCtx, ctx.Path, review.BuildPrompt(ctx.Task, string(diff)), true)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		var stdout bytes.Buffer
+		c.Stdout = &stdout
+		c.Stderr = cmd.ErrOrStderr()
+		if err := c.Run(); err != nil {
+			if runCtx.Err() == gocontext.DeadlineExceeded {
+				return fmt.Errorf("reviewer %s did not finish within %s", ag.Name, timeout)
+			}
+			return fmt.Errorf("reviewer %s failed: %w", ag.Name, err)
+		}
+
+		r, err := review.Parse(stdout.String())
+		if err != nil {
+			return fmt.Errorf("%w\n\nReviewer output:\n%s", err, lastLines(stdout.String(), 20))
+		}
+		r.Reviewer = ag.Name
+		r.Base = base
+		r.Head = strings.TrimSpace(string(head))
+		r.CreatedAt = time.Now()
+
+		if err := store.Update(cmd.Context(), name, func(c *wizctx.Context) {
+			c.Review = r
+		}); err != nil {
+			return err
+		}
+		return writeReview(cmd, ctx.Name, r, format, output)
+	},
+}
+
+// writeReview prints a review or writes it to path in the given format.
+func writeReview(cmd *cobra.Command, name string, r *review.Review, format, path string) error {
+	var data []byte
+	if format == "json" {
+		var err error
+		data, err = json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(r.Markdown(name))
+	}
+
+	if path == "" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Review (%s) written to %s\n", r.Verdict, path)
+	return nil
+}
+
+// lastLines returns at most the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func init() {
+	reviewCmd.Flags().String("agent", "", "Reviewer agent (default: config reviewer, else claude)")
+	reviewCmd.Flags().String("format", "", "Output format: markdown or json (default: from --output extension, else markdown)")
+	reviewCmd.Flags().StringP("output", "o", "", "Write the review to a file instead of stdout")
+	reviewCmd.Flags().Bool("show", false, "Show the stored review without running the reviewer")
+	reviewCmd.Flags().Duration("timeout", 10*time.Minute, "Maximum time to wait for the reviewer")
+	rootCmd.AddCommand(reviewCmd)
+}
@@ -34,7 +34,7 @@ var rootCmd = &cobra.Command{
 			return nil
 		}
 
-		result, err := tui.Run(contexts)
+		result, err := tui.Run(wizctx.Active(contexts))
 		if err != nil {
 			return err
 		}
@@ -58,6 +58,14 @@ var rootCmd = &cobra.Command{
 			if result.Context != nil {
 				deleteCmd.RunE(cmd, []string{result.Context.Name})
 			}
+		case tui.ActionFork:
+			if result.Context != nil {
+				c, err := forkContext(cmd, store, repo, result.Context, result.Name, "", result.Context.Strategy)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "\U0001f9d9 Forked %s into %s (branch %s)\n", result.Context.Name, c.Name, c.Branch)
+			}
 		case tui.ActionCreate:
 			fmt.Fprintln(os.Stderr, "Use: wiz create <name>")
 		}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
 
 	"github.com/buck3000/wiz/internal/agent"
 	wizctx "github.com/buck3000/wiz/internal/context"
@@ -21,6 +23,9 @@ var runCmd = &cobra.Command{
 		name := args[0]
 		agentName, _ := cmd.Flags().GetString("agent")
 		prompt, _ := cmd.Flags().GetString("prompt")
+		promptFile, _ := cmd.Flags().GetString("prompt-file")
+		inputs, _ := cmd.Flags().GetStringToString("input")
+		headless, _ := cmd.Flags().GetBool("headless")
 
 		repo, err := gitx.Discover(".")
 		if err != nil {
@@ -28,7 +33,7 @@ var runCmd = &cobra.Command{
 		}
 
 		store := wizctx.NewStore(repo)
-		ctx, err := store.Get(name)
+		ctx, err := activeContext(store, name)
 		if err != nil {
 			return err
 		}
@@ -39,6 +44,9 @@ var runCmd = &cobra.Command{
 			"WIZ_DIR="+ctx.Path,
 			"WIZ_BRANCH="+ctx.Branch,
 		)
+		if len(ctx.Ports) > 0 {
+			env = append(env, "WIZ_PORTS="+portList(ctx.Ports))
+		}
 
 		// Agent mode: resolve agent and exec it.
 		if agentName == "" {
@@ -49,16 +57,33 @@ var runCmd = &cobra.Command{
 			if err != nil {
 				return err
 			}
+			prompt, err = promptText(repo, prompt, promptFile)
+			if err != nil {
+				return err
+			}
+			// Fall back to context's task as prompt; it was rendered at create time.
 			if prompt == "" {
 				prompt = ctx.Task
+			} else {
+				prompt, err = renderPrompt(cmd, repo, ctx, prompt, promptFile, inputs)
+				if err != nil {
+					return err
+				}
+			}
+			c, cleanup, err := ag.Cmd(cmd.Context(), ctx.Path, prompt, headless)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			if prompt != "" && !ag.TakesPrompt(headless) {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n", prompt)
+			}
+			if c.Stdin == nil {
+				c.Stdin = os.Stdin
 			}
-			bin, agentArgs := ag.BuildExecArgs(prompt)
-			c := exec.CommandContext(cmd.Context(), bin, agentArgs...)
-			c.Dir = ctx.Path
-			c.Stdin = os.Stdin
 			c.Stdout = cmd.OutOrStdout()
 			c.Stderr = cmd.OutOrStderr()
-			c.Env = env
+			c.Env = append(env, ag.Environ()...)
 			return c.Run()
 		}
 
@@ -79,8 +104,20 @@ var runCmd = &cobra.Command{
 	},
 }
 
+// portList formats ports as a comma-separated list.
+func portList(ports []int) string {
+	s := make([]string, len(ports))
+	for i, p := range ports {
+		s[i] = strconv.Itoa(p)
+	}
+	return strings.Join(s, ",")
+}
+
 func init() {
-	runCmd.Flags().String("agent", "", "Agent to run (claude, gemini, codex, or custom)")
+	runCmd.Flags().String("agent", "", "Agent to run (see: wiz agents list)")
 	runCmd.Flags().String("prompt", "", "Prompt to send to the agent")
+	runCmd.Flags().String("prompt-file", "", "Read the prompt from a file (e.g. from .wiz/prompts/)")
+	runCmd.Flags().StringToString("input", nil, "Prompt template input (key=value, repeatable)")
+	runCmd.Flags().Bool("headless", false, "Run the agent non-interactively")
 	rootCmd.AddCommand(runCmd)
 }
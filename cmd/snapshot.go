@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot <name>",
+	Short: "Record a context's working tree as a rollback point",
+	Long: `Record the context's working tree, including uncommitted and untracked files,
+as a commit on refs/wiz/snapshots/<name>/<id>. The branch, index and working
+tree are left untouched. Roll back with wiz restore.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		message, _ := cmd.Flags().GetString("message")
+		c, err := snapshotContext(args[0])
+		if err != nil {
+			return err
+		}
+		s, err := snapshot.Take(cmd.Context(), c.Path, c.Name, message)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Snapshot %d of %s: %s\n", s.ID, c.Name, shortSHA(s.Commit))
+		return nil
+	},
+}
+
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots <name>",
+	Short: "List a context's snapshots",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		c, err := snapshotContext(args[0])
+		if err != nil {
+			return err
+		}
+		snaps, err := snapshot.List(cmd.Context(), c.Path, c.Name)
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			if snaps == nil {
+				snaps = []snapshot.Snapshot{}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(snaps)
+		}
+		if len(snaps) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No snapshots of %s. Create one with: wiz snapshot %s\n", c.Name, c.Name)
+			return nil
+		}
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tCREATED\tCOMMIT\tON\tMESSAGE")
+		for _, s := range snaps {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", s.ID, s.Created.Format("2006-01-02 15:04"),
+				shortSHA(s.Commit), shortSHA(s.Head), s.Message)
+		}
+		return tw.Flush()
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <name> <id>",
+	Short: "Roll a context back to a snapshot",
+	Long: `Roll the context back to a snapshot: its branch is reset to the commit the
+snapshot was taken on and the snapshot's files are restored as uncommitted
+changes. Untracked files that are not in the snapshot are removed.
+
+The current state is snapshotted first (unless unchanged since the latest
+snapshot), so a restore can itself be undone.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid snapshot id %q", args[1])
+		}
+		c, err := snapshotContext(args[0])
+		if err != nil {
+			return err
+		}
+		s, err := snapshot.Get(cmd.Context(), c.Path, c.Name, id)
+		if err != nil {
+			return err
+		}
+
+		saved, err := snapshot.TakeIfChanged(cmd.Context(), c.Path, c.Name, fmt.Sprintf("before restoring snapshot %d", id))
+		if err != nil {
+			return fmt.Errorf("snapshot current state: %w", err)
+		}
+		if saved != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Saved current state as snapshot %d\n", saved.ID)
+		}
+		if err := snapshot.Restore(cmd.Context(), c.Path, s); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\U0001f9d9 Restored %s to snapshot %d (%s)\n", c.Name, s.ID, s.Message)
+		return nil
+	},
+}
+
+// snapshotContext looks up the named context for the snapshot commands.
+func snapshotContext(name string) (*wizctx.Context, error) {
+	repo, err := gitx.Discover(".")
+	if err != nil {
+		return nil, err
+	}
+	return activeContext(wizctx.NewStore(repo), name)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+func init() {
+	snapshotCmd.Flags().StringP("message", "m", "", "Describe the snapshot")
+	snapshotsCmd.Flags().Bool("json", false, "Output as JSON")
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(snapshotsCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
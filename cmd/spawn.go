@@ -18,6 +18,8 @@ var spawnCmd = &cobra.Command{
 		name := args[0]
 		agentName, _ := cmd.Flags().GetString("agent")
 		prompt, _ := cmd.Flags().GetString("prompt")
+		promptFile, _ := cmd.Flags().GetString("prompt-file")
+		inputs, _ := cmd.Flags().GetStringToString("input")
 
 		repo, err := gitx.Discover(".")
 		if err != nil {
@@ -25,7 +27,7 @@ var spawnCmd = &cobra.Command{
 		}
 
 		store := wizctx.NewStore(repo)
-		ctx, err := store.Get(name)
+		ctx, err := activeContext(store, name)
 		if err != nil {
 			return err
 		}
@@ -43,9 +45,18 @@ var spawnCmd = &cobra.Command{
 			if err != nil {
 				return err
 			}
-			// Fall back to context's task as prompt.
+			prompt, err = promptText(repo, prompt, promptFile)
+			if err != nil {
+				return err
+			}
+			// Fall back to context's task as prompt; it was rendered at create time.
 			if prompt == "" {
 				prompt = ctx.Task
+			} else {
+				prompt, err = renderPrompt(cmd, repo, ctx, prompt, promptFile, inputs)
+				if err != nil {
+					return err
+				}
 			}
 			shellCmd = ag.BuildCommand(prompt)
 		} else {
@@ -67,7 +78,9 @@ var spawnCmd = &cobra.Command{
 }
 
 func init() {
-	spawnCmd.Flags().String("agent", "", "Agent to run (claude, gemini, codex, or custom)")
+	spawnCmd.Flags().String("agent", "", "Agent to run (see: wiz agents list)")
 	spawnCmd.Flags().String("prompt", "", "Prompt to send to the agent")
+	spawnCmd.Flags().String("prompt-file", "", "Read the prompt from a file (e.g. from .wiz/prompts/)")
+	spawnCmd.Flags().StringToString("input", nil, "Prompt template input (key=value, repeatable)")
 	rootCmd.AddCommand(spawnCmd)
 }
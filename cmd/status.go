@@ -4,7 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/buck3000/wiz/internal/check"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
 	"github.com/buck3000/wiz/internal/gitx"
 	"github.com/spf13/cobra"
 )
@@ -28,8 +32,14 @@ var statusCmd = &cobra.Command{
 			return nil
 		}
 
+		// The context may have been renamed or moved since the shell entered it.
+		c := lookupStatusContext(ctxName, wizDir)
+		if c != nil {
+			ctxName, wizDir = c.Name, c.Path
+		}
+
 		if asJSON {
-			return printJSONStatus(cmd, ctxName, repoName, wizDir)
+			return printJSONStatus(cmd, c, ctxName, repoName, wizDir)
 		}
 
 		if porcelain {
@@ -60,19 +70,44 @@ var statusCmd = &cobra.Command{
 }
 
 type statusJSON struct {
-	Context   string `json:"context"`
-	Repo      string `json:"repo"`
-	Dir       string `json:"dir"`
-	Branch    string `json:"branch"`
-	State     string `json:"state"`
-	Ahead     int    `json:"ahead"`
-	Behind    int    `json:"behind"`
-	Staged    int    `json:"staged"`
-	Unstaged  int    `json:"unstaged"`
-	Untracked int    `json:"untracked"`
+	Context   string         `json:"context"`
+	Repo      string         `json:"repo"`
+	Dir       string         `json:"dir"`
+	Branch    string         `json:"branch"`
+	State     string         `json:"state"`
+	Ahead     int            `json:"ahead"`
+	Behind    int            `json:"behind"`
+	Staged    int            `json:"staged"`
+	Unstaged  int            `json:"unstaged"`
+	Untracked int            `json:"untracked"`
+	Checks    []check.Result `json:"checks,omitempty"`
+	PR        *forge.PR      `json:"pr,omitempty"`
+	PRChecks  []forge.Check  `json:"pr_checks,omitempty"`
+}
+
+// lookupStatusContext finds the shell's context by name or former name. The
+// store is located from the context directory (<repo>/.git/wiz/trees/<dir>),
+// which also works from clones and after the directory was moved, falling
+// back to the current repository.
+func lookupStatusContext(name, dir string) *wizctx.Context {
+	var repo *gitx.Repo
+	if wizDir := filepath.Dir(filepath.Dir(dir)); dir != "" && filepath.Base(wizDir) == "wiz" {
+		repo, _ = gitx.Discover(filepath.Dir(filepath.Dir(wizDir)))
+	}
+	if repo == nil {
+		var err error
+		if repo, err = gitx.Discover("."); err != nil {
+			return nil
+		}
+	}
+	c, err := wizctx.NewStore(repo).Resolve(name)
+	if err != nil {
+		return nil
+	}
+	return c
 }
 
-func printJSONStatus(cmd *cobra.Command, ctxName, repoName, dir string) error {
+func printJSONStatus(cmd *cobra.Command, c *wizctx.Context, ctxName, repoName, dir string) error {
 	s := statusJSON{
 		Context: ctxName,
 		Repo:    repoName,
@@ -96,11 +131,18 @@ func printJSONStatus(cmd *cobra.Command, ctxName, repoName, dir string) error {
 		}
 	}
 
+	if c != nil {
+		s.Checks = c.Checks
+		s.PR, s.PRChecks = c.PR, c.PRChecks
+	}
+
 	enc := json.NewEncoder(cmd.OutOrStdout())
 	enc.SetIndent("", "  ")
 	return enc.Encode(s)
 }
 
+// printPorcelainStatus prints "<context> <repo> <branch> <state> <ahead>
+// <behind> <dir>"; the directory comes last as it may contain spaces.
 func printPorcelainStatus(cmd *cobra.Command, ctxName, repoName, dir string) error {
 	branch := os.Getenv("WIZ_BRANCH")
 	state := "clean"
@@ -112,14 +154,14 @@ func printPorcelainStatus(cmd *cobra.Command, ctxName, repoName, dir string) err
 			if st.Dirty {
 				state = "dirty"
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s %s %d %d\n",
-				ctxName, repoName, branch, state, st.Ahead, st.Behind)
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s %s %d %d %s\n",
+				ctxName, repoName, branch, state, st.Ahead, st.Behind, dir)
 			return nil
 		}
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s %s 0 0\n",
-		ctxName, repoName, branch, state)
+	fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s %s 0 0 %s\n",
+		ctxName, repoName, branch, state, dir)
 	return nil
 }
 
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/spf13/cobra"
+)
+
+// syncResult is the outcome of syncing one context.
+type syncResult struct {
+	Context   string   `json:"context"`
+	Onto      string   `json:"onto"`
+	Result    string   `json:"result"` // synced, up-to-date, skipped, conflict, error
+	Detail    string   `json:"detail,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	Before    [2]int   `json:"before"` // ahead, behind
+	After     [2]int   `json:"after"`
+	// parentHead is the parent commit a stacked context is now based on;
+	// it is saved when updateParentHead is set.
+	parentHead       string
+	updateParentHead bool
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync <name...>",
+	Short: "Rebase or merge contexts onto their updated base branch",
+	Long: `Fetch, then bring each context's branch up to date with its base branch
+(the base's upstream when it has one). Contexts are rebased unless
+"sync_mode" is "merge" in the wiz config or --merge is given.
+
+Dirty contexts are skipped unless --autostash is given. A conflicting
+rebase or merge is aborted and reported; the remaining contexts still sync.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		merge, _ := cmd.Flags().GetBool("merge")
+		rebase, _ := cmd.Flags().GetBool("rebase")
+		autostash, _ := cmd.Flags().GetBool("autostash")
+		noFetch, _ := cmd.Flags().GetBool("no-fetch")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		repo, err := gitx.Discover(".")
+		if err != nil {
+			return err
+		}
+		store := wizctx.NewStore(repo)
+
+		mode := config.Load(repo).SyncMode
+		if merge {
+			mode = "merge"
+		}
+		if rebase {
+			mode = "rebase"
+		}
+		if mode != "rebase" && mode != "merge" {
+			return fmt.Errorf("unknown sync_mode %q (want rebase or merge)", mode)
+		}
+
+		contexts, err := store.List()
+		if err != nil {
+			return err
+		}
+		// Archived contexts have no working directory to sync.
+		existing := wizctx.Active(contexts)
+		selected := make(map[string]bool)
+		if all {
+			for _, c := range existing {
+				selected[c.Name] = true
+			}
+		} else {
+			if len(args) == 0 {
+				return fmt.Errorf("usage: wiz sync <name...> or wiz sync --all")
+			}
+			for _, name := range args {
+				if _, err := activeContext(store, name); err != nil {
+					return err
+				}
+				// Children are restacked along with their parent.
+				selected[name] = true
+				for _, child := range wizctx.Descendants(existing, name) {
+					selected[child] = true
+				}
+			}
+		}
+
+		if !noFetch {
+			if remotes, _ := repo.Run(cmd.Context(), "remote"); remotes != "" {
+				if !asJSON {
+					fmt.Fprintf(cmd.ErrOrStderr(), "\U0001f9d9 Fetching...\n")
+				}
+				if _, err := repo.Run(cmd.Context(), "fetch", "--quiet", "--all"); err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: fetch failed: %v\n", err)
+				}
+			}
+		}
+
+		byName := make(map[string]*wizctx.Context, len(existing))
+		for i := range existing {
+			byName[existing[i].Name] = &existing[i]
+		}
+		current, _ := repo.CurrentBranch(cmd.Context())
+		var results []syncResult
+		// Tree order syncs parents before the children stacked on them.
+		for _, n := range wizctx.Tree(existing) {
+			c := n.Context
+			if !selected[c.Name] {
+				continue
+			}
+			base := c.BaseBranch
+			if base == "" {
+				base = current
+			}
+			r := syncContext(cmd, repo, &c, byName[c.Parent], base, mode, autostash, !noFetch)
+			if r.updateParentHead {
+				if err := store.Update(cmd.Context(), c.Name, func(c *wizctx.Context) {
+					c.ParentHead = r.parentHead
+				}); err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: save %s: %v\n", c.Name, err)
+				}
+			}
+			results = append(results, r)
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				return err
+			}
+		} else {
+			printSyncResults(cmd, results)
+		}
+
+		failed := 0
+		for _, r := range results {
+			if r.Result == "conflict" || r.Result == "error" {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d contexts could not be synced", failed, len(results))
+		}
+		return nil
+	},
+}
+
+// syncContext brings one context up to date with base, or with its parent's
+// branch when it is stacked on a parent context.
+func syncContext(cmd *cobra.Command, repo *gitx.Repo, c, parent *wizctx.Context, base, mode string, autostash, useUpstream bool) syncResult {
+	r := syncResult{Context: c.Name, Onto: base}
+
+	if parent != nil {
+		r.Onto = parent.Branch
+	} else if useUpstream {
+		// Prefer the base's upstream so fetched commits are picked up.
+		if up, err := repo.Run(cmd.Context(), "rev-parse", "--abbrev-ref", "--symbolic-full-name", base+"@{upstream}"); err == nil && up != "" {
+			r.Onto = up
+		}
+	}
+	// Clone contexts share the main repository's objects, so the commit can
+	// be used there directly.
+	onto, err := repo.Run(cmd.Context(), "rev-parse", "--verify", r.Onto+"^{commit}")
+	if err != nil {
+		r.Result, r.Detail = "error", fmt.Sprintf("unknown base %q", r.Onto)
+		return r
+	}
+
+	before, err := gitx.StatusAgainst(cmd.Context(), c.Path, onto)
+	if err != nil {
+		r.Result, r.Detail = "error", err.Error()
+		return r
+	}
+	r.Before = [2]int{before.Ahead, before.Behind}
+	r.After = r.Before
+
+	switch {
+	case before.Behind == 0:
+		r.Result = "up-to-date"
+		if parent != nil && c.ParentHead != onto {
+			r.parentHead, r.updateParentHead = onto, true
+		}
+		return r
+	case before.Dirty && !autostash:
+		r.Result, r.Detail = "skipped", "uncommitted changes (use --autostash)"
+		return r
+	}
+
+	switch {
+	case mode == "merge":
+		err = gitx.Merge(cmd.Context(), c.Path, onto, autostash)
+	case c.ParentHead != "" && gitx.IsAncestor(cmd.Context(), c.Path, c.ParentHead, "HEAD"):
+		// Only replay the context's own commits: its parent's old commits
+		// may have been rewritten by the parent's sync, or squash-merged
+		// if the parent was finished.
+		err = gitx.RebaseOnto(cmd.Context(), c.Path, onto, c.ParentHead, autostash)
+	default:
+		err = gitx.Rebase(cmd.Context(), c.Path, onto, autostash)
+	}
+	var conflict *gitx.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		r.Result, r.Detail, r.Conflicts = "conflict", mode+" aborted", conflict.Files
+		return r
+	case err != nil:
+		r.Result, r.Detail = "error", err.Error()
+		return r
+	}
+
+	r.Result = "synced"
+	if parent != nil {
+		r.parentHead, r.updateParentHead = onto, true
+	} else if c.ParentHead != "" {
+		// A former child is now based directly on its base branch.
+		r.updateParentHead = true
+	}
+	if after, err := gitx.StatusAgainst(cmd.Context(), c.Path, onto); err == nil {
+		r.After = [2]int{after.Ahead, after.Behind}
+	}
+	return r
+}
+
+func printSyncResults(cmd *cobra.Command, results []syncResult) {
+	out := cmd.OutOrStdout()
+	ab := func(v [2]int) string { return fmt.Sprintf("↑%d ↓%d", v[0], v[1]) }
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTEXT\tONTO\tRESULT\tBEFORE\tAFTER")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Context, r.Onto, r.Result, ab(r.Before), ab(r.After))
+	}
+	tw.Flush()
+
+	for _, r := range results {
+		switch {
+		case len(r.Conflicts) > 0:
+			fmt.Fprintf(out, "\n%s: %s; conflicts in:\n", r.Context, r.Detail)
+			for _, f := range r.Conflicts {
+				fmt.Fprintf(out, "  %s\n", f)
+			}
+		case r.Detail != "":
+			fmt.Fprintf(out, "\n%s: %s\n", r.Context, strings.TrimSpace(r.Detail))
+		}
+	}
+}
+
+func init() {
+	syncCmd.Flags().Bool("all", false, "Sync all contexts")
+	syncCmd.Flags().Bool("merge", false, "Merge the base instead of rebasing")
+	syncCmd.Flags().Bool("rebase", false, "Rebase onto the base (overrides sync_mode)")
+	syncCmd.Flags().Bool("autostash", false, "Stash uncommitted changes around the sync instead of skipping")
+	syncCmd.Flags().Bool("no-fetch", false, "Don't fetch; sync onto the local base branch")
+	syncCmd.Flags().Bool("json", false, "Output results as JSON")
+	syncCmd.MarkFlagsMutuallyExclusive("merge", "rebase")
+	rootCmd.AddCommand(syncCmd)
+}
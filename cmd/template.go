@@ -3,7 +3,9 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/buck3000/wiz/internal/config"
 	"github.com/buck3000/wiz/internal/gitx"
 	"github.com/buck3000/wiz/internal/template"
 	"github.com/spf13/cobra"
@@ -23,6 +25,17 @@ var templateSaveCmd = &cobra.Command{
 		base, _ := cmd.Flags().GetString("base")
 		agent, _ := cmd.Flags().GetString("agent")
 		strategy, _ := cmd.Flags().GetString("strategy")
+		branchTmpl, _ := cmd.Flags().GetString("branch-template")
+		checkSpecs, _ := cmd.Flags().GetStringArray("check")
+
+		var checks []config.CheckConfig
+		for _, spec := range checkSpecs {
+			name, run, ok := strings.Cut(spec, "=")
+			if !ok || name == "" || run == "" {
+				return fmt.Errorf("invalid --check %q; want name=command", spec)
+			}
+			checks = append(checks, config.CheckConfig{Name: name, Run: run})
+		}
 
 		repo, err := gitx.Discover(".")
 		if err != nil {
@@ -31,10 +44,12 @@ var templateSaveCmd = &cobra.Command{
 
 		store := template.NewStore(repo)
 		t := template.Template{
-			Name:     name,
-			Base:     base,
-			Strategy: strategy,
-			Agent:    agent,
+			Name:           name,
+			Base:           base,
+			Strategy:       strategy,
+			Agent:          agent,
+			Checks:         checks,
+			BranchTemplate: branchTmpl,
 		}
 		if err := store.Save(t); err != nil {
 			return err
@@ -81,6 +96,16 @@ var templateListCmd = &cobra.Command{
 			if t.Strategy != "" {
 				fmt.Fprintf(cmd.OutOrStdout(), " (%s)", t.Strategy)
 			}
+			if t.BranchTemplate != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), " branch: %s", t.BranchTemplate)
+			}
+			if len(t.Checks) > 0 {
+				names := make([]string, len(t.Checks))
+				for i, c := range t.Checks {
+					names[i] = c.Name
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), " checks: %s", strings.Join(names, ", "))
+			}
 			fmt.Fprintln(cmd.OutOrStdout())
 		}
 		return nil
@@ -109,6 +134,8 @@ func init() {
 	templateSaveCmd.Flags().String("base", "", "Default base branch")
 	templateSaveCmd.Flags().String("agent", "", "Default agent")
 	templateSaveCmd.Flags().String("strategy", "", "Default strategy")
+	templateSaveCmd.Flags().String("branch-template", "", "Branch name template, e.g. '{{.User}}/{{.Name}}'")
+	templateSaveCmd.Flags().StringArray("check", nil, "Check to run for contexts from this template (name=command, repeatable)")
 
 	templateListCmd.Flags().Bool("json", false, "Output as JSON")
 
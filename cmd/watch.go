@@ -4,6 +4,7 @@ import (
 	"time"
 
 	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
 	"github.com/buck3000/wiz/internal/gitx"
 	"github.com/buck3000/wiz/internal/tui"
 	"github.com/spf13/cobra"
@@ -14,18 +15,26 @@ var watchCmd = &cobra.Command{
 	Short: "Live dashboard of all contexts",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		interval, _ := cmd.Flags().GetDuration("interval")
+		prInterval, _ := cmd.Flags().GetDuration("pr-interval")
 
 		repo, err := gitx.Discover(".")
 		if err != nil {
 			return err
 		}
 
+		// PR status is polled only when a forge is reachable.
+		var f forge.Forge
+		if prInterval > 0 {
+			f, _ = openForge(cmd, repo)
+		}
+
 		store := wizctx.NewStore(repo)
-		return tui.RunDashboard(store, interval)
+		return tui.RunDashboard(store, interval, f, prInterval)
 	},
 }
 
 func init() {
 	watchCmd.Flags().Duration("interval", 2*time.Second, "Refresh interval")
+	watchCmd.Flags().Duration("pr-interval", time.Minute, "PR and CI status poll interval (0 to disable)")
 	rootCmd.AddCommand(watchCmd)
 }
@@ -1,34 +1,278 @@
 package agent
 
-import "strings"
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Prompt delivery modes. A flag mode is written as "flag:<flag>", e.g.
+// "flag:--prompt"; a file mode may name the flag that takes the file path,
+// e.g. "file:--message-file". Agents that can't start an interactive session
+// with a prompt use none: the prompt is printed before the agent starts.
+const (
+	PromptArg   = "arg"
+	PromptFlag  = "flag"
+	PromptStdin = "stdin"
+	PromptFile  = "file"
+	PromptNone  = "none"
+)
 
 // Agent describes how to invoke a coding agent CLI.
 type Agent struct {
 	Name    string
 	Command string
 	Args    []string
+	// PromptMode controls how the prompt is delivered: arg (default),
+	// flag:<flag>, stdin, file, file:<flag> or none.
+	PromptMode string
+	// HeadlessArgs and HeadlessPromptMode replace Args and PromptMode for
+	// non-interactive runs. Empty values fall back to the interactive ones.
+	HeadlessArgs       []string
+	HeadlessPromptMode string
+	// Env holds extra environment variables for the agent process.
+	Env map[string]string
+	// WorkDir is a subdirectory of the context to run the agent in.
+	WorkDir string
+	// VersionArgs print the agent's version (default: --version).
+	VersionArgs []string
+	// InstructionsFile is the context file the agent reads project
+	// instructions from (default: AGENTS.md).
+	InstructionsFile string
+}
+
+// InstructionsFileName returns the agent's instructions file name.
+func (a *Agent) InstructionsFileName() string {
+	if a.InstructionsFile != "" {
+		return a.InstructionsFile
+	}
+	return "AGENTS.md"
 }
 
 // BuildCommand returns a full shell command string for spawning this agent with a prompt.
 func (a *Agent) BuildCommand(prompt string) string {
+	return a.buildShell(prompt, false)
+}
+
+// BuildHeadlessCommand is like BuildCommand but uses the headless variant.
+func (a *Agent) BuildHeadlessCommand(prompt string) string {
+	return a.buildShell(prompt, true)
+}
+
+func (a *Agent) buildShell(prompt string, headless bool) string {
+	args, mode := a.variant(headless)
+	kind, flag, _ := parsePromptMode(mode)
+
 	parts := []string{a.Command}
-	parts = append(parts, a.Args...)
+	for _, arg := range args {
+		parts = append(parts, shellQuoteArg(arg))
+	}
+	if prompt != "" {
+		switch kind {
+		case PromptArg:
+			parts = append(parts, shellEscape(prompt))
+		case PromptFlag:
+			parts = append(parts, flag, shellEscape(prompt))
+		case PromptFile:
+			if flag != "" {
+				parts = append(parts, flag)
+			}
+			parts = append(parts, `"$WIZ_PROMPT_FILE"`)
+		}
+	}
+	line := strings.Join(parts, " ")
+
+	if env := a.Environ(); len(env) > 0 {
+		quoted := make([]string, len(env))
+		for i, kv := range env {
+			k, v, _ := strings.Cut(kv, "=")
+			quoted[i] = k + "=" + shellEscape(v)
+		}
+		line = "env " + strings.Join(quoted, " ") + " " + line
+	}
 	if prompt != "" {
-		parts = append(parts, shellEscape(prompt))
+		switch kind {
+		case PromptStdin:
+			line = "printf '%s' " + shellEscape(prompt) + " | " + line
+		case PromptNone:
+			line = "printf '%s\\n\\n' " + shellEscape(prompt) + " && " + line
+		case PromptFile:
+			// The subshell removes the file once the agent exits, without
+			// touching the traps of the shell the line is typed into.
+			line = `(WIZ_PROMPT_FILE="$(mktemp)" && trap 'rm -f "$WIZ_PROMPT_FILE"' EXIT && printf '%s' ` + shellEscape(prompt) +
+				` > "$WIZ_PROMPT_FILE" && ` + line + `)`
+		}
+	}
+	if a.WorkDir != "" {
+		line = "cd " + shellEscape(a.WorkDir) + " && " + line
 	}
-	return strings.Join(parts, " ")
+	return line
 }
 
 // BuildExecArgs returns the executable and arguments for exec.Command (no shell).
+// Prompts delivered via stdin or file are not included; use Cmd for those.
 func (a *Agent) BuildExecArgs(prompt string) (string, []string) {
-	args := make([]string, len(a.Args))
-	copy(args, a.Args)
+	return a.buildExec(prompt, "", false)
+}
+
+// BuildHeadlessExecArgs is like BuildExecArgs but uses the headless variant.
+func (a *Agent) BuildHeadlessExecArgs(prompt string) (string, []string) {
+	return a.buildExec(prompt, "", true)
+}
+
+func (a *Agent) buildExec(prompt, promptFile string, headless bool) (string, []string) {
+	variantArgs, mode := a.variant(headless)
+	kind, flag, _ := parsePromptMode(mode)
+
+	args := make([]string, len(variantArgs))
+	copy(args, variantArgs)
 	if prompt != "" {
-		args = append(args, prompt)
+		switch kind {
+		case PromptArg:
+			args = append(args, prompt)
+		case PromptFlag:
+			args = append(args, flag, prompt)
+		case PromptFile:
+			if promptFile != "" {
+				if flag != "" {
+					args = append(args, flag)
+				}
+				args = append(args, promptFile)
+			}
+		}
 	}
 	return a.Command, args
 }
 
+// Cmd returns an exec.Cmd that runs the agent inside the context at root,
+// delivering the prompt according to the prompt mode. The returned cleanup
+// func removes any temporary prompt file and must be called once the command
+// has finished.
+func (a *Agent) Cmd(ctx context.Context, root, prompt string, headless bool) (*exec.Cmd, func(), error) {
+	_, mode := a.variant(headless)
+	kind, _, err := parsePromptMode(mode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {}
+	var promptFile string
+	if kind == PromptFile && prompt != "" {
+		f, err := os.CreateTemp("", "wiz-prompt-*.md")
+		if err != nil {
+			return nil, nil, fmt.Errorf("write prompt file: %w", err)
+		}
+		promptFile = f.Name()
+		cleanup = func() { os.Remove(promptFile) }
+		if _, err := f.WriteString(prompt); err != nil {
+			f.Close()
+			cleanup()
+			return nil, nil, fmt.Errorf("write prompt file: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("write prompt file: %w", err)
+		}
+	}
+
+	bin, args := a.buildExec(prompt, promptFile, headless)
+	c := exec.CommandContext(ctx, bin, args...)
+	c.Dir = root
+	if a.WorkDir != "" {
+		c.Dir = filepath.Join(root, a.WorkDir)
+	}
+	c.Env = append(os.Environ(), a.Environ()...)
+	if kind == PromptStdin && prompt != "" {
+		c.Stdin = strings.NewReader(prompt)
+	}
+	return c, cleanup, nil
+}
+
+// TakesPrompt reports whether the interactive or headless variant passes
+// the prompt to the agent. If not, callers should show it to the user.
+func (a *Agent) TakesPrompt(headless bool) bool {
+	_, mode := a.variant(headless)
+	kind, _, _ := parsePromptMode(mode)
+	return kind != PromptNone
+}
+
+// Environ returns the agent's extra environment as sorted KEY=VALUE pairs.
+func (a *Agent) Environ() []string {
+	env := make([]string, 0, len(a.Env))
+	for k, v := range a.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Version runs the agent's version command and returns the first line of output.
+func (a *Agent) Version(ctx context.Context) (string, error) {
+	args := a.VersionArgs
+	if len(args) == 0 {
+		args = []string{"--version"}
+	}
+	out, err := exec.CommandContext(ctx, a.Command, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("agent %q: %s %s: %w", a.Name, a.Command, strings.Join(args, " "), err)
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// variant returns the args and prompt mode for an interactive or headless run.
+func (a *Agent) variant(headless bool) ([]string, string) {
+	args, mode := a.Args, a.PromptMode
+	if headless {
+		if a.HeadlessArgs != nil {
+			args = a.HeadlessArgs
+		}
+		if a.HeadlessPromptMode != "" {
+			mode = a.HeadlessPromptMode
+		}
+	}
+	return args, mode
+}
+
+// parsePromptMode splits a prompt mode into its kind and optional flag.
+func parsePromptMode(mode string) (kind, flag string, err error) {
+	if mode == "" {
+		return PromptArg, "", nil
+	}
+	kind, flag, _ = strings.Cut(mode, ":")
+	switch kind {
+	case PromptArg, PromptStdin, PromptNone:
+		if flag != "" {
+			return "", "", fmt.Errorf("prompt mode %q does not take a flag", kind)
+		}
+	case PromptFlag:
+		if !strings.HasPrefix(flag, "-") {
+			return "", "", fmt.Errorf("prompt mode %q: expected flag:<flag>, e.g. flag:--prompt", mode)
+		}
+	case PromptFile:
+		if flag != "" && !strings.HasPrefix(flag, "-") {
+			return "", "", fmt.Errorf("prompt mode %q: expected file or file:<flag>", mode)
+		}
+	default:
+		return "", "", fmt.Errorf("unknown prompt mode %q (want arg, flag:<flag>, stdin, file or none)", mode)
+	}
+	return kind, flag, nil
+}
+
+// shellQuoteArg quotes an argument only when it contains shell metacharacters.
+func shellQuoteArg(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=./:,@+%", r))
+	}) < 0 {
+		return s
+	}
+	return shellEscape(s)
+}
+
 // shellEscape wraps a string in single quotes, escaping internal single quotes.
 func shellEscape(s string) string {
 	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
@@ -1,8 +1,16 @@
 package agent
 
 import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/buck3000/wiz/internal/config"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/testutil"
 )
 
 func TestBuildCommand(t *testing.T) {
@@ -96,3 +104,228 @@ func TestListReturnsKnownAgents(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildCommandPromptModes(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"", "agent 'hi'"},
+		{"arg", "agent 'hi'"},
+		{"flag:--prompt", "agent --prompt 'hi'"},
+		{"stdin", "printf '%s' 'hi' | agent"},
+		{"none", `printf '%s\n\n' 'hi' && agent`},
+		{"file", `(WIZ_PROMPT_FILE="$(mktemp)" && trap 'rm -f "$WIZ_PROMPT_FILE"' EXIT && printf '%s' 'hi' > "$WIZ_PROMPT_FILE" && agent "$WIZ_PROMPT_FILE")`},
+		{"file:--message-file", `(WIZ_PROMPT_FILE="$(mktemp)" && trap 'rm -f "$WIZ_PROMPT_FILE"' EXIT && printf '%s' 'hi' > "$WIZ_PROMPT_FILE" && agent --message-file "$WIZ_PROMPT_FILE")`},
+	}
+	for _, tt := range tests {
+		a := &Agent{Name: "a", Command: "agent", PromptMode: tt.mode}
+		if got := a.BuildCommand("hi"); got != tt.want {
+			t.Errorf("mode %q: BuildCommand = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestBuildCommandRemovesPromptFile(t *testing.T) {
+	// The "agent" prints the prompt file's path.
+	a := &Agent{Name: "a", Command: `printf '%s\n'`, PromptMode: "file"}
+	out, err := exec.Command("sh", "-c", a.BuildCommand("hi")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	path := strings.TrimSpace(string(out))
+	if path == "" {
+		t.Fatal("no prompt file passed")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("prompt file %s left behind", path)
+	}
+}
+
+func TestBuildCommandEnvAndWorkDir(t *testing.T) {
+	a := &Agent{
+		Name:    "a",
+		Command: "agent",
+		Env:     map[string]string{"B": "two words", "A": "1"},
+		WorkDir: "web",
+	}
+	got := a.BuildCommand("go")
+	want := "cd 'web' && env A='1' B='two words' agent 'go'"
+	if got != want {
+		t.Errorf("BuildCommand = %q, want %q", got, want)
+	}
+}
+
+func TestHeadlessVariant(t *testing.T) {
+	a := &Agent{
+		Name:               "a",
+		Command:            "agent",
+		Args:               []string{"--tui"},
+		PromptMode:         "flag:--prompt-interactive",
+		HeadlessArgs:       []string{"exec"},
+		HeadlessPromptMode: "arg",
+	}
+	bin, args := a.BuildHeadlessExecArgs("do it")
+	if bin != "agent" || strings.Join(args, " ") != "exec do it" {
+		t.Errorf("headless = %s %v", bin, args)
+	}
+	_, args = a.BuildExecArgs("do it")
+	if strings.Join(args, " ") != "--tui --prompt-interactive do it" {
+		t.Errorf("interactive = %v", args)
+	}
+	if got := a.BuildHeadlessCommand("x"); got != "agent exec 'x'" {
+		t.Errorf("BuildHeadlessCommand = %q", got)
+	}
+}
+
+func TestPromptNone(t *testing.T) {
+	a, _ := Builtin("aider")
+	if a.TakesPrompt(false) || !a.TakesPrompt(true) {
+		t.Errorf("aider TakesPrompt = %v interactive, %v headless", a.TakesPrompt(false), a.TakesPrompt(true))
+	}
+	if _, args := a.BuildExecArgs("fix it"); len(args) != 0 {
+		t.Errorf("interactive args = %v", args)
+	}
+	c, cleanup, err := a.Cmd(context.Background(), "/repo", "fix it", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if len(c.Args) != 1 || c.Stdin != nil {
+		t.Errorf("interactive Cmd = %v, stdin %v", c.Args, c.Stdin)
+	}
+}
+
+func TestCmdStdinAndFile(t *testing.T) {
+	a := &Agent{Name: "a", Command: "agent", PromptMode: "stdin", WorkDir: "sub", Env: map[string]string{"K": "v"}}
+	c, cleanup, err := a.Cmd(context.Background(), "/repo", "hello", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if c.Stdin == nil {
+		t.Error("stdin mode: Stdin not set")
+	}
+	if c.Dir != filepath.Join("/repo", "sub") {
+		t.Errorf("Dir = %q", c.Dir)
+	}
+	if c.Env[len(c.Env)-1] != "K=v" {
+		t.Errorf("Env missing K=v: %v", c.Env[len(c.Env)-1])
+	}
+
+	a = &Agent{Name: "a", Command: "agent", PromptMode: "file:--message-file"}
+	c, cleanup, err = a.Cmd(context.Background(), "/repo", "hello", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Args) != 3 || c.Args[1] != "--message-file" {
+		t.Fatalf("args = %v", c.Args)
+	}
+	data, err := os.ReadFile(c.Args[2])
+	if err != nil || string(data) != "hello" {
+		t.Errorf("prompt file = %q, %v", data, err)
+	}
+	cleanup()
+	if _, err := os.Stat(c.Args[2]); !os.IsNotExist(err) {
+		t.Error("cleanup did not remove prompt file")
+	}
+}
+
+func TestValidateDefinition(t *testing.T) {
+	bad := []*Agent{
+		{Name: "a", Command: ""},
+		{Name: "a", Command: "sh", PromptMode: "carrier-pigeon"},
+		{Name: "a", Command: "sh", PromptMode: "flag:prompt"},
+		{Name: "a", Command: "sh", HeadlessPromptMode: "stdin:--x"},
+		{Name: "a", Command: "sh", WorkDir: "../outside"},
+		{Name: "a", Command: "sh", WorkDir: "/abs"},
+		{Name: "a", Command: "sh", Env: map[string]string{"A=B": "c"}},
+	}
+	for _, a := range bad {
+		if err := a.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", a)
+		}
+	}
+	ok := &Agent{Name: "a", Command: "sh", PromptMode: "flag:--prompt", WorkDir: "sub/dir", Env: map[string]string{"A": "1"}}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
+
+func TestAllMergesCustomAgents(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := config.WizDir(repo)
+	os.MkdirAll(dir, 0o755)
+	cfg := `{"agents": {"claude": {"command": "my-claude"}, "zz": {"command": "zz-agent", "prompt_mode": "stdin"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := All(repo)
+	byName := make(map[string]Info)
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
+	if c := byName["claude"]; !c.Custom || c.Command != "my-claude" {
+		t.Errorf("custom claude should override builtin: %+v", c)
+	}
+	if z := byName["zz"]; !z.Custom || z.PromptMode != "stdin" {
+		t.Errorf("custom zz = %+v", z)
+	}
+	if g := byName["gemini"]; g.Custom {
+		t.Errorf("gemini should be builtin: %+v", g)
+	}
+
+	a, err := Lookup(repo, "zz")
+	if err != nil || a.Command != "zz-agent" {
+		t.Errorf("Lookup(zz) = %+v, %v", a, err)
+	}
+	if _, err := Lookup(repo, "nope"); err == nil {
+		t.Error("Lookup(nope) should fail")
+	}
+}
+
+func TestBuiltinsAreValidDefinitions(t *testing.T) {
+	for _, name := range List() {
+		a, ok := Builtin(name)
+		if !ok {
+			t.Fatalf("Builtin(%q) missing", name)
+		}
+		if a.Name != name {
+			t.Errorf("builtin %q has Name %q", name, a.Name)
+		}
+		if _, _, err := parsePromptMode(a.PromptMode); err != nil {
+			t.Errorf("builtin %q: %v", name, err)
+		}
+		if _, _, err := parsePromptMode(a.HeadlessPromptMode); err != nil {
+			t.Errorf("builtin %q headless: %v", name, err)
+		}
+	}
+	for _, want := range []string{"aider", "opencode", "goose", "cursor-agent", "amp"} {
+		if _, ok := Builtin(want); !ok {
+			t.Errorf("missing builtin %q", want)
+		}
+	}
+}
+
+func TestUnknownAgentListsRegistry(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, _ := gitx.Discover(tr.Dir)
+	dir := config.WizDir(repo)
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"agents": {"house-bot": {"command": "hb"}}}`), 0o644)
+
+	_, err := Resolve(repo, "nope")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"aider", "claude", "house-bot", "opencode"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
@@ -3,43 +3,175 @@ package agent
 import (
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/buck3000/wiz/internal/config"
 	"github.com/buck3000/wiz/internal/gitx"
 )
 
 var builtins = map[string]Agent{
-	"claude": {Name: "claude", Command: "claude", Args: nil},
-	"gemini": {Name: "gemini", Command: "gemini", Args: nil},
-	"codex":  {Name: "codex", Command: "codex", Args: nil},
+	"claude": {
+		Name:             "claude",
+		Command:          "claude",
+		HeadlessArgs:     []string{"-p"},
+		InstructionsFile: "CLAUDE.local.md",
+	},
+	"gemini": {
+		Name:               "gemini",
+		Command:            "gemini",
+		PromptMode:         "flag:--prompt-interactive",
+		HeadlessPromptMode: "flag:--prompt",
+		InstructionsFile:   "GEMINI.md",
+	},
+	"codex": {
+		Name:         "codex",
+		Command:      "codex",
+		HeadlessArgs: []string{"exec"},
+	},
+	// aider has no interactive initial prompt; with a message it runs the
+	// request and exits.
+	"aider": {
+		Name:               "aider",
+		Command:            "aider",
+		PromptMode:         "none",
+		HeadlessArgs:       []string{"--yes-always", "--no-pretty"},
+		HeadlessPromptMode: "file:--message-file",
+	},
+	"opencode": {
+		Name:               "opencode",
+		Command:            "opencode",
+		PromptMode:         "flag:--prompt",
+		HeadlessArgs:       []string{"run"},
+		HeadlessPromptMode: "arg",
+	},
+	"goose": {
+		Name:         "goose",
+		Command:      "goose",
+		Args:         []string{"run", "--interactive"},
+		PromptMode:   "flag:--text",
+		HeadlessArgs: []string{"run", "--no-session"},
+	},
+	"cursor-agent": {
+		Name:         "cursor-agent",
+		Command:      "cursor-agent",
+		HeadlessArgs: []string{"--print", "--output-format", "text"},
+	},
+	// amp executes a piped prompt and exits; -x takes it as an argument.
+	"amp": {
+		Name:               "amp",
+		Command:            "amp",
+		PromptMode:         "none",
+		HeadlessArgs:       []string{"-x"},
+		HeadlessPromptMode: "arg",
+	},
 }
 
-// Resolve looks up an agent by name: config custom agents first, then builtins.
-func Resolve(repo *gitx.Repo, name string) (*Agent, error) {
+// Info describes a known agent and where it was defined.
+type Info struct {
+	Agent
+	Custom bool
+}
+
+// Lookup returns the agent definition for name without checking that it is
+// installed: config custom agents first, then builtins.
+func Lookup(repo *gitx.Repo, name string) (*Agent, error) {
 	cfg := config.Load(repo)
 	if custom, ok := cfg.Agents[name]; ok {
-		a := &Agent{
-			Name:    name,
-			Command: custom.Command,
-			Args:    custom.Args,
-		}
-		if err := a.Validate(); err != nil {
-			return nil, err
-		}
-		return a, nil
+		return fromConfig(name, custom), nil
 	}
 	if a, ok := builtins[name]; ok {
-		if err := a.Validate(); err != nil {
-			return nil, err
-		}
 		return &a, nil
 	}
-	return nil, fmt.Errorf("unknown agent %q; known agents: claude, gemini, codex", name)
+	return nil, fmt.Errorf("unknown agent %q; known agents: %s", name, strings.Join(names(cfg), ", "))
+}
+
+// names returns builtin and custom agent names, sorted and deduplicated.
+func names(cfg config.Config) []string {
+	seen := make(map[string]bool, len(builtins)+len(cfg.Agents))
+	for name := range builtins {
+		seen[name] = true
+	}
+	for name := range cfg.Agents {
+		seen[name] = true
+	}
+	out := make([]string, 0, len(seen))
+	for name := range seen {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Resolve looks up an agent by name and validates it.
+func Resolve(repo *gitx.Repo, name string) (*Agent, error) {
+	a, err := Lookup(repo, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// All returns builtins merged with the repo's custom agents, sorted by name.
+// A custom agent with a builtin's name replaces it.
+func All(repo *gitx.Repo) []Info {
+	cfg := config.Load(repo)
+	infos := make([]Info, 0, len(builtins)+len(cfg.Agents))
+	for name, a := range builtins {
+		if _, ok := cfg.Agents[name]; !ok {
+			infos = append(infos, Info{Agent: a})
+		}
+	}
+	for name, c := range cfg.Agents {
+		infos = append(infos, Info{Agent: *fromConfig(name, c), Custom: true})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
 }
 
-// Validate checks that the agent's command binary exists in PATH.
+// fromConfig builds an agent from a custom agent definition.
+func fromConfig(name string, c config.AgentConfig) *Agent {
+	return &Agent{
+		Name:               name,
+		Command:            c.Command,
+		Args:               c.Args,
+		PromptMode:         c.PromptMode,
+		HeadlessArgs:       c.HeadlessArgs,
+		HeadlessPromptMode: c.HeadlessPromptMode,
+		Env:                c.Env,
+		WorkDir:            c.WorkDir,
+		VersionArgs:        c.VersionArgs,
+		InstructionsFile:   c.InstructionsFile,
+	}
+}
+
+// Validate checks that the agent definition is well-formed and that its
+// command binary exists in PATH.
 func (a *Agent) Validate() error {
+	if a.Command == "" {
+		return fmt.Errorf("agent %q: command is required", a.Name)
+	}
+	if _, _, err := parsePromptMode(a.PromptMode); err != nil {
+		return fmt.Errorf("agent %q: %w", a.Name, err)
+	}
+	if _, _, err := parsePromptMode(a.HeadlessPromptMode); err != nil {
+		return fmt.Errorf("agent %q: headless: %w", a.Name, err)
+	}
+	if a.WorkDir != "" {
+		clean := filepath.Clean(a.WorkDir)
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("agent %q: workdir %q must be a subdirectory of the context", a.Name, a.WorkDir)
+		}
+	}
+	for k := range a.Env {
+		if k == "" || strings.ContainsAny(k, "= ") {
+			return fmt.Errorf("agent %q: invalid env var name %q", a.Name, k)
+		}
+	}
 	_, err := exec.LookPath(a.Command)
 	if err != nil {
 		return fmt.Errorf("agent %q: command %q not found in PATH; install it first", a.Name, a.Command)
@@ -47,6 +179,15 @@ func (a *Agent) Validate() error {
 	return nil
 }
 
+// Builtin returns the built-in agent with the given name.
+func Builtin(name string) (*Agent, bool) {
+	a, ok := builtins[name]
+	if !ok {
+		return nil, false
+	}
+	return &a, true
+}
+
 // List returns all built-in agent names sorted alphabetically.
 func List() []string {
 	names := make([]string, 0, len(builtins))
@@ -0,0 +1,224 @@
+// Package bundle packs a context into a portable .wizbundle file: a gzipped
+// tar of the context's metadata, a git bundle of its branch and a patch of
+// its uncommitted changes.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/buck3000/wiz/internal/changes"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+)
+
+// Ext is the file extension of bundles.
+const Ext = ".wizbundle"
+
+// Version is the bundle format version written by Write.
+const Version = 1
+
+// Entries of the tar archive.
+const (
+	manifestFile = "manifest.json"
+	contextFile  = "context.json"
+	gitFile      = "branch.bundle"
+	patchFile    = "changes.patch"
+)
+
+// Manifest describes a bundle.
+type Manifest struct {
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	Branch     string    `json:"branch"`
+	Head       string    `json:"head"`            // the branch's commit
+	Files      []string  `json:"files,omitempty"` // files with uncommitted changes
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// Bundle is an exported context.
+type Bundle struct {
+	Manifest Manifest
+	Context  wizctx.Context
+	Git      []byte // git bundle of refs/heads/<Manifest.Branch>
+	Patch    []byte // uncommitted changes relative to Head, untracked files included
+}
+
+// Export bundles c, whose working directory is dir. Machine-specific state
+// (path, ports, archive state and former names) is left out of the context.
+func Export(ctx context.Context, dir string, c wizctx.Context) (*Bundle, error) {
+	head, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("context %q has no commits: %w", c.Name, err)
+	}
+	if branch, _ := git(ctx, dir, "branch", "--show-current"); branch != c.Branch {
+		return nil, fmt.Errorf("context %q is not on its branch %s", c.Name, c.Branch)
+	}
+
+	tmp, err := os.MkdirTemp("", "wiz-export-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	file := filepath.Join(tmp, gitFile)
+	if _, err := git(ctx, dir, "bundle", "create", "--quiet", file, "refs/heads/"+c.Branch); err != nil {
+		return nil, fmt.Errorf("bundle branch %s: %w", c.Branch, err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p, err := changes.Collect(ctx, dir, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("collect uncommitted changes: %w", err)
+	}
+
+	c.Path, c.Ports, c.Aliases = "", nil, nil
+	c.Archived, c.ArchivedAt, c.ArchiveSnapshot = false, time.Time{}, 0
+	return &Bundle{
+		Manifest: Manifest{
+			Version:    Version,
+			Name:       c.Name,
+			Branch:     c.Branch,
+			Head:       head,
+			Files:      p.Files,
+			ExportedAt: time.Now(),
+		},
+		Context: c,
+		Git:     data,
+		Patch:   p.Data,
+	}, nil
+}
+
+// FetchBranch creates branch in repo from the bundled branch. It fails if
+// repo lacks commits the bundle builds on.
+func (b *Bundle) FetchBranch(ctx context.Context, repo *gitx.Repo, branch string) error {
+	tmp, err := os.MkdirTemp("", "wiz-import-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	file := filepath.Join(tmp, gitFile)
+	if err := os.WriteFile(file, b.Git, 0o644); err != nil {
+		return err
+	}
+	if _, err := repo.Run(ctx, "bundle", "verify", "--quiet", file); err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+	if _, err := repo.Run(ctx, "fetch", "--quiet", "--no-tags", file, "refs/heads/"+b.Manifest.Branch+":refs/heads/"+branch); err != nil {
+		return fmt.Errorf("fetch branch %s: %w", b.Manifest.Branch, err)
+	}
+	return nil
+}
+
+// ApplyChanges applies the bundle's uncommitted changes to the working
+// tree at dir, which must be checked out at the bundled commit.
+func (b *Bundle) ApplyChanges(ctx context.Context, dir string) error {
+	if len(b.Patch) == 0 {
+		return nil
+	}
+	return changes.Apply(ctx, dir, &changes.Patch{Data: b.Patch})
+}
+
+// Write writes b to w as a gzipped tar.
+func Write(w io.Writer, b *Bundle) error {
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	c, err := json.MarshalIndent(b.Context, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{manifestFile, manifest},
+		{contextFile, c},
+		{gitFile, b.Git},
+		{patchFile, b.Patch},
+	}
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:    e.name,
+			Mode:    0o644,
+			Size:    int64(len(e.data)),
+			ModTime: b.Manifest.ExportedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read reads a bundle written by Write.
+func Read(r io.Reader) (*Bundle, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a wiz bundle: %w", err)
+	}
+	files := make(map[string][]byte)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read bundle: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("read bundle: %w", err)
+		}
+		files[hdr.Name] = data
+	}
+
+	b := &Bundle{Git: files[gitFile], Patch: files[patchFile]}
+	if err := json.Unmarshal(files[manifestFile], &b.Manifest); err != nil {
+		return nil, fmt.Errorf("read bundle manifest: %w", err)
+	}
+	if b.Manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.Manifest.Version)
+	}
+	if err := json.Unmarshal(files[contextFile], &b.Context); err != nil {
+		return nil, fmt.Errorf("read bundle context: %w", err)
+	}
+	if len(b.Git) == 0 {
+		return nil, fmt.Errorf("bundle has no %s", gitFile)
+	}
+	return b, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
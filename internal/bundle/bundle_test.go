@@ -0,0 +1,91 @@
+package bundle_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/bundle"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/testutil"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	tr := testutil.NewTestRepo(t)
+	// The importing repository has the initial commit but not the branch.
+	dst := filepath.Join(t.TempDir(), "dst")
+	git(t, tr.Dir, "clone", "--quiet", tr.Dir, dst)
+	tr.CreateBranch("feat")
+	tr.Checkout("feat")
+	tr.AddFile("a.txt", "a\n")
+	tr.Commit("add a")
+	tr.AddFile("a.txt", "a changed\n")
+	tr.AddFile("new.txt", "new\n")
+	os.Remove(filepath.Join(tr.Dir, "README.md"))
+	status := git(t, tr.Dir, "status", "--porcelain")
+
+	c := wizctx.Context{Name: "feat", Branch: "feat", Path: tr.Dir, Task: "Do it", Tags: []string{"x"}, Ports: []int{4000}}
+	b, err := bundle.Export(ctx, tr.Dir, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(b.Manifest.Files, []string{"README.md", "a.txt", "new.txt"}) {
+		t.Errorf("Files = %v", b.Manifest.Files)
+	}
+	if b.Context.Path != "" || b.Context.Ports != nil {
+		t.Errorf("machine-specific state exported: %+v", b.Context)
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := bundle.Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Manifest.Head != tr.Head() || got.Context.Task != "Do it" || !slices.Equal(got.Context.Tags, []string{"x"}) {
+		t.Errorf("read back %+v", got.Manifest)
+	}
+
+	repo, err := gitx.Discover(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.FetchBranch(ctx, repo, "imported"); err != nil {
+		t.Fatal(err)
+	}
+	wt := filepath.Join(t.TempDir(), "wt")
+	git(t, dst, "worktree", "add", "--quiet", wt, "imported")
+	if err := got.ApplyChanges(ctx, wt); err != nil {
+		t.Fatal(err)
+	}
+	if h := git(t, wt, "rev-parse", "HEAD"); h != tr.Head() {
+		t.Errorf("imported HEAD = %s, want %s", h, tr.Head())
+	}
+	if s := git(t, wt, "status", "--porcelain"); s != status {
+		t.Errorf("imported status = %q, want %q", s, status)
+	}
+}
+
+func TestReadRejectsOtherFiles(t *testing.T) {
+	if _, err := bundle.Read(strings.NewReader("not a bundle")); err == nil {
+		t.Error("Read should fail")
+	}
+}
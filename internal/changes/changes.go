@@ -0,0 +1,117 @@
+// Package changes moves uncommitted work from one working tree to another as
+// a binary patch.
+package changes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/buck3000/wiz/internal/snapshot"
+)
+
+// Patch is a set of working tree changes relative to HEAD.
+type Patch struct {
+	Files []string
+	Data  []byte
+}
+
+// Collect returns the staged and unstaged changes at dir under paths (all
+// if empty), and untracked files too if untracked is set. Ignored files are
+// left out.
+func Collect(ctx context.Context, dir string, paths []string, untracked bool) (*Patch, error) {
+	work, head, err := snapshot.Capture(ctx, dir, "wiz move-changes")
+	if err != nil {
+		return nil, err
+	}
+	if head == "" {
+		return nil, fmt.Errorf("%s has no commits", dir)
+	}
+
+	out, err := git(ctx, dir, nil, append([]string{"diff", "-z", "--no-renames", "--name-only", head, work, "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	var others []string
+	if !untracked {
+		out, err := git(ctx, dir, nil, "ls-files", "-z", "--others", "--exclude-standard")
+		if err != nil {
+			return nil, err
+		}
+		others = splitNUL(out)
+	}
+	p := &Patch{}
+	for _, f := range splitNUL(out) {
+		if !slices.Contains(others, f) {
+			p.Files = append(p.Files, f)
+		}
+	}
+	if len(p.Files) == 0 {
+		return p, nil
+	}
+
+	args := append([]string{"--literal-pathspecs", "diff", "--no-renames", "--binary", head, work, "--"}, p.Files...)
+	if p.Data, err = git(ctx, dir, nil, args...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Check reports whether p applies cleanly to the working tree at dir,
+// without changing anything.
+func Check(ctx context.Context, dir string, p *Patch) error {
+	if _, err := git(ctx, dir, p.Data, "apply", "--check", "--binary"); err != nil {
+		return fmt.Errorf("changes do not apply: %w", err)
+	}
+	return nil
+}
+
+// Apply applies p to the working tree at dir. Changed files are left
+// unstaged and new files untracked. Nothing is changed if p doesn't apply.
+func Apply(ctx context.Context, dir string, p *Patch) error {
+	if _, err := git(ctx, dir, p.Data, "apply", "--binary"); err != nil {
+		return fmt.Errorf("changes do not apply: %w", err)
+	}
+	return nil
+}
+
+// Discard removes the changes in p, collected from dir, from dir: the index
+// entries of its files are reset to HEAD and the working tree reverted.
+func Discard(ctx context.Context, dir string, p *Patch) error {
+	if _, err := git(ctx, dir, nil, append([]string{"--literal-pathspecs", "reset", "--quiet", "--"}, p.Files...)...); err != nil {
+		return err
+	}
+	if _, err := git(ctx, dir, p.Data, "apply", "--reverse", "--binary"); err != nil {
+		return fmt.Errorf("clean up: %w", err)
+	}
+	return nil
+}
+
+// splitNUL splits the output of a git command run with -z, which leaves
+// paths unquoted.
+func splitNUL(out []byte) []string {
+	var paths []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			paths = append(paths, f)
+		}
+	}
+	return paths
+}
+
+func git(ctx context.Context, dir string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,147 @@
+package changes_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/changes"
+	"github.com/buck3000/wiz/testutil"
+)
+
+func status(t *testing.T, dir string) string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", dir, "status", "--porcelain").CombinedOutput()
+	if err != nil {
+		t.Fatalf("git status: %v\n%s", err, out)
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+// twoTrees returns a repo and a second worktree of it on another branch.
+func twoTrees(t *testing.T) (*testutil.TestRepo, string) {
+	tr := testutil.NewTestRepo(t)
+	tr.AddFile("a.txt", "a\n")
+	tr.AddFile("b.txt", "b\n")
+	tr.Commit("add files")
+	other := filepath.Join(t.TempDir(), "other")
+	if out, err := exec.Command("git", "-C", tr.Dir, "worktree", "add", "-q", "-b", "other", other).CombinedOutput(); err != nil {
+		t.Fatalf("worktree add: %v\n%s", err, out)
+	}
+	return tr, other
+}
+
+func TestMoveChanges(t *testing.T) {
+	tr, other := twoTrees(t)
+	ctx := context.Background()
+	base := status(t, tr.Dir)
+
+	os.WriteFile(filepath.Join(tr.Dir, "a.txt"), []byte("a changed\n"), 0o644)
+	os.WriteFile(filepath.Join(tr.Dir, "staged.txt"), []byte("staged\n"), 0o644)
+	exec.Command("git", "-C", tr.Dir, "add", "staged.txt").Run()
+	os.Remove(filepath.Join(tr.Dir, "b.txt"))
+	os.WriteFile(filepath.Join(tr.Dir, "untracked.txt"), []byte("new\n"), 0o644)
+
+	p, err := changes.Collect(ctx, tr.Dir, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.Files, []string{"a.txt", "b.txt", "staged.txt"}) {
+		t.Fatalf("Files = %v", p.Files)
+	}
+	if err := changes.Check(ctx, other, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := changes.Apply(ctx, other, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := changes.Discard(ctx, tr.Dir, p); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := status(t, other), " M a.txt\n D b.txt\n?? staged.txt"; got != want {
+		t.Errorf("target status = %q, want %q", got, want)
+	}
+	// The untracked file was not asked for and stays behind.
+	if got, want := status(t, tr.Dir), strings.TrimLeft(base+"\n?? untracked.txt", "\n"); got != want {
+		t.Errorf("source status = %q, want %q", got, want)
+	}
+}
+
+func TestMoveChangesPathsAndUntracked(t *testing.T) {
+	tr, other := twoTrees(t)
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(tr.Dir, "a.txt"), []byte("a changed\n"), 0o644)
+	os.WriteFile(filepath.Join(tr.Dir, "b.txt"), []byte("b changed\n"), 0o644)
+	os.WriteFile(filepath.Join(tr.Dir, "new.txt"), []byte("new\n"), 0o644)
+
+	p, err := changes.Collect(ctx, tr.Dir, []string{"b.txt", "new.txt"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.Files, []string{"b.txt", "new.txt"}) {
+		t.Fatalf("Files = %v", p.Files)
+	}
+	if err := changes.Apply(ctx, other, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := changes.Discard(ctx, tr.Dir, p); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := status(t, other), " M b.txt\n?? new.txt"; got != want {
+		t.Errorf("target status = %q, want %q", got, want)
+	}
+	if got := status(t, tr.Dir); !strings.Contains(got, " M a.txt") || strings.Contains(got, "b.txt") || strings.Contains(got, "new.txt") {
+		t.Errorf("source status = %q, want only a.txt changed", got)
+	}
+}
+
+func TestMoveChangesNonASCIIPaths(t *testing.T) {
+	tr, other := twoTrees(t)
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(tr.Dir, "café.txt"), []byte("new\n"), 0o644)
+	os.WriteFile(filepath.Join(tr.Dir, "naïve notes.md"), []byte("new\n"), 0o644)
+
+	p, err := changes.Collect(ctx, tr.Dir, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.Files, []string{"café.txt", "naïve notes.md"}) {
+		t.Fatalf("Files = %q", p.Files)
+	}
+	if err := changes.Apply(ctx, other, p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(other, "naïve notes.md")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMoveChangesConflict(t *testing.T) {
+	tr, other := twoTrees(t)
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(tr.Dir, "a.txt"), []byte("mine\n"), 0o644)
+	os.WriteFile(filepath.Join(other, "a.txt"), []byte("theirs\n"), 0o644)
+
+	p, err := changes.Collect(ctx, tr.Dir, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = changes.Check(ctx, other, p)
+	if err == nil || !strings.Contains(err.Error(), "a.txt") {
+		t.Fatalf("Check = %v, want a conflict in a.txt", err)
+	}
+	if err := changes.Apply(ctx, other, p); err == nil {
+		t.Fatal("Apply should fail")
+	}
+	if data, _ := os.ReadFile(filepath.Join(other, "a.txt")); string(data) != "theirs\n" {
+		t.Errorf("target a.txt = %q after a failed apply", data)
+	}
+}
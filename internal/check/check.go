@@ -0,0 +1,205 @@
+package check
+
+import (
+	gocontext "context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buck3000/wiz/internal/config"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/template"
+)
+
+// DefaultTimeout bounds a check without an explicit timeout.
+const DefaultTimeout = 10 * time.Minute
+
+// Check is a named verification command.
+type Check struct {
+	Name    string
+	Run     string
+	Timeout time.Duration
+}
+
+// Result is the outcome of one check in one context.
+type Result struct {
+	Name     string        `json:"name"`
+	Command  string        `json:"command"`
+	Passed   bool          `json:"passed"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	Log      string        `json:"log,omitempty"`  // path to the combined output
+	Head     string        `json:"head,omitempty"` // commit the check ran against
+	RanAt    time.Time     `json:"ran_at"`
+}
+
+// Job is the set of checks to run in one context.
+type Job struct {
+	Context string
+	Dir     string
+	Checks  []Check
+}
+
+// FromConfig converts configured checks, validating names and timeouts.
+func FromConfig(cfgs []config.CheckConfig) ([]Check, error) {
+	checks := make([]Check, 0, len(cfgs))
+	seen := make(map[string]bool, len(cfgs))
+	for i, c := range cfgs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("check %d: name is required", i)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("check %q is defined twice", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Run == "" {
+			return nil, fmt.Errorf("check %q: run is required", c.Name)
+		}
+		timeout := DefaultTimeout
+		if c.Timeout != "" {
+			d, err := time.ParseDuration(c.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("check %q: invalid timeout %q", c.Name, c.Timeout)
+			}
+			timeout = d
+		}
+		checks = append(checks, Check{Name: c.Name, Run: c.Run, Timeout: timeout})
+	}
+	return checks, nil
+}
+
+// ForTemplate returns the checks for a context created from the named
+// template: the template's checks if it defines any, else the configured ones.
+func ForTemplate(repo *gitx.Repo, templateName string) ([]Check, error) {
+	cfgs := config.Load(repo).Checks
+	if templateName != "" {
+		if t, err := template.NewStore(repo).Get(templateName); err == nil && len(t.Checks) > 0 {
+			cfgs = t.Checks
+		}
+	}
+	return FromConfig(cfgs)
+}
+
+// Run runs a single check through sh in dir, writing its output to a log
+// file in logDir.
+func Run(ctx gocontext.Context, dir, logDir string, c Check) Result {
+	r := Result{Name: c.Name, Command: c.Run, RanAt: time.Now()}
+	if out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output(); err == nil {
+		r.Head = strings.TrimSpace(string(out))
+	}
+
+	var log *os.File
+	if err := os.MkdirAll(logDir, 0o755); err == nil {
+		r.Log = filepath.Join(logDir, logName(c.Name))
+		log, err = os.Create(r.Log)
+		if err != nil {
+			r.Log = ""
+		}
+	}
+
+	runCtx, cancel := gocontext.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, "sh", "-c", c.Run)
+	cmd.Dir = dir
+	if log != nil {
+		defer log.Close()
+		cmd.Stdout = log
+		cmd.Stderr = log
+	}
+	err := cmd.Run()
+	r.Duration = time.Since(r.RanAt).Round(time.Millisecond)
+	r.Passed = err == nil
+	if err != nil {
+		r.ExitCode = -1
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() >= 0 {
+			r.ExitCode = exitErr.ExitCode()
+		}
+		if log != nil {
+			if runCtx.Err() == gocontext.DeadlineExceeded {
+				fmt.Fprintf(log, "\nwiz: check timed out after %s\n", c.Timeout)
+			} else if r.ExitCode < 0 {
+				fmt.Fprintf(log, "\nwiz: %v\n", err)
+			}
+		}
+	}
+	return r
+}
+
+// RunAll runs each job's checks in order, running up to parallel jobs at
+// once. done, if non-nil, is called as each job finishes. Logs are written
+// under logRoot/<context>/.
+func RunAll(ctx gocontext.Context, jobs []Job, logRoot string, parallel int, done func(Job, []Result)) [][]Result {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([][]Result, len(jobs))
+	sem := make(chan struct{}, parallel)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job Job) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			logDir := LogDir(logRoot, job.Context)
+			rs := make([]Result, 0, len(job.Checks))
+			for _, c := range job.Checks {
+				rs = append(rs, Run(ctx, job.Dir, logDir, c))
+			}
+			results[i] = rs
+			if done != nil {
+				mu.Lock()
+				done(job, rs)
+				mu.Unlock()
+			}
+		}(i, job)
+	}
+	wg.Wait()
+	return results
+}
+
+// Passed reports whether every result passed. No results is not a pass.
+func Passed(results []Result) bool {
+	if len(results) == 0 {
+		return false
+	}
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary returns a short summary such as "2/3 passed".
+func Summary(results []Result) string {
+	if len(results) == 0 {
+		return "-"
+	}
+	n := 0
+	for _, r := range results {
+		if r.Passed {
+			n++
+		}
+	}
+	return fmt.Sprintf("%d/%d passed", n, len(results))
+}
+
+// LogDir returns the directory under logRoot holding a context's check logs.
+func LogDir(logRoot, contextName string) string {
+	return filepath.Join(logRoot, logName(contextName))
+}
+
+var unsafeLogChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// logName makes a name safe for use as a file or directory name.
+func logName(name string) string {
+	return unsafeLogChars.ReplaceAllString(name, "_")
+}
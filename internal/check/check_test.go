@@ -0,0 +1,87 @@
+package check
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/buck3000/wiz/internal/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	checks, err := FromConfig([]config.CheckConfig{
+		{Name: "test", Run: "go test ./..."},
+		{Name: "lint", Run: "npm run lint", Timeout: "30s"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checks[0].Timeout != DefaultTimeout || checks[1].Timeout != 30*time.Second {
+		t.Errorf("timeouts = %s, %s", checks[0].Timeout, checks[1].Timeout)
+	}
+
+	for _, bad := range [][]config.CheckConfig{
+		{{Run: "true"}},
+		{{Name: "a"}},
+		{{Name: "a", Run: "true", Timeout: "soon"}},
+		{{Name: "a", Run: "true"}, {Name: "a", Run: "false"}},
+	} {
+		if _, err := FromConfig(bad); err == nil {
+			t.Errorf("FromConfig(%+v): expected error", bad)
+		}
+	}
+}
+
+func TestRunWritesLog(t *testing.T) {
+	dir, logDir := t.TempDir(), t.TempDir()
+
+	r := Run(context.Background(), dir, logDir, Check{Name: "unit/test", Run: "echo hello; exit 3", Timeout: time.Minute})
+	if r.Passed || r.ExitCode != 3 {
+		t.Errorf("result = %+v", r)
+	}
+	data, err := os.ReadFile(r.Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "hello" {
+		t.Errorf("log = %q", data)
+	}
+
+	r = Run(context.Background(), dir, logDir, Check{Name: "slow", Run: "sleep 5", Timeout: 50 * time.Millisecond})
+	if r.Passed {
+		t.Error("timed out check passed")
+	}
+}
+
+func TestRunAllBoundsConcurrency(t *testing.T) {
+	jobs := make([]Job, 4)
+	for i := range jobs {
+		jobs[i] = Job{Context: "ctx", Dir: t.TempDir(), Checks: []Check{{Name: "ok", Run: "sleep 0.2", Timeout: time.Minute}}}
+	}
+	var done int32
+	start := time.Now()
+	results := RunAll(context.Background(), jobs, t.TempDir(), 2, func(Job, []Result) {
+		atomic.AddInt32(&done, 1)
+	})
+	// Four 200ms jobs, two at a time, take at least two rounds.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("RunAll took %s; concurrency not bounded", elapsed)
+	}
+	if done != 4 {
+		t.Errorf("done called %d times", done)
+	}
+	for i, rs := range results {
+		if !Passed(rs) {
+			t.Errorf("job %d: %+v", i, rs)
+		}
+	}
+	if Summary(results[0]) != "1/1 passed" {
+		t.Errorf("Summary = %q", Summary(results[0]))
+	}
+	if Passed(nil) {
+		t.Error("Passed(nil) = true")
+	}
+}
@@ -0,0 +1,102 @@
+package compare
+
+import (
+	gocontext "context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/buck3000/wiz/internal/check"
+)
+
+// FileStat is the number of lines added and deleted in one file.
+type FileStat struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// Attempt summarizes one context's changes against the shared base.
+type Attempt struct {
+	Name    string         `json:"name"`
+	Branch  string         `json:"branch"`
+	Base    string         `json:"base"`
+	Head    string         `json:"head"`
+	Commits []string       `json:"commits"` // "<short-sha> <subject>", newest first
+	Files   []FileStat     `json:"files"`
+	Added   int            `json:"added"`
+	Deleted int            `json:"deleted"`
+	Checks  []check.Result `json:"checks,omitempty"`
+}
+
+// Collect summarizes ref against base using the repository at dir.
+func Collect(ctx gocontext.Context, dir, name, base, ref string) (*Attempt, error) {
+	a := &Attempt{Name: name, Branch: ref, Base: base}
+
+	head, err := git(ctx, dir, "rev-parse", "--short", ref)
+	if err != nil {
+		return nil, err
+	}
+	a.Head = head
+
+	log, err := git(ctx, dir, "log", "--format=%h %s", base+".."+ref)
+	if err != nil {
+		return nil, err
+	}
+	a.Commits = lines(log)
+
+	numstat, err := git(ctx, dir, "diff", "--numstat", base+"..."+ref)
+	if err != nil {
+		return nil, err
+	}
+	a.Files = ParseNumstat(numstat)
+	for _, f := range a.Files {
+		a.Added += f.Added
+		a.Deleted += f.Deleted
+	}
+	return a, nil
+}
+
+// ParseNumstat parses `git diff --numstat` output.
+func ParseNumstat(out string) []FileStat {
+	var files []FileStat
+	for _, line := range lines(out) {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		f := FileStat{Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			f.Binary = true
+		} else {
+			f.Added, _ = strconv.Atoi(parts[0])
+			f.Deleted, _ = strconv.Atoi(parts[1])
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// DiffStat returns `git diff --stat` between two refs' trees.
+func DiffStat(ctx gocontext.Context, dir, a, b string) (string, error) {
+	return git(ctx, dir, "diff", "--stat", a, b)
+}
+
+func git(ctx gocontext.Context, dir string, args ...string) (string, error) {
+	c := exec.CommandContext(ctx, "git", args...)
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,65 @@
+package compare_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/compare"
+	"github.com/buck3000/wiz/testutil"
+)
+
+func TestParseNumstat(t *testing.T) {
+	files := compare.ParseNumstat("3\t1\tmain.go\n-\t-\tlogo.png\n")
+	if len(files) != 2 {
+		t.Fatalf("got %d files", len(files))
+	}
+	if files[0].Path != "main.go" || files[0].Added != 3 || files[0].Deleted != 1 {
+		t.Errorf("files[0] = %+v", files[0])
+	}
+	if !files[1].Binary {
+		t.Errorf("files[1] = %+v, want binary", files[1])
+	}
+}
+
+func TestCollectAndDiffStat(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+	base := tr.CurrentBranch()
+
+	tr.CreateBranch("a")
+	tr.Checkout("a")
+	tr.AddFile("fix.go", "package fix\n\nfunc A() {}\n")
+	tr.Commit("attempt a")
+
+	tr.Checkout(base)
+	tr.CreateBranch("b")
+	tr.Checkout("b")
+	tr.AddFile("fix.go", "package fix\n")
+	tr.Commit("attempt b part 1")
+	tr.AddFile("other.go", "package fix\n")
+	tr.Commit("attempt b part 2")
+
+	a, err := compare.Collect(ctx, tr.Dir, "a", base, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Commits) != 1 || a.Added != 3 || len(a.Files) != 1 {
+		t.Errorf("attempt a = %+v", a)
+	}
+	b, err := compare.Collect(ctx, tr.Dir, "b", base, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Commits) != 2 || len(b.Files) != 2 {
+		t.Errorf("attempt b = %+v", b)
+	}
+
+	stat, err := compare.DiffStat(ctx, tr.Dir, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stat, "fix.go") || !strings.Contains(stat, "other.go") {
+		t.Errorf("DiffStat = %q", stat)
+	}
+}
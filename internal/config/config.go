@@ -11,8 +11,37 @@ import (
 
 // AgentConfig defines a custom agent command.
 type AgentConfig struct {
-	Command string   `json:"command"`
-	Args    []string `json:"args,omitempty"`
+	Command            string            `json:"command"`
+	Args               []string          `json:"args,omitempty"`
+	PromptMode         string            `json:"prompt_mode,omitempty"` // arg, flag:<flag>, stdin, file[:<flag>], none
+	HeadlessArgs       []string          `json:"headless_args,omitempty"`
+	HeadlessPromptMode string            `json:"headless_prompt_mode,omitempty"`
+	Env                map[string]string `json:"env,omitempty"`
+	WorkDir            string            `json:"workdir,omitempty"` // subdirectory of the context
+	VersionArgs        []string          `json:"version_args,omitempty"`
+	InstructionsFile   string            `json:"instructions_file,omitempty"` // default: AGENTS.md
+}
+
+// InstructionsConfig controls the agent instructions file written into new contexts.
+type InstructionsConfig struct {
+	Enabled  bool   `json:"enabled"`
+	File     string `json:"file,omitempty"`     // overrides the agent's instructions file name
+	Template string `json:"template,omitempty"` // Go template path, relative to the repo root
+}
+
+// CheckConfig is a named verification command run by wiz check.
+type CheckConfig struct {
+	Name    string `json:"name"`
+	Run     string `json:"run"`               // shell command, run in the context
+	Timeout string `json:"timeout,omitempty"` // e.g. "10m" (default 10m)
+}
+
+// ForgeConfig overrides forge detection for wiz finish, e.g. for self-hosted
+// instances whose host name doesn't reveal the forge.
+type ForgeConfig struct {
+	Type   string `json:"type,omitempty"`    // github, gitlab, gitea or bitbucket
+	APIURL string `json:"api_url,omitempty"` // e.g. https://git.example.com/api/v1
+	Remote string `json:"remote,omitempty"`  // git remote to push to (default origin)
 }
 
 // Config holds user-configurable wiz settings.
@@ -22,6 +51,16 @@ type Config struct {
 	StatusCacheTTL  time.Duration          `json:"-"`
 	StatusCacheTTLs string                 `json:"status_cache_ttl"` // e.g. "2s"
 	Agents          map[string]AgentConfig `json:"agents,omitempty"`
+	Instructions    InstructionsConfig     `json:"instructions"`
+	PortBase        int                    `json:"port_base,omitempty"`         // first port handed out to contexts
+	PortsPerContext int                    `json:"ports_per_context,omitempty"` // 0 disables port allocation
+	Reviewer        string                 `json:"reviewer,omitempty"`          // agent used by wiz review
+	Checks          []CheckConfig          `json:"checks,omitempty"`
+	SyncMode        string                 `json:"sync_mode,omitempty"` // rebase (default) or merge
+	Forge           ForgeConfig            `json:"forge,omitempty"`
+	// BranchTemplate names new contexts' branches, e.g. "{{.User}}/{{.Name}}"
+	// (default: the context name).
+	BranchTemplate string `json:"branch_template,omitempty"`
 }
 
 // Defaults returns the default configuration.
@@ -31,6 +70,9 @@ func Defaults() Config {
 		PromptEmoji:     "\U0001f9d9", // 🧙
 		StatusCacheTTL:  2 * time.Second,
 		StatusCacheTTLs: "2s",
+		PortBase:        4000,
+		Reviewer:        "claude",
+		SyncMode:        "rebase",
 	}
 }
 
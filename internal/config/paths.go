@@ -37,6 +37,11 @@ func CacheDir(repo *gitx.Repo) string {
 	return filepath.Join(WizDir(repo), "cache")
 }
 
+// ChecksDir returns <wiz-dir>/checks/ — where check logs are written.
+func ChecksDir(repo *gitx.Repo) string {
+	return filepath.Join(WizDir(repo), "checks")
+}
+
 // LicenseFilePath returns ~/.config/wiz/license.json (global, not per-repo).
 func LicenseFilePath() string {
 	home, err := os.UserHomeDir()
@@ -0,0 +1,58 @@
+package conflict
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buck3000/wiz/testutil"
+)
+
+func TestForecasterCache(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+	base := tr.CurrentBranch()
+	tr.AddFile("shared.txt", "line 1\nline 2\n")
+	tr.Commit("add shared file")
+
+	branch := func(name, content string) string {
+		tr.Checkout(base)
+		tr.CreateBranch(name)
+		tr.Checkout(name)
+		tr.AddFile("shared.txt", content)
+		tr.Commit(name)
+		return tr.Head()
+	}
+	a := branch("a", "line 1 from a\nline 2\n")
+	b := branch("b", "line 1 from b\nline 2\n")
+	branches := []Branch{{Name: "a", Tip: a, Base: base}, {Name: "b", Tip: b, Base: base}}
+
+	f := NewForecaster(tr.Dir)
+	if _, err := f.Forecast(ctx, branches); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged branches are answered without running git.
+	dir := f.dir
+	f.dir = t.TempDir()
+	pairs, err := f.Forecast(ctx, branches)
+	if err != nil || len(pairs) != 1 || !pairs[0].Conflicting() {
+		t.Fatalf("cached Forecast = %+v, %v", pairs, err)
+	}
+	f.dir = dir
+
+	// Results for the old tip of a moved branch are dropped.
+	tr.Checkout("b")
+	tr.AddFile("shared.txt", "line 1 from a\nline 2\n")
+	tr.Commit("b agrees with a")
+	branches[1].Tip = tr.Head()
+	pairs, err = f.Forecast(ctx, branches)
+	if err != nil || len(pairs) != 1 || pairs[0].Conflicting() {
+		t.Fatalf("Forecast after move = %+v, %v", pairs, err)
+	}
+	if len(f.changed) != 2 || len(f.merges) != 1 {
+		t.Errorf("cache holds %d diffs and %d merges, want 2 and 1", len(f.changed), len(f.merges))
+	}
+	if _, ok := f.changed[[2]string{base, b}]; ok {
+		t.Error("diff for b's old tip still cached")
+	}
+}
@@ -0,0 +1,170 @@
+package conflict
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Branch is one context's line of work: its tip commit and the base it
+// branched from.
+type Branch struct {
+	Name string
+	Tip  string
+	Base string
+}
+
+// Pair is a forecast for two contexts that change some of the same files.
+type Pair struct {
+	A         string   `json:"a"`
+	B         string   `json:"b"`
+	Overlap   []string `json:"overlap"`             // files changed by both
+	Conflicts []string `json:"conflicts,omitempty"` // files a trial merge conflicts in
+}
+
+// Conflicting reports whether merging the pair would conflict.
+func (p Pair) Conflicting() bool { return len(p.Conflicts) > 0 }
+
+// Forecaster predicts conflicts between branches. Results are cached by
+// commit, so repeated forecasts only redo work for branches that moved, and
+// a forecast for unchanged branches is returned as is. Only results for the
+// latest branches are kept.
+type Forecaster struct {
+	dir string
+
+	mu      sync.Mutex             // serializes forecasts
+	last    []Branch               // branches of the latest forecast
+	pairs   []Pair                 // its result
+	done    bool                   // whether last and pairs are set
+	changed map[[2]string][]string // (base, tip) -> changed files
+	merges  map[[2]string][]string // (tip, tip) -> conflicting files
+}
+
+// NewForecaster returns a forecaster for the repository at dir.
+func NewForecaster(dir string) *Forecaster {
+	return &Forecaster{
+		dir:     dir,
+		changed: make(map[[2]string][]string),
+		merges:  make(map[[2]string][]string),
+	}
+}
+
+// Forecast returns a Pair for every two branches whose changes overlap, with
+// a trial merge of their tips. Pairs that touch disjoint files are omitted.
+func (f *Forecaster) Forecast(ctx gocontext.Context, branches []Branch) ([]Pair, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done && slices.Equal(f.last, branches) {
+		return slices.Clone(f.pairs), nil
+	}
+
+	// Results for branches that moved or went away are dropped.
+	changed := make(map[[2]string][]string, len(branches))
+	merges := make(map[[2]string][]string)
+
+	files := make([]map[string]bool, len(branches))
+	for i, b := range branches {
+		list, err := f.changedFiles(ctx, changed, b.Base, b.Tip)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b.Name, err)
+		}
+		files[i] = make(map[string]bool, len(list))
+		for _, file := range list {
+			files[i][file] = true
+		}
+	}
+
+	var pairs []Pair
+	for i := range branches {
+		for j := i + 1; j < len(branches); j++ {
+			var overlap []string
+			for file := range files[i] {
+				if files[j][file] {
+					overlap = append(overlap, file)
+				}
+			}
+			if len(overlap) == 0 {
+				continue
+			}
+			sort.Strings(overlap)
+			conflicts, err := f.trialMerge(ctx, merges, branches[i].Tip, branches[j].Tip)
+			if err != nil {
+				return nil, fmt.Errorf("%s and %s: %w", branches[i].Name, branches[j].Name, err)
+			}
+			pairs = append(pairs, Pair{A: branches[i].Name, B: branches[j].Name, Overlap: overlap, Conflicts: conflicts})
+		}
+	}
+
+	f.changed, f.merges = changed, merges
+	f.last, f.pairs, f.done = slices.Clone(branches), pairs, true
+	return slices.Clone(pairs), nil
+}
+
+// changedFiles returns the files changed on tip since base, from the cache
+// or git, and records them in keep.
+func (f *Forecaster) changedFiles(ctx gocontext.Context, keep map[[2]string][]string, base, tip string) ([]string, error) {
+	key := [2]string{base, tip}
+	files, ok := f.changed[key]
+	if !ok {
+		out, err := f.git(ctx, "diff", "--name-only", base+"..."+tip)
+		if err != nil {
+			return nil, err
+		}
+		files = splitLines(out)
+	}
+	keep[key] = files
+	return files, nil
+}
+
+// trialMerge merges a and b in memory with git merge-tree and returns the
+// conflicting files, caching them in keep.
+func (f *Forecaster) trialMerge(ctx gocontext.Context, keep map[[2]string][]string, a, b string) ([]string, error) {
+	if b < a {
+		a, b = b, a
+	}
+	key := [2]string{a, b}
+	if conflicts, ok := f.merges[key]; ok {
+		keep[key] = conflicts
+		return conflicts, nil
+	}
+
+	var conflicts []string
+	out, err := f.git(ctx, "merge-tree", "--write-tree", "--name-only", "--no-messages", a, b)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// The first line is the resulting tree; conflicted files follow.
+		lines := splitLines(out)
+		seen := make(map[string]bool)
+		for _, l := range lines[min(1, len(lines)):] {
+			if !seen[l] {
+				seen[l] = true
+				conflicts = append(conflicts, l)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("git merge-tree (requires git 2.38+): %w", err)
+	}
+	keep[key] = conflicts
+	return conflicts, nil
+}
+
+func (f *Forecaster) git(ctx gocontext.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = f.dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,58 @@
+package conflict_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/conflict"
+	"github.com/buck3000/wiz/testutil"
+)
+
+func TestForecast(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	base := tr.CurrentBranch()
+	tr.AddFile("shared.txt", "line 1\nline 2\nline 3\n")
+	tr.Commit("add shared file")
+
+	branch := func(name, file, content string) string {
+		tr.Checkout(base)
+		tr.CreateBranch(name)
+		tr.Checkout(name)
+		tr.AddFile(file, content)
+		tr.Commit(name)
+		return tr.Head()
+	}
+	a := branch("a", "shared.txt", "line 1 from a\nline 2\nline 3\n")
+	b := branch("b", "shared.txt", "line 1 from b\nline 2\nline 3\n")
+	c := branch("c", "shared.txt", "line 1\nline 2\nline 3 from c\n")
+	d := branch("d", "other.txt", "unrelated\n")
+	tr.Checkout(base)
+
+	f := conflict.NewForecaster(tr.Dir)
+	pairs, err := f.Forecast(context.Background(), []conflict.Branch{
+		{Name: "a", Tip: a, Base: base},
+		{Name: "b", Tip: b, Base: base},
+		{Name: "c", Tip: c, Base: base},
+		{Name: "d", Tip: d, Base: base},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]conflict.Pair{}
+	for _, p := range pairs {
+		got[p.A+"-"+p.B] = p
+	}
+	if len(got) != 3 {
+		t.Fatalf("pairs = %+v, want a-b, a-c and b-c", pairs)
+	}
+	if p := got["a-b"]; !p.Conflicting() || p.Conflicts[0] != "shared.txt" {
+		t.Errorf("a-b = %+v, want conflict in shared.txt", p)
+	}
+	if p := got["a-c"]; p.Conflicting() || len(p.Overlap) != 1 {
+		t.Errorf("a-c = %+v, want clean overlap", p)
+	}
+	if _, ok := got["a-d"]; ok {
+		t.Error("disjoint branches should not be paired")
+	}
+}
@@ -0,0 +1,55 @@
+package context
+
+import (
+	gocontext "context"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"text/template"
+
+	"github.com/buck3000/wiz/internal/gitx"
+)
+
+// BranchVars are the fields available to a branch template.
+type BranchVars struct {
+	Name string // context name
+	User string // login name of the current user
+}
+
+// BranchName returns the branch for a new context: explicit if set,
+// otherwise tmpl (e.g. "{{.User}}/{{.Name}}") rendered for the context,
+// or the context name itself without a template. The result must be a
+// valid branch name.
+func BranchName(ctx gocontext.Context, repo *gitx.Repo, explicit, tmpl, name string) (string, error) {
+	branch := explicit
+	if branch == "" && tmpl != "" {
+		t, err := template.New("branch").Option("missingkey=error").Parse(tmpl)
+		if err != nil {
+			return "", fmt.Errorf("branch_template: %w", err)
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, BranchVars{Name: name, User: currentUser()}); err != nil {
+			return "", fmt.Errorf("branch_template: %w", err)
+		}
+		branch = strings.TrimSpace(b.String())
+	}
+	if branch == "" {
+		branch = name
+	}
+	if _, err := repo.Run(ctx, "check-ref-format", "--branch", branch); err != nil {
+		return "", fmt.Errorf("invalid branch name %q", branch)
+	}
+	return branch, nil
+}
+
+// currentUser returns $USER, or the login name of the process owner.
+func currentUser() string {
+	if u := os.Getenv("USER"); u != "" {
+		return u
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "wiz"
+}
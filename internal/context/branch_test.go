@@ -0,0 +1,37 @@
+package context_test
+
+import (
+	gocontext "context"
+	"testing"
+
+	wizctx "github.com/buck3000/wiz/internal/context"
+)
+
+func TestBranchName(t *testing.T) {
+	_, repo := setupStore(t)
+	ctx := gocontext.Background()
+	t.Setenv("USER", "ada")
+
+	tests := []struct {
+		explicit, tmpl, want string
+	}{
+		{"", "", "feat-x"},
+		{"", "{{.User}}/{{.Name}}", "ada/feat-x"},
+		{"", "wiz/{{.Name}}", "wiz/feat-x"},
+		{"custom/branch", "wiz/{{.Name}}", "custom/branch"},
+	}
+	for _, tc := range tests {
+		got, err := wizctx.BranchName(ctx, repo, tc.explicit, tc.tmpl, "feat-x")
+		if err != nil || got != tc.want {
+			t.Errorf("BranchName(%q, %q) = %q, %v; want %q", tc.explicit, tc.tmpl, got, err, tc.want)
+		}
+	}
+	for _, tmpl := range []string{"{{.Nope}}", "{{.Name", "bad..{{.Name}}"} {
+		if _, err := wizctx.BranchName(ctx, repo, "", tmpl, "feat-x"); err == nil {
+			t.Errorf("BranchName(tmpl %q): expected error", tmpl)
+		}
+	}
+	if _, err := wizctx.BranchName(ctx, repo, "has space", "", "feat-x"); err == nil {
+		t.Error("expected an invalid explicit branch to be rejected")
+	}
+}
@@ -41,7 +41,9 @@ func (c *cloneProvisioner) Create(ctx gocontext.Context, opts CreateOpts) (strin
 		return "", fmt.Errorf("discover clone: %w", err)
 	}
 
-	if cloneRepo.BranchExists(ctx, opts.Branch) {
+	// A branch of the main repository is only a remote-tracking branch in
+	// the clone; checkout creates the local branch from it.
+	if cloneRepo.BranchExists(ctx, opts.Branch) || opts.Repo.BranchExists(ctx, opts.Branch) {
 		if _, err := cloneRepo.Run(ctx, "checkout", opts.Branch); err != nil {
 			os.RemoveAll(clonePath)
 			return "", fmt.Errorf("checkout branch: %w", err)
@@ -73,3 +75,27 @@ func (c *cloneProvisioner) Destroy(_ gocontext.Context, path string, force bool)
 	}
 	return os.RemoveAll(path)
 }
+
+func (c *cloneProvisioner) Move(_ gocontext.Context, path, name string) (string, error) {
+	dst := filepath.Join(config.ClonesDir(c.repo), SafeDirName(name))
+	if _, err := os.Stat(dst); err == nil {
+		return "", fmt.Errorf("%s already exists", dst)
+	}
+	if err := os.Rename(path, dst); err != nil {
+		return "", fmt.Errorf("move clone: %w", err)
+	}
+	return dst, nil
+}
+
+// RenameBranch renames the branch in the clone, where the context's commits
+// live, and in the main repository if it has a branch of that name too.
+func (c *cloneProvisioner) RenameBranch(ctx gocontext.Context, path, oldBranch, newBranch string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "branch", "-m", oldBranch, newBranch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("rename branch: %w\n%s", err, out)
+	}
+	if c.repo.BranchExists(ctx, oldBranch) && !c.repo.BranchExists(ctx, newBranch) {
+		c.repo.Run(ctx, "branch", "-m", oldBranch, newBranch)
+	}
+	return nil
+}
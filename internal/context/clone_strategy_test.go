@@ -130,8 +130,11 @@ func TestCloneProvisionerExistingBranch(t *testing.T) {
 	repo, _ := gitx.Discover(tr.Dir)
 	ctx := gocontext.Background()
 
-	// Create a branch in the source repo.
+	// Create a branch with its own commit in the source repo.
 	tr.CreateBranch("existing-branch")
+	tr.Checkout("existing-branch")
+	tr.AddFile("branch.txt", "on branch")
+	tr.Commit("branch commit")
 	tr.Checkout("main")
 
 	prov := wizctx.NewProvisioner(wizctx.StrategyClone, repo)
@@ -153,6 +156,9 @@ func TestCloneProvisionerExistingBranch(t *testing.T) {
 	if branch != "existing-branch" {
 		t.Errorf("branch = %q, want existing-branch", branch)
 	}
+	if _, err := os.Stat(filepath.Join(path, "branch.txt")); err != nil {
+		t.Errorf("clone is not at the branch's commit: %v", err)
+	}
 }
 
 func TestAutoStrategyDefaultsToWorktree(t *testing.T) {
@@ -164,3 +170,33 @@ func TestAutoStrategyDefaultsToWorktree(t *testing.T) {
 		t.Errorf("auto strategy resolved to %q, want worktree", prov.Strategy())
 	}
 }
+
+func TestCloneProvisionerMoveAndRenameBranch(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, _ := gitx.Discover(tr.Dir)
+	ctx := gocontext.Background()
+
+	prov := wizctx.NewProvisioner(wizctx.StrategyClone, repo)
+	path, err := prov.Create(ctx, wizctx.CreateOpts{Name: "old", Branch: "old", Repo: repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := prov.RenameBranch(ctx, path, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	newPath, err := prov.Move(ctx, path, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("old clone dir should be gone")
+	}
+	cloneRepo, err := gitx.Discover(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branch, _ := cloneRepo.CurrentBranch(ctx); branch != "new" {
+		t.Errorf("branch = %q, want new", branch)
+	}
+}
@@ -3,7 +3,12 @@ package context
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
+
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/forge"
+	"github.com/buck3000/wiz/internal/review"
 )
 
 // Strategy identifies how a context is backed.
@@ -29,14 +34,55 @@ func ParseStrategy(s string) Strategy {
 
 // Context represents a wiz context — an isolated working directory tied to a git branch.
 type Context struct {
-	Name       string   `json:"name"`
-	Branch     string   `json:"branch"`
-	Path       string   `json:"path"`
-	Strategy   Strategy `json:"strategy"`
-	CreatedAt  time.Time `json:"created_at"`
-	BaseBranch string   `json:"base_branch,omitempty"`
-	Task       string   `json:"task,omitempty"`
-	Agent      string   `json:"agent,omitempty"`
+	Name       string         `json:"name"`
+	Branch     string         `json:"branch"`
+	Path       string         `json:"path"`
+	Strategy   Strategy       `json:"strategy"`
+	CreatedAt  time.Time      `json:"created_at"`
+	BaseBranch string         `json:"base_branch,omitempty"`
+	Task       string         `json:"task,omitempty"`
+	Agent      string         `json:"agent,omitempty"`
+	Ports      []int          `json:"ports,omitempty"`
+	Review     *review.Review `json:"review,omitempty"` // latest wiz review
+	Template   string         `json:"template,omitempty"`
+	// Parent is the context this one is stacked on; BaseBranch is then the
+	// parent's branch and ParentHead the parent commit it was last based on.
+	Parent     string         `json:"parent,omitempty"`
+	ParentHead string         `json:"parent_head,omitempty"`
+	Checks     []check.Result `json:"checks,omitempty"` // latest wiz check results
+	// DependsOn lists contexts whose work this one builds on (set by orchestra).
+	DependsOn []string `json:"depends_on,omitempty"`
+	// PR is the pull request for the branch and PRChecks its CI checks, as
+	// last seen by wiz finish or wiz pr at PRPolledAt.
+	PR         *forge.PR     `json:"pr,omitempty"`
+	PRChecks   []forge.Check `json:"pr_checks,omitempty"`
+	PRPolledAt time.Time     `json:"pr_polled_at,omitzero"`
+	// Aliases are names the context had before wiz rename.
+	Aliases []string `json:"aliases,omitempty"`
+	// ForkedFrom is the context this one was forked from by wiz fork, at
+	// commit ForkPoint.
+	ForkedFrom string `json:"forked_from,omitempty"`
+	ForkPoint  string `json:"fork_point,omitempty"`
+	// Archived contexts have no working directory; wiz unarchive provisions
+	// it again at Path and restores snapshot ArchiveSnapshot, if any.
+	Archived        bool      `json:"archived,omitempty"`
+	ArchivedAt      time.Time `json:"archived_at,omitzero"`
+	ArchiveSnapshot int       `json:"archive_snapshot,omitempty"`
+	// Notes, Tags and Meta are free-form annotations set with wiz annotate.
+	Notes string            `json:"notes,omitempty"`
+	Tags  []string          `json:"tags,omitempty"`
+	Meta  map[string]string `json:"meta,omitempty"`
+}
+
+// Active returns the contexts that are not archived.
+func Active(contexts []Context) []Context {
+	var active []Context
+	for _, c := range contexts {
+		if !c.Archived {
+			active = append(active, c)
+		}
+	}
+	return active
 }
 
 var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/-]*$`)
@@ -55,6 +101,54 @@ func ValidateName(name string) error {
 	return nil
 }
 
+var slugUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slug turns free text such as an issue title into a valid context name of
+// at most max characters: lowercase words joined by dashes.
+func Slug(s string, max int) string {
+	slug := strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(slug) > max {
+		slug = slug[:max]
+		// Cut at a word boundary when there is one.
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return strings.Trim(slug, "-")
+}
+
+// AllocatePorts returns the lowest block of n consecutive ports starting at
+// or above base that doesn't overlap any port held by existing contexts.
+// Returns nil if n <= 0.
+func AllocatePorts(existing []Context, base, n int) []int {
+	if n <= 0 {
+		return nil
+	}
+	used := make(map[int]bool)
+	for _, c := range existing {
+		for _, p := range c.Ports {
+			used[p] = true
+		}
+	}
+	for start := base; start+n-1 <= 65535; start += n {
+		free := true
+		for p := start; p < start+n; p++ {
+			if used[p] {
+				free = false
+				break
+			}
+		}
+		if free {
+			ports := make([]int, n)
+			for i := range ports {
+				ports[i] = start + i
+			}
+			return ports
+		}
+	}
+	return nil
+}
+
 // SafeDirName converts a context name to a safe directory component.
 func SafeDirName(name string) string {
 	return regexp.MustCompile(`[/\\]`).ReplaceAllString(name, "__")
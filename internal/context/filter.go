@@ -0,0 +1,147 @@
+package context
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Filter is a parsed filter expression: whitespace-separated terms that must
+// all match. A term is key=value, key!=value or key~value (contains), or a
+// bare word searched for in every field like Matches. Keys are the fields
+// name, branch, base, task, notes, agent, strategy, template, parent, tag
+// and archived; any other key is looked up in Meta. Comparisons ignore case,
+// and key= matches contexts where the field is empty or unset.
+type Filter []filterTerm
+
+type filterTerm struct {
+	key, op, value string
+}
+
+// ParseFilter parses a filter expression.
+func ParseFilter(expr string) (Filter, error) {
+	var f Filter
+	for _, word := range strings.Fields(expr) {
+		i := strings.IndexAny(word, "=~")
+		if i < 0 {
+			f = append(f, filterTerm{value: word})
+			continue
+		}
+		t := filterTerm{key: word[:i], op: word[i : i+1], value: word[i+1:]}
+		if t.op == "=" && strings.HasSuffix(t.key, "!") {
+			t.key, t.op = strings.TrimSuffix(t.key, "!"), "!="
+		}
+		if t.key == "" {
+			return nil, fmt.Errorf("invalid filter term %q; want key=value, key!=value, key~value or a word", word)
+		}
+		f = append(f, t)
+	}
+	return f, nil
+}
+
+// Match reports whether c matches every term of f.
+func (f Filter) Match(c *Context) bool {
+	for _, t := range f {
+		if !t.match(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t filterTerm) match(c *Context) bool {
+	if t.key == "" {
+		return c.Matches(t.value)
+	}
+	values := c.field(t.key)
+	switch t.op {
+	case "~":
+		return slices.ContainsFunc(values, func(v string) bool { return containsFold(v, t.value) })
+	case "!=":
+		return !t.equal(values)
+	default:
+		return t.equal(values)
+	}
+}
+
+func (t filterTerm) equal(values []string) bool {
+	if len(values) == 0 {
+		return t.value == ""
+	}
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, t.value) })
+}
+
+// filterFields are the filter keys that name Context fields.
+var filterFields = []string{"name", "branch", "base", "task", "notes", "agent", "strategy", "template", "parent", "tag", "archived"}
+
+var validMetaKey = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ValidateMetaKey checks that key can be used in Meta and found by a filter.
+func ValidateMetaKey(key string) error {
+	if !validMetaKey.MatchString(key) {
+		return fmt.Errorf("invalid metadata key %q: must start with alphanumeric and contain only [a-zA-Z0-9._-]", key)
+	}
+	if slices.Contains(filterFields, strings.ToLower(key)) {
+		return fmt.Errorf("invalid metadata key %q: it names a context field", key)
+	}
+	return nil
+}
+
+// field returns the values of a filter key for c.
+func (c *Context) field(key string) []string {
+	switch strings.ToLower(key) {
+	case "name":
+		return []string{c.Name}
+	case "branch":
+		return []string{c.Branch}
+	case "base":
+		return []string{c.BaseBranch}
+	case "task":
+		return []string{c.Task}
+	case "notes":
+		return []string{c.Notes}
+	case "agent":
+		return []string{c.Agent}
+	case "strategy":
+		return []string{string(c.Strategy)}
+	case "template":
+		return []string{c.Template}
+	case "parent":
+		return []string{c.Parent}
+	case "tag":
+		return c.Tags
+	case "archived":
+		return []string{fmt.Sprint(c.Archived)}
+	}
+	for k, v := range c.Meta {
+		if strings.EqualFold(k, key) {
+			return []string{v}
+		}
+	}
+	return nil
+}
+
+// Matches reports whether text occurs, ignoring case, in c's name, branch,
+// task, agent, notes, tags or metadata values.
+func (c *Context) Matches(text string) bool {
+	fields := append([]string{c.Name, c.Branch, c.Task, c.Agent, c.Notes}, c.Tags...)
+	for _, v := range c.Meta {
+		fields = append(fields, v)
+	}
+	return slices.ContainsFunc(fields, func(v string) bool { return containsFold(v, text) })
+}
+
+// HasTags reports whether c has all of tags.
+func (c *Context) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		if !slices.ContainsFunc(c.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,69 @@
+package context_test
+
+import (
+	"testing"
+
+	wizctx "github.com/buck3000/wiz/internal/context"
+)
+
+func TestFilter(t *testing.T) {
+	c := &wizctx.Context{
+		Name:     "feat-auth",
+		Branch:   "alice/feat-auth",
+		Strategy: wizctx.StrategyWorktree,
+		Task:     "Add OAuth login",
+		Agent:    "claude",
+		Notes:    "blocked on the API keys",
+		Tags:     []string{"auth", "Urgent"},
+		Meta:     map[string]string{"Owner": "alice"},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"", true},
+		{"oauth", true},
+		{"keys", true},
+		{"urgent", true},
+		{"missing", false},
+		{"name=feat-auth", true},
+		{"name=feat", false},
+		{"name~feat", true},
+		{"tag=auth", true},
+		{"tag=urgent", true},
+		{"tag!=auth", false},
+		{"tag=ui", false},
+		{"owner=alice", true},
+		{"owner!=bob", true},
+		{"reviewer=", true},
+		{"reviewer!=", false},
+		{"template=", true},
+		{"agent=claude tag=auth", true},
+		{"agent=claude tag=ui", false},
+		{"archived=false", true},
+		{"strategy=worktree", true},
+	}
+	for _, tt := range tests {
+		f, err := wizctx.ParseFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q): %v", tt.expr, err)
+		}
+		if got := f.Match(c); got != tt.want {
+			t.Errorf("%q: Match = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	if _, err := wizctx.ParseFilter("=x"); err == nil {
+		t.Error("ParseFilter(\"=x\") should fail")
+	}
+}
+
+func TestHasTags(t *testing.T) {
+	c := &wizctx.Context{Tags: []string{"auth", "ui"}}
+	if !c.HasTags() || !c.HasTags("auth") || !c.HasTags("UI", "auth") {
+		t.Error("HasTags should match tags it has")
+	}
+	if c.HasTags("auth", "backend") {
+		t.Error("HasTags(auth, backend) = true")
+	}
+}
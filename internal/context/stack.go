@@ -0,0 +1,131 @@
+package context
+
+import "fmt"
+
+// TreeNode is a context positioned in the stack tree.
+type TreeNode struct {
+	Context Context
+	Depth   int
+	// Last is true when this is the final child of its parent.
+	Last bool
+}
+
+// Tree orders contexts depth-first so each context follows its parent,
+// preserving the original order among siblings. Contexts whose parent is
+// missing are treated as roots.
+func Tree(contexts []Context) []TreeNode {
+	byName := make(map[string]bool, len(contexts))
+	for _, c := range contexts {
+		byName[c.Name] = true
+	}
+	children := make(map[string][]Context)
+	var roots []Context
+	for _, c := range contexts {
+		if c.Parent != "" && byName[c.Parent] && c.Parent != c.Name {
+			children[c.Parent] = append(children[c.Parent], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	nodes := make([]TreeNode, 0, len(contexts))
+	seen := make(map[string]bool, len(contexts))
+	var walk func(cs []Context, depth int)
+	walk = func(cs []Context, depth int) {
+		for i, c := range cs {
+			if seen[c.Name] {
+				continue
+			}
+			seen[c.Name] = true
+			nodes = append(nodes, TreeNode{Context: c, Depth: depth, Last: i == len(cs)-1})
+			walk(children[c.Name], depth+1)
+		}
+	}
+	walk(roots, 0)
+	// Contexts caught in a parent cycle are never reached from a root.
+	for _, c := range contexts {
+		if !seen[c.Name] {
+			seen[c.Name] = true
+			nodes = append(nodes, TreeNode{Context: c, Last: true})
+		}
+	}
+	return nodes
+}
+
+// Descendants returns the names of all contexts stacked, directly or
+// indirectly, on the named context, parents before children.
+func Descendants(contexts []Context, name string) []string {
+	var out []string
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for _, c := range contexts {
+			if c.Parent == parent && !seen[c.Name] {
+				seen[c.Name] = true
+				out = append(out, c.Name)
+				queue = append(queue, c.Name)
+			}
+		}
+	}
+	return out
+}
+
+// DependencyOrder orders the named contexts so that each comes after the
+// contexts it is stacked on or depends on, keeping the given order where
+// there is no dependency. Dependencies outside names are ignored.
+func DependencyOrder(contexts []Context, names []string) ([]string, error) {
+	byName := make(map[string]Context, len(contexts))
+	for _, c := range contexts {
+		byName[c.Name] = c
+	}
+	selected := make(map[string]bool, len(names))
+	for _, n := range names {
+		if _, ok := byName[n]; !ok {
+			return nil, fmt.Errorf("context %q not found", n)
+		}
+		selected[n] = true
+	}
+	deps := func(name string) []string {
+		c := byName[name]
+		var out []string
+		for _, d := range append([]string{c.Parent}, c.DependsOn...) {
+			if d != "" && d != name && selected[d] {
+				out = append(out, d)
+			}
+		}
+		return out
+	}
+
+	var order []string
+	done := make(map[string]bool, len(names))
+	for len(order) < len(names) {
+		progressed := false
+		for _, n := range names {
+			if done[n] {
+				continue
+			}
+			ready := true
+			for _, d := range deps(n) {
+				ready = ready && done[d]
+			}
+			if ready {
+				done[n] = true
+				order = append(order, n)
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			var stuck []string
+			for _, n := range names {
+				if !done[n] {
+					stuck = append(stuck, n)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle among contexts %v", stuck)
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,89 @@
+package context_test
+
+import (
+	"testing"
+
+	wizctx "github.com/buck3000/wiz/internal/context"
+)
+
+func TestTreeOrdersChildrenAfterParents(t *testing.T) {
+	contexts := []wizctx.Context{
+		{Name: "ui", Parent: "api"},
+		{Name: "schema"},
+		{Name: "other"},
+		{Name: "api", Parent: "schema"},
+		{Name: "orphan", Parent: "gone"},
+	}
+	nodes := wizctx.Tree(contexts)
+
+	var got []string
+	depths := map[string]int{}
+	for _, n := range nodes {
+		got = append(got, n.Context.Name)
+		depths[n.Context.Name] = n.Depth
+	}
+	want := []string{"schema", "api", "ui", "other", "orphan"}
+	if len(got) != len(want) {
+		t.Fatalf("Tree = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Tree = %v, want %v", got, want)
+		}
+	}
+	if depths["schema"] != 0 || depths["api"] != 1 || depths["ui"] != 2 || depths["orphan"] != 0 {
+		t.Errorf("depths = %v", depths)
+	}
+}
+
+func TestTreeSurvivesCycle(t *testing.T) {
+	nodes := wizctx.Tree([]wizctx.Context{{Name: "a", Parent: "b"}, {Name: "b", Parent: "a"}})
+	if len(nodes) != 2 {
+		t.Errorf("Tree returned %d nodes, want 2", len(nodes))
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	contexts := []wizctx.Context{
+		{Name: "schema"},
+		{Name: "api", Parent: "schema"},
+		{Name: "ui", Parent: "api"},
+		{Name: "docs", Parent: "schema"},
+	}
+	got := wizctx.Descendants(contexts, "schema")
+	if len(got) != 3 || got[0] != "api" || got[1] != "docs" || got[2] != "ui" {
+		t.Errorf("Descendants = %v", got)
+	}
+	if d := wizctx.Descendants(contexts, "ui"); len(d) != 0 {
+		t.Errorf("Descendants(ui) = %v", d)
+	}
+}
+
+func TestDependencyOrder(t *testing.T) {
+	contexts := []wizctx.Context{
+		{Name: "ui", DependsOn: []string{"api"}},
+		{Name: "docs"},
+		{Name: "api", Parent: "schema"},
+		{Name: "schema"},
+	}
+	got, err := wizctx.DependencyOrder(contexts, []string{"ui", "docs", "api", "schema"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"docs", "schema", "api", "ui"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("DependencyOrder = %v, want %v", got, want)
+		}
+	}
+
+	// Dependencies that aren't being ordered are ignored.
+	if got, _ := wizctx.DependencyOrder(contexts, []string{"ui"}); len(got) != 1 {
+		t.Errorf("DependencyOrder(ui) = %v", got)
+	}
+
+	cyclic := []wizctx.Context{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", Parent: "a"}}
+	if _, err := wizctx.DependencyOrder(cyclic, []string{"a", "b"}); err == nil {
+		t.Error("expected cycle error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/buck3000/wiz/internal/config"
 	"github.com/buck3000/wiz/internal/gitx"
@@ -58,8 +59,37 @@ func (s *Store) Get(name string) (*Context, error) {
 	return nil, fmt.Errorf("context %q not found", name)
 }
 
+// Resolve is like Get, but also finds a context by a name it had before
+// being renamed, e.g. the WIZ_CTX of a shell opened before wiz rename.
+func (s *Store) Resolve(name string) (*Context, error) {
+	if c, err := s.Get(name); err == nil {
+		return c, nil
+	}
+	st, err := s.readState()
+	if err != nil {
+		return nil, err
+	}
+	for i := range st.Contexts {
+		if slices.Contains(st.Contexts[i].Aliases, name) {
+			return &st.Contexts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("context %q not found", name)
+}
+
 // Add adds a context to the store. Acquires a file lock.
 func (s *Store) Add(ctx gocontext.Context, c Context) error {
+	return s.add(ctx, &c, 0, 0)
+}
+
+// AddWithPorts is like Add, but first sets c.Ports to a free block of n
+// ports from base. Allocating under the lock keeps concurrent creates from
+// getting the same ports.
+func (s *Store) AddWithPorts(ctx gocontext.Context, c *Context, base, n int) error {
+	return s.add(ctx, c, base, n)
+}
+
+func (s *Store) add(ctx gocontext.Context, c *Context, base, n int) error {
 	return s.lk.WithLock(ctx, func() error {
 		st, err := s.readState()
 		if err != nil {
@@ -70,7 +100,10 @@ func (s *Store) Add(ctx gocontext.Context, c Context) error {
 				return fmt.Errorf("context %q already exists", c.Name)
 			}
 		}
-		st.Contexts = append(st.Contexts, c)
+		if n > 0 {
+			c.Ports = AllocatePorts(st.Contexts, base, n)
+		}
+		st.Contexts = append(st.Contexts, *c)
 		return s.writeState(st)
 	})
 }
@@ -99,7 +132,8 @@ func (s *Store) Remove(ctx gocontext.Context, name string) error {
 	})
 }
 
-// Rename renames a context. Acquires a file lock.
+// Rename renames a context, keeping the old name as an alias and updating
+// contexts that refer to it. Acquires a file lock.
 func (s *Store) Rename(ctx gocontext.Context, oldName, newName string) error {
 	if err := ValidateName(newName); err != nil {
 		return err
@@ -116,10 +150,25 @@ func (s *Store) Rename(ctx gocontext.Context, oldName, newName string) error {
 		}
 		found := false
 		for i := range st.Contexts {
-			if st.Contexts[i].Name == oldName {
-				st.Contexts[i].Name = newName
+			c := &st.Contexts[i]
+			if c.Name == oldName {
+				c.Name = newName
+				c.Aliases = append(slices.DeleteFunc(c.Aliases, func(a string) bool {
+					return a == newName || a == oldName
+				}), oldName)
 				found = true
-				break
+			}
+			// Keep stacked children pointing at their parent.
+			if c.Parent == oldName {
+				c.Parent = newName
+			}
+			if c.ForkedFrom == oldName {
+				c.ForkedFrom = newName
+			}
+			for j, d := range c.DependsOn {
+				if d == oldName {
+					c.DependsOn[j] = newName
+				}
 			}
 		}
 		if !found {
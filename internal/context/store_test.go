@@ -106,6 +106,7 @@ func TestStoreRename(t *testing.T) {
 	ctx := gocontext.Background()
 
 	store.Add(ctx, wizctx.Context{Name: "old-name", Branch: "old-name"})
+	store.Add(ctx, wizctx.Context{Name: "child", Branch: "child", Parent: "old-name"})
 	err := store.Rename(ctx, "old-name", "new-name")
 	if err != nil {
 		t.Fatal(err)
@@ -123,6 +124,48 @@ func TestStoreRename(t *testing.T) {
 	if err == nil {
 		t.Fatal("old name should not exist")
 	}
+
+	child, _ := store.Get("child")
+	if child.Parent != "new-name" {
+		t.Errorf("child Parent = %q, want new-name", child.Parent)
+	}
+}
+
+func TestStoreResolveAlias(t *testing.T) {
+	store, _ := setupStore(t)
+	ctx := gocontext.Background()
+
+	store.Add(ctx, wizctx.Context{Name: "a", Branch: "a"})
+	store.Add(ctx, wizctx.Context{Name: "dep", Branch: "dep", DependsOn: []string{"a"}})
+	if err := store.Rename(ctx, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Rename(ctx, "b", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		c, err := store.Resolve(name)
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", name, err)
+		}
+		if c.Name != "c" {
+			t.Errorf("Resolve(%q) = %q, want c", name, c.Name)
+		}
+	}
+	dep, _ := store.Get("dep")
+	if len(dep.DependsOn) != 1 || dep.DependsOn[0] != "c" {
+		t.Errorf("DependsOn = %v, want [c]", dep.DependsOn)
+	}
+
+	// A context's own name wins over another's alias.
+	store.Add(ctx, wizctx.Context{Name: "a", Branch: "a2"})
+	if c, _ := store.Resolve("a"); c.Branch != "a2" {
+		t.Errorf("Resolve(a) = %+v, want the new context", c)
+	}
+	if _, err := store.Resolve("missing"); err == nil {
+		t.Error("Resolve(missing) should fail")
+	}
 }
 
 func TestStoreRenameDuplicate(t *testing.T) {
@@ -172,6 +215,42 @@ func TestStoreConcurrentAdd(t *testing.T) {
 	}
 }
 
+func TestStoreAddWithPortsConcurrent(t *testing.T) {
+	store, _ := setupStore(t)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range 10 {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			name := "ctx-" + string(rune('a'+idx))
+			c := wizctx.Context{Name: name, Branch: name}
+			errs[idx] = store.AddWithPorts(gocontext.Background(), &c, 4000, 2)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int]string)
+	list, _ := store.List()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("goroutine %d: %v", i, err)
+		}
+	}
+	for _, c := range list {
+		if len(c.Ports) != 2 {
+			t.Errorf("%s: ports = %v", c.Name, c.Ports)
+		}
+		for _, p := range c.Ports {
+			if other, ok := seen[p]; ok {
+				t.Errorf("port %d given to %s and %s", p, other, c.Name)
+			}
+			seen[p] = c.Name
+		}
+	}
+}
+
 func TestValidateName(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -196,3 +275,54 @@ func TestValidateName(t *testing.T) {
 		}
 	}
 }
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"Fix login crash", 40, "fix-login-crash"},
+		{"42 — Can't save: 'draft' posts!", 40, "42-can-t-save-draft-posts"},
+		{"Add OAuth2 support for enterprise tenants", 20, "add-oauth2-support"},
+		{"supercalifragilistic", 5, "super"},
+	}
+	for _, tc := range tests {
+		got := wizctx.Slug(tc.in, tc.max)
+		if got != tc.want {
+			t.Errorf("Slug(%q, %d) = %q, want %q", tc.in, tc.max, got, tc.want)
+		}
+		if err := wizctx.ValidateName(got); err != nil {
+			t.Errorf("Slug(%q) is not a valid name: %v", tc.in, err)
+		}
+	}
+}
+
+func TestAllocatePorts(t *testing.T) {
+	if got := wizctx.AllocatePorts(nil, 4000, 0); got != nil {
+		t.Errorf("n=0: got %v, want nil", got)
+	}
+
+	got := wizctx.AllocatePorts(nil, 4000, 3)
+	if len(got) != 3 || got[0] != 4000 || got[2] != 4002 {
+		t.Errorf("first block = %v", got)
+	}
+
+	// The first block is taken; a gap left by a deleted context is reused.
+	existing := []wizctx.Context{
+		{Name: "a", Ports: []int{4000, 4001, 4002}},
+		{Name: "c", Ports: []int{4006, 4007, 4008}},
+	}
+	got = wizctx.AllocatePorts(existing, 4000, 3)
+	if len(got) != 3 || got[0] != 4003 {
+		t.Errorf("gap block = %v, want 4003-4005", got)
+	}
+}
+
+func TestActive(t *testing.T) {
+	contexts := []wizctx.Context{{Name: "a"}, {Name: "b", Archived: true}, {Name: "c"}}
+	got := wizctx.Active(contexts)
+	if len(got) != 2 || got[0].Name != "a" || got[1].Name != "c" {
+		t.Errorf("Active = %+v", got)
+	}
+}
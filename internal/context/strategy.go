@@ -20,6 +20,11 @@ type Provisioner interface {
 	Create(ctx gocontext.Context, opts CreateOpts) (string, error)
 	// Destroy removes the context directory.
 	Destroy(ctx gocontext.Context, path string, force bool) error
+	// Move moves the context directory at path to the one for name and
+	// returns the new path.
+	Move(ctx gocontext.Context, path, name string) (string, error)
+	// RenameBranch renames the context's branch.
+	RenameBranch(ctx gocontext.Context, path, oldBranch, newBranch string) error
 	// Strategy returns the strategy name.
 	Strategy() Strategy
 }
@@ -0,0 +1,39 @@
+package context
+
+import (
+	gocontext "context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/buck3000/wiz/internal/gitx"
+)
+
+// Tip returns the commit at the tip of c's branch and makes sure its objects
+// are available in repo. Worktree branches live in repo already; clone
+// contexts keep their commits in the clone, so those are fetched into repo's
+// object store (through a temporary ref that is removed again).
+func Tip(ctx gocontext.Context, repo *gitx.Repo, c *Context) (string, error) {
+	if c.Strategy != StrategyClone {
+		sha, err := repo.Run(ctx, "rev-parse", "--verify", "refs/heads/"+c.Branch+"^{commit}")
+		if err != nil {
+			return "", fmt.Errorf("context %q: branch %q not found", c.Name, c.Branch)
+		}
+		return sha, nil
+	}
+
+	out, err := exec.CommandContext(ctx, "git", "-C", c.Path, "rev-parse", "--verify", "refs/heads/"+c.Branch+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("context %q: branch %q not found in clone", c.Name, c.Branch)
+	}
+	sha := strings.TrimSpace(string(out))
+	if _, err := repo.Run(ctx, "cat-file", "-e", sha+"^{commit}"); err == nil {
+		return sha, nil
+	}
+	ref := "refs/wiz/tmp/" + SafeDirName(c.Name)
+	if _, err := repo.Run(ctx, "fetch", "--quiet", "--no-tags", c.Path, "+refs/heads/"+c.Branch+":"+ref); err != nil {
+		return "", fmt.Errorf("fetch %s: %w", c.Name, err)
+	}
+	repo.Run(ctx, "update-ref", "-d", ref)
+	return sha, nil
+}
@@ -47,3 +47,23 @@ func (w *worktreeProvisioner) Destroy(ctx gocontext.Context, path string, force
 	}
 	return nil
 }
+
+func (w *worktreeProvisioner) Move(ctx gocontext.Context, path, name string) (string, error) {
+	dst := filepath.Join(config.TreesDir(w.repo), SafeDirName(name))
+	if _, err := os.Stat(dst); err == nil {
+		return "", fmt.Errorf("%s already exists", dst)
+	}
+	if _, err := w.repo.Run(ctx, "worktree", "move", path, dst); err != nil {
+		return "", fmt.Errorf("move worktree: %w", err)
+	}
+	return dst, nil
+}
+
+// RenameBranch renames the branch in the main repository; git updates the
+// worktree that has it checked out.
+func (w *worktreeProvisioner) RenameBranch(ctx gocontext.Context, _, oldBranch, newBranch string) error {
+	if _, err := w.repo.Run(ctx, "branch", "-m", oldBranch, newBranch); err != nil {
+		return fmt.Errorf("rename branch: %w", err)
+	}
+	return nil
+}
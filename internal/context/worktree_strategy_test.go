@@ -107,3 +107,43 @@ func TestWorktreeProvisionerWithBase(t *testing.T) {
 		t.Fatal("base.txt not found in worktree")
 	}
 }
+
+func TestWorktreeProvisionerMoveAndRenameBranch(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, _ := gitx.Discover(tr.Dir)
+	ctx := gocontext.Background()
+
+	prov := wizctx.NewProvisioner(wizctx.StrategyWorktree, repo)
+	path, err := prov.Create(ctx, wizctx.CreateOpts{Name: "old", Branch: "old", Repo: repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := prov.RenameBranch(ctx, path, "old", "team/new"); err != nil {
+		t.Fatal(err)
+	}
+	newPath, err := prov.Move(ctx, path, "team/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(newPath) != "team__new" {
+		t.Errorf("moved to %s", newPath)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("old worktree dir should be gone")
+	}
+
+	wtRepo, err := gitx.Discover(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branch, _ := wtRepo.CurrentBranch(ctx); branch != "team/new" {
+		t.Errorf("branch = %q, want team/new", branch)
+	}
+	if repo.BranchExists(ctx, "old") {
+		t.Error("old branch should be renamed")
+	}
+	if err := prov.Destroy(ctx, newPath, false); err != nil {
+		t.Fatalf("destroy moved worktree: %v", err)
+	}
+}
@@ -1,10 +1,17 @@
 package doctor
 
 import (
+	"context"
 	"os"
 	"os/exec"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/buck3000/wiz/internal/agent"
+	"github.com/buck3000/wiz/internal/config"
+	"github.com/buck3000/wiz/internal/gitx"
 	"github.com/buck3000/wiz/internal/terminfo"
 )
 
@@ -32,9 +39,84 @@ func RunAll() []CheckResult {
 	results = append(results, checkTerminal())
 	results = append(results, checkShellIntegration())
 	results = append(results, checkActiveContext())
+	results = append(results, checkAgents()...)
 	return results
 }
 
+// checkAgents reports each installed built-in agent with its version, plus
+// one informational line listing the agents that weren't found, and checks
+// the custom agents configured for the current repository.
+func checkAgents() []CheckResult {
+	var custom []string
+	repo, err := gitx.Discover(".")
+	if err == nil {
+		for name := range config.Load(repo).Agents {
+			custom = append(custom, name)
+		}
+		sort.Strings(custom)
+	}
+
+	var results []CheckResult
+	var missing []string
+	for _, name := range agent.List() {
+		if slices.Contains(custom, name) {
+			continue
+		}
+		a, _ := agent.Builtin(name)
+		if _, err := exec.LookPath(a.Command); err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		results = append(results, agentVersion(a))
+	}
+	for _, name := range custom {
+		a, err := agent.Resolve(repo, name)
+		if err != nil {
+			results = append(results, CheckResult{Name: "Agent " + name, Status: Fail, Message: err.Error()})
+			continue
+		}
+		if _, err := exec.LookPath(a.Command); err != nil {
+			results = append(results, CheckResult{
+				Name:    "Agent " + name,
+				Status:  Warn,
+				Message: "Configured, but " + a.Command + " not found in PATH",
+			})
+			continue
+		}
+		results = append(results, agentVersion(a))
+	}
+	if len(results) == 0 {
+		return []CheckResult{{
+			Name:    "Agents",
+			Status:  Warn,
+			Message: "No coding agents found in PATH (known: " + strings.Join(agent.List(), ", ") + ")",
+		}}
+	}
+	if len(missing) > 0 {
+		results = append(results, CheckResult{
+			Name:    "Other agents",
+			Status:  OK,
+			Message: "Not installed: " + strings.Join(missing, ", "),
+		})
+	}
+	return results
+}
+
+// agentVersion reports an installed agent with its version.
+func agentVersion(a *agent.Agent) CheckResult {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	version, err := a.Version(ctx)
+	cancel()
+	if err != nil || version == "" {
+		version = "installed (version unknown)"
+	}
+	return CheckResult{
+		Name:    "Agent " + a.Name,
+		Status:  OK,
+		Message: version,
+	}
+}
+
 func checkGhCLI() CheckResult {
 	out, err := exec.Command("gh", "--version").Output()
 	if err != nil {
@@ -0,0 +1,239 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// bitbucket talks to the Bitbucket Cloud REST API, where the owner is the
+// workspace and the repository is identified by its slug.
+type bitbucket struct {
+	pusher
+	c      *client
+	remote *Remote
+}
+
+type bbBranchRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+type bbPull struct {
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	State       string      `json:"state"` // OPEN, MERGED, DECLINED, SUPERSEDED
+	Draft       bool        `json:"draft"`
+	Source      bbBranchRef `json:"source"`
+	Destination bbBranchRef `json:"destination"`
+	Links       struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+	Participants []struct {
+		Approved bool   `json:"approved"`
+		State    string `json:"state"` // approved, changes_requested or null
+	} `json:"participants"`
+}
+
+// isBBFork reports whether a PR's source repository differs from its
+// destination.
+func isBBFork(source, dest string) bool {
+	return source != "" && dest != "" && !strings.EqualFold(source, dest)
+}
+
+func (p *bbPull) pr() *PR {
+	pr := &PR{
+		Number:    p.ID,
+		URL:       p.Links.HTML.Href,
+		Title:     p.Title,
+		State:     StateClosed,
+		Draft:     p.Draft,
+		Head:      p.Source.Branch.Name,
+		Base:      p.Destination.Branch.Name,
+		Fork:      isBBFork(p.Source.Repository.FullName, p.Destination.Repository.FullName),
+		HeadSHA:   p.Source.Commit.Hash,
+		Mergeable: Unknown, // not reported by the API
+	}
+	switch p.State {
+	case "OPEN":
+		pr.State = StateOpen
+	case "MERGED":
+		pr.State, pr.Mergeable = StateMerged, ""
+	}
+	states := make([]string, 0, len(p.Participants))
+	for _, part := range p.Participants {
+		switch {
+		case part.State != "":
+			states = append(states, part.State)
+		case part.Approved:
+			states = append(states, "approved")
+		}
+	}
+	pr.Review = reviewState(states, "approved", "changes_requested")
+	return pr
+}
+
+func (b *bitbucket) Kind() string { return Bitbucket }
+
+func (b *bitbucket) repoPath(format string, args ...any) string {
+	return fmt.Sprintf("/repositories/%s/%s", b.remote.Owner, b.remote.Repo) + fmt.Sprintf(format, args...)
+}
+
+// CreatePR opens a pull request. Bitbucket has no PR labels, so
+// opts.Labels is ignored; reviewers are given by account id or {uuid}.
+func (b *bitbucket) CreatePR(ctx context.Context, opts CreateOpts) (*PR, error) {
+	in := map[string]any{
+		"title":       opts.Title,
+		"description": opts.Body,
+		"draft":       opts.Draft,
+		"source":      map[string]any{"branch": map[string]string{"name": opts.Head}},
+		"destination": map[string]any{"branch": map[string]string{"name": opts.Base}},
+	}
+	if len(opts.Reviewers) > 0 {
+		in["reviewers"] = bbReviewers(opts.Reviewers)
+	}
+	var p bbPull
+	if err := b.c.do(ctx, "POST", b.repoPath("/pullrequests"), in, &p); err != nil {
+		return nil, err
+	}
+	return p.pr(), nil
+}
+
+// UpdatePR edits a pull request; Bitbucket replaces the reviewer list.
+func (b *bitbucket) UpdatePR(ctx context.Context, number int, opts CreateOpts) (*PR, error) {
+	in := map[string]any{}
+	if opts.Title != "" {
+		in["title"] = opts.Title
+	}
+	if opts.Body != "" {
+		in["description"] = opts.Body
+	}
+	if len(opts.Reviewers) > 0 {
+		in["reviewers"] = bbReviewers(opts.Reviewers)
+	}
+	if len(in) == 0 {
+		return b.GetPR(ctx, number)
+	}
+	var p bbPull
+	if err := b.c.do(ctx, "PUT", b.repoPath("/pullrequests/%d", number), in, &p); err != nil {
+		return nil, err
+	}
+	return p.pr(), nil
+}
+
+// bbReviewers identifies reviewers by {uuid} or account id.
+func bbReviewers(ids []string) []map[string]string {
+	reviewers := make([]map[string]string, 0, len(ids))
+	for _, r := range ids {
+		if strings.HasPrefix(r, "{") {
+			reviewers = append(reviewers, map[string]string{"uuid": r})
+		} else {
+			reviewers = append(reviewers, map[string]string{"account_id": r})
+		}
+	}
+	return reviewers
+}
+
+func (b *bitbucket) MergePR(ctx context.Context, number int, method MergeMethod) error {
+	strategy := map[MergeMethod]string{
+		MergeCommit: "merge_commit",
+		MergeSquash: "squash",
+		MergeRebase: "rebase_merge",
+	}[method]
+	return b.c.do(ctx, "POST", b.repoPath("/pullrequests/%d/merge", number), map[string]any{"merge_strategy": strategy}, nil)
+}
+
+func (b *bitbucket) GetPR(ctx context.Context, number int) (*PR, error) {
+	var p bbPull
+	if err := b.c.do(ctx, "GET", b.repoPath("/pullrequests/%d", number), nil, &p); err != nil {
+		return nil, err
+	}
+	return p.pr(), nil
+}
+
+func (b *bitbucket) FindPR(ctx context.Context, head string) (*PR, error) {
+	var page struct {
+		Values []bbPull `json:"values"`
+	}
+	q := url.Values{"q": {fmt.Sprintf(`source.branch.name = %q AND state = "OPEN"`, head)}}
+	if err := b.c.do(ctx, "GET", b.repoPath("/pullrequests?%s", q.Encode()), nil, &page); err != nil {
+		return nil, err
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+	return page.Values[0].pr(), nil
+}
+
+func (b *bitbucket) Checks(ctx context.Context, pr *PR) ([]Check, error) {
+	var page struct {
+		Values []struct {
+			Key   string `json:"key"`
+			Name  string `json:"name"`
+			State string `json:"state"` // INPROGRESS, SUCCESSFUL, FAILED, STOPPED
+			URL   string `json:"url"`
+		} `json:"values"`
+	}
+	if err := b.c.do(ctx, "GET", b.repoPath("/commit/%s/statuses", pr.HeadSHA), nil, &page); err != nil {
+		return nil, err
+	}
+	checks := make([]Check, 0, len(page.Values))
+	for _, s := range page.Values {
+		name := s.Name
+		if name == "" {
+			name = s.Key
+		}
+		checks = append(checks, Check{
+			Name:   name,
+			Status: checkStatus(s.State, []string{"SUCCESSFUL"}, []string{"INPROGRESS"}),
+			URL:    s.URL,
+		})
+	}
+	return checks, nil
+}
+
+// GetIssue reads an issue from the repository's built-in issue tracker.
+func (b *bitbucket) GetIssue(ctx context.Context, number int) (*Issue, error) {
+	var i struct {
+		ID      int    `json:"id"`
+		Title   string `json:"title"`
+		State   string `json:"state"` // new, open, resolved, closed, ...
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	if err := b.c.do(ctx, "GET", b.repoPath("/issues/%d", number), nil, &i); err != nil {
+		return nil, err
+	}
+	state := StateClosed
+	if i.State == "new" || i.State == "open" {
+		state = StateOpen
+	}
+	return &Issue{Number: i.ID, URL: i.Links.HTML.Href, Title: i.Title, Body: i.Content.Raw, State: state}, nil
+}
+
+// bitbucketAuth sends "user:app-password" tokens as basic auth and anything
+// else as a bearer access token.
+func bitbucketAuth(req *http.Request, token string) {
+	if user, pass, ok := strings.Cut(token, ":"); ok {
+		req.SetBasicAuth(user, pass)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
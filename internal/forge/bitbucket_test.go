@@ -0,0 +1,76 @@
+package forge_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/forge"
+)
+
+func TestBitbucket(t *testing.T) {
+	ctx := context.Background()
+	pull := map[string]any{
+		"id": 4, "title": "Add auth", "state": "OPEN",
+		"source":      map[string]any{"branch": map[string]any{"name": "feat-auth"}, "commit": map[string]any{"hash": "aaa111"}},
+		"destination": map[string]any{"branch": map[string]any{"name": "main"}},
+		"links":       map[string]any{"html": map[string]any{"href": "https://bitbucket.org/ws/widgets/pull-requests/4"}},
+		"participants": []any{
+			map[string]any{"approved": true},
+		},
+	}
+	api := newFakeAPI(t, map[string]any{
+		"POST /repositories/ws/widgets/pullrequests":         pull,
+		"GET /repositories/ws/widgets/pullrequests":          map[string]any{"values": []any{pull}},
+		"GET /repositories/ws/widgets/pullrequests/4":        pull,
+		"POST /repositories/ws/widgets/pullrequests/4/merge": pull,
+		"GET /repositories/ws/widgets/commit/aaa111/statuses": map[string]any{"values": []any{
+			map[string]any{"key": "pipeline", "state": "INPROGRESS"},
+			map[string]any{"name": "build", "state": "FAILED"},
+		}},
+	})
+	f := newTestForge(t, forge.Bitbucket, api, "ws")
+
+	pr, err := f.CreatePR(ctx, forge.CreateOpts{Title: "Add auth", Head: "feat-auth", Base: "main",
+		Reviewers: []string{"{1234-uuid}", "557058:abc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Number != 4 || pr.State != forge.StateOpen || pr.Review != forge.ReviewApproved || pr.Mergeable != forge.Unknown {
+		t.Errorf("CreatePR = %+v", pr)
+	}
+	req := api.Requests["POST /repositories/ws/widgets/pullrequests"]
+	if reviewers, _ := req["reviewers"].([]any); len(reviewers) != 2 {
+		t.Errorf("reviewers = %v", req["reviewers"])
+	}
+
+	if found, err := f.FindPR(ctx, "feat-auth"); err != nil || found == nil || found.HeadSHA != "aaa111" {
+		t.Fatalf("FindPR = %+v, %v", found, err)
+	}
+	checks, err := f.Checks(ctx, pr)
+	if err != nil || len(checks) != 2 || checks[0].Name != "pipeline" || checks[0].Status != forge.CheckPending || checks[1].Status != forge.CheckFailure {
+		t.Errorf("Checks = %+v, %v", checks, err)
+	}
+	if err := f.MergePR(ctx, 4, forge.MergeCommit); err != nil {
+		t.Fatal(err)
+	}
+	if s := api.Requests["POST /repositories/ws/widgets/pullrequests/4/merge"]["merge_strategy"]; s != "merge_commit" {
+		t.Errorf("merge_strategy = %v", s)
+	}
+
+	// App passwords ("user:password") use basic auth.
+	f, _ = forge.New(&forge.Remote{Name: "origin", Host: "bitbucket.org", Owner: "ws", Repo: "widgets"},
+		forge.Options{APIURL: api.URL, Token: "me:app-pass"})
+	if _, err := f.GetPR(ctx, 4); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := api.Auth[len(api.Auth)-1], "Basic "+base64.StdEncoding.EncodeToString([]byte("me:app-pass")); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+
+	var apiErr *forge.APIError
+	if _, err := f.GetPR(ctx, 99); !errors.As(err, &apiErr) || apiErr.Status != 404 {
+		t.Errorf("GetPR(99) err = %v, want 404 APIError", err)
+	}
+}
@@ -0,0 +1,415 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Forge kinds.
+const (
+	GitHub    = "github"
+	GitLab    = "gitlab"
+	Gitea     = "gitea" // also Forgejo
+	Bitbucket = "bitbucket"
+)
+
+// PR states.
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+	StateMerged = "merged"
+)
+
+// Mergeability.
+const (
+	Mergeable   = "mergeable"
+	Conflicting = "conflicting"
+	Unknown     = "unknown"
+)
+
+// Review states.
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewPending          = "pending"
+)
+
+// Check states.
+const (
+	CheckPending = "pending"
+	CheckSuccess = "success"
+	CheckFailure = "failure"
+)
+
+// MergeMethod selects how a PR is merged.
+type MergeMethod string
+
+const (
+	MergeCommit MergeMethod = "merge"
+	MergeSquash MergeMethod = "squash"
+	MergeRebase MergeMethod = "rebase"
+)
+
+// PR is a pull request (a merge request on GitLab).
+type PR struct {
+	Number    int    `json:"number"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	State     string `json:"state"` // open, closed or merged
+	Draft     bool   `json:"draft,omitempty"`
+	Head      string `json:"head"`
+	Base      string `json:"base"`
+	Fork      bool   `json:"fork,omitempty"` // Head is a branch of another repository
+	HeadSHA   string `json:"head_sha,omitempty"`
+	Mergeable string `json:"mergeable,omitempty"` // mergeable, conflicting or unknown
+	Review    string `json:"review,omitempty"`    // approved, changes_requested or pending
+}
+
+// Check is one CI check or commit status on a PR's head commit.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // pending, success or failure
+	URL    string `json:"url,omitempty"`
+}
+
+// Issue is an issue in the forge's tracker.
+type Issue struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+	Title  string `json:"title"`
+	Body   string `json:"body,omitempty"`
+	State  string `json:"state"` // open or closed
+}
+
+// ChecksState combines checks into one status: failure if any failed,
+// pending if any are still running, otherwise success. It is "" without
+// checks.
+func ChecksState(checks []Check) string {
+	state := ""
+	for _, c := range checks {
+		switch c.Status {
+		case CheckFailure:
+			return CheckFailure
+		case CheckPending:
+			state = CheckPending
+		default:
+			if state == "" {
+				state = CheckSuccess
+			}
+		}
+	}
+	return state
+}
+
+// ChecksSummary summarizes checks as e.g. "2/3 passed, 1 pending".
+func ChecksSummary(checks []Check) string {
+	if len(checks) == 0 {
+		return "-"
+	}
+	passed, pending := 0, 0
+	for _, c := range checks {
+		switch c.Status {
+		case CheckSuccess:
+			passed++
+		case CheckPending:
+			pending++
+		}
+	}
+	s := fmt.Sprintf("%d/%d passed", passed, len(checks))
+	if pending > 0 {
+		s += fmt.Sprintf(", %d pending", pending)
+	}
+	return s
+}
+
+// CreateOpts describes a PR to open. Passed to UpdatePR, empty Title and
+// Body leave those unchanged and Draft is ignored.
+type CreateOpts struct {
+	Title     string
+	Body      string
+	Head      string
+	Base      string
+	Draft     bool
+	Labels    []string
+	Reviewers []string // usernames
+}
+
+// Forge is a code host that wiz can push branches to and open, inspect and
+// merge pull requests on.
+type Forge interface {
+	// Kind returns the forge kind, e.g. "github".
+	Kind() string
+	// Push pushes branch from the checkout at dir and sets its upstream.
+	Push(ctx context.Context, dir, branch string) error
+	CreatePR(ctx context.Context, opts CreateOpts) (*PR, error)
+	// UpdatePR edits an existing PR's title and body and adds labels and
+	// reviewers.
+	UpdatePR(ctx context.Context, number int, opts CreateOpts) (*PR, error)
+	MergePR(ctx context.Context, number int, method MergeMethod) error
+	GetPR(ctx context.Context, number int) (*PR, error)
+	// FindPR returns the open PR for head, or nil if there is none.
+	FindPR(ctx context.Context, head string) (*PR, error)
+	// Checks returns the CI checks reported for the PR's head commit.
+	Checks(ctx context.Context, pr *PR) ([]Check, error)
+	GetIssue(ctx context.Context, number int) (*Issue, error)
+}
+
+// HeadRef returns the ref under which a forge of the given kind publishes
+// PR number's head commit, fork PRs included, or "" if it doesn't.
+func HeadRef(kind string, number int) string {
+	switch kind {
+	case GitHub, Gitea:
+		return fmt.Sprintf("refs/pull/%d/head", number)
+	case GitLab:
+		return fmt.Sprintf("refs/merge-requests/%d/head", number)
+	}
+	return ""
+}
+
+// ParseNumber parses a PR or issue reference: "12", "#12" or a web URL
+// such as https://github.com/acme/widgets/pull/12.
+func ParseNumber(ref string) (int, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(ref), "#")
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return n, nil
+	}
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for i := 1; i < len(segs); i++ {
+			switch segs[i-1] {
+			case "pull", "pulls", "merge_requests", "pull-requests", "issues":
+				if n, err := strconv.Atoi(segs[i]); err == nil && n > 0 {
+					return n, nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("%q is not a PR or issue number or URL", ref)
+}
+
+// Remote is a parsed git remote URL.
+type Remote struct {
+	Name  string // git remote name, e.g. origin
+	Host  string
+	Owner string // owner, organization or (GitLab) group path
+	Repo  string
+}
+
+// Path returns "owner/repo".
+func (r *Remote) Path() string { return r.Owner + "/" + r.Repo }
+
+// ParseRemote parses an https, ssh or scp-style (git@host:owner/repo) URL.
+func ParseRemote(name, rawURL string) (*Remote, error) {
+	s := strings.TrimSpace(rawURL)
+	var host, path string
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at, colon := strings.Index(s, "@"), strings.Index(s, ":"); colon > 0 && at < colon {
+		host, path = s[at+1:colon], s[colon+1:]
+	} else {
+		return nil, fmt.Errorf("unrecognized remote URL %q", rawURL)
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	i := strings.LastIndex(path, "/")
+	if host == "" || i <= 0 || i == len(path)-1 {
+		return nil, fmt.Errorf("remote URL %q has no owner/repo path", rawURL)
+	}
+	return &Remote{Name: name, Host: host, Owner: path[:i], Repo: path[i+1:]}, nil
+}
+
+// DetectKind guesses the forge kind from a remote's host name.
+func DetectKind(host string) string {
+	h := strings.ToLower(host)
+	switch {
+	case h == "github.com" || strings.Contains(h, "github"):
+		return GitHub
+	case h == "gitlab.com" || strings.Contains(h, "gitlab"):
+		return GitLab
+	case h == "bitbucket.org":
+		return Bitbucket
+	case h == "codeberg.org" || strings.Contains(h, "gitea") || strings.Contains(h, "forgejo"):
+		return Gitea
+	}
+	return ""
+}
+
+// Options configure New. Empty fields are detected or defaulted.
+type Options struct {
+	Kind   string // github, gitlab, gitea or bitbucket
+	APIURL string // API base URL, for self-hosted forges
+	Token  string
+	Client *http.Client
+}
+
+// New returns the forge hosting remote.
+func New(remote *Remote, opts Options) (Forge, error) {
+	kind := opts.Kind
+	if kind == "" {
+		kind = DetectKind(remote.Host)
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("can't tell which forge hosts %s; set \"forge\": {\"type\": ...} in the wiz config", remote.Host)
+	}
+	if _, ok := tokenEnv[kind]; !ok {
+		return nil, fmt.Errorf("unknown forge type %q (want github, gitlab, gitea or bitbucket)", kind)
+	}
+	api := strings.TrimSuffix(opts.APIURL, "/")
+	if api == "" {
+		api = defaultAPIURL(kind, remote.Host)
+	}
+	token := opts.Token
+	if token == "" {
+		token = Token(kind)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no %s token; set %s", kind, tokenEnv[kind][0])
+	}
+	c := &client{base: api, token: token, http: opts.Client}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: 30 * time.Second}
+	}
+	p := pusher{remote: remote.Name}
+
+	switch kind {
+	case GitHub:
+		return &gitHub{pusher: p, c: c, remote: remote}, nil
+	case GitLab:
+		return &gitLab{pusher: p, c: c, remote: remote}, nil
+	case Gitea:
+		c.auth = giteaAuth
+		return &gitea{pusher: p, c: c, remote: remote}, nil
+	default: // Bitbucket
+		c.auth = bitbucketAuth
+		return &bitbucket{pusher: p, c: c, remote: remote}, nil
+	}
+}
+
+func defaultAPIURL(kind, host string) string {
+	switch kind {
+	case GitHub:
+		if host == "github.com" {
+			return "https://api.github.com"
+		}
+		return "https://" + host + "/api/v3"
+	case GitLab:
+		return "https://" + host + "/api/v4"
+	case Gitea:
+		return "https://" + host + "/api/v1"
+	case Bitbucket:
+		return "https://api.bitbucket.org/2.0"
+	}
+	return ""
+}
+
+// tokenEnv lists the environment variables checked for each kind's token.
+var tokenEnv = map[string][]string{
+	GitHub:    {"GITHUB_TOKEN", "GH_TOKEN"},
+	GitLab:    {"GITLAB_TOKEN"},
+	Gitea:     {"GITEA_TOKEN", "FORGEJO_TOKEN"},
+	Bitbucket: {"BITBUCKET_TOKEN"},
+}
+
+// Token returns the API token for kind from the environment. For GitHub it
+// falls back to the token the gh CLI is logged in with.
+func Token(kind string) string {
+	for _, env := range tokenEnv[kind] {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	if kind == GitHub {
+		if out, err := exec.Command("gh", "auth", "token").Output(); err == nil {
+			return strings.TrimSpace(string(out))
+		}
+	}
+	return ""
+}
+
+// pusher pushes branches with git, which uses the user's own credentials.
+type pusher struct {
+	remote string
+}
+
+func (p pusher) Push(ctx context.Context, dir, branch string) error {
+	cmd := exec.CommandContext(ctx, "git", "push", "-u", p.remote, branch)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git push: %w\n%s", err, out)
+	}
+	return nil
+}
+
+// APIError is a non-2xx response from a forge API.
+type APIError struct {
+	Method string
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.URL, e.Status, http.StatusText(e.Status), e.Body)
+}
+
+// client is a minimal JSON REST client.
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+	// auth sets the authorization header; default is a bearer token.
+	auth func(req *http.Request, token string)
+}
+
+func (c *client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		c.auth(req, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{Method: method, URL: req.URL.String(), Status: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s %s: decode response: %w", method, req.URL, err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package forge_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/forge"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		url               string
+		host, owner, repo string
+	}{
+		{"git@github.com:acme/widgets.git", "github.com", "acme", "widgets"},
+		{"https://github.com/acme/widgets", "github.com", "acme", "widgets"},
+		{"https://user@gitlab.com/group/sub/widgets.git", "gitlab.com", "group/sub", "widgets"},
+		{"ssh://git@git.example.com:2222/team/widgets.git", "git.example.com", "team", "widgets"},
+		{"git@bitbucket.org:workspace/widgets.git", "bitbucket.org", "workspace", "widgets"},
+	}
+	for _, tt := range tests {
+		r, err := forge.ParseRemote("origin", tt.url)
+		if err != nil {
+			t.Errorf("ParseRemote(%q): %v", tt.url, err)
+			continue
+		}
+		if r.Host != tt.host || r.Owner != tt.owner || r.Repo != tt.repo {
+			t.Errorf("ParseRemote(%q) = %+v", tt.url, r)
+		}
+	}
+	for _, bad := range []string{"/tmp/local/repo", "https://github.com/only-owner", ""} {
+		if _, err := forge.ParseRemote("origin", bad); err == nil {
+			t.Errorf("ParseRemote(%q): expected error", bad)
+		}
+	}
+}
+
+func TestDetectKind(t *testing.T) {
+	for host, want := range map[string]string{
+		"github.com":          forge.GitHub,
+		"github.example.com":  forge.GitHub,
+		"gitlab.com":          forge.GitLab,
+		"gitlab.internal":     forge.GitLab,
+		"codeberg.org":        forge.Gitea,
+		"forgejo.example.org": forge.Gitea,
+		"bitbucket.org":       forge.Bitbucket,
+		"git.example.com":     "",
+	} {
+		if got := forge.DetectKind(host); got != want {
+			t.Errorf("DetectKind(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	for ref, want := range map[string]int{
+		"12": 12,
+		"#7": 7,
+		"https://github.com/acme/widgets/pull/12":                   12,
+		"https://github.com/acme/widgets/pull/12/files":             12,
+		"https://gitlab.com/group/sub/widgets/-/merge_requests/3":   3,
+		"https://bitbucket.org/ws/widgets/pull-requests/4/overview": 4,
+		"https://codeberg.org/team/widgets/issues/9":                9,
+	} {
+		if got, err := forge.ParseNumber(ref); err != nil || got != want {
+			t.Errorf("ParseNumber(%q) = %d, %v; want %d", ref, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "abc", "-1", "https://github.com/acme/widgets"} {
+		if _, err := forge.ParseNumber(bad); err == nil {
+			t.Errorf("ParseNumber(%q): expected error", bad)
+		}
+	}
+	if got := forge.HeadRef(forge.GitLab, 3); got != "refs/merge-requests/3/head" {
+		t.Errorf("HeadRef(gitlab) = %q", got)
+	}
+	if got := forge.HeadRef(forge.Bitbucket, 3); got != "" {
+		t.Errorf("HeadRef(bitbucket) = %q", got)
+	}
+}
+
+func TestChecksState(t *testing.T) {
+	pass := forge.Check{Name: "build", Status: forge.CheckSuccess}
+	wait := forge.Check{Name: "test", Status: forge.CheckPending}
+	fail := forge.Check{Name: "lint", Status: forge.CheckFailure}
+	tests := []struct {
+		checks         []forge.Check
+		state, summary string
+	}{
+		{nil, "", "-"},
+		{[]forge.Check{pass}, forge.CheckSuccess, "1/1 passed"},
+		{[]forge.Check{pass, wait}, forge.CheckPending, "1/2 passed, 1 pending"},
+		{[]forge.Check{wait, fail, pass}, forge.CheckFailure, "1/3 passed, 1 pending"},
+	}
+	for _, tt := range tests {
+		if got := forge.ChecksState(tt.checks); got != tt.state {
+			t.Errorf("ChecksState(%v) = %q, want %q", tt.checks, got, tt.state)
+		}
+		if got := forge.ChecksSummary(tt.checks); got != tt.summary {
+			t.Errorf("ChecksSummary(%v) = %q, want %q", tt.checks, got, tt.summary)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	remote := &forge.Remote{Name: "origin", Host: "git.example.com", Owner: "team", Repo: "widgets"}
+	if _, err := forge.New(remote, forge.Options{Token: "t"}); err == nil {
+		t.Error("expected error for an unknown host")
+	}
+	if _, err := forge.New(remote, forge.Options{Kind: "sourcehut", Token: "t"}); err == nil {
+		t.Error("expected error for an unknown forge type")
+	}
+	t.Setenv("GITLAB_TOKEN", "")
+	if _, err := forge.New(remote, forge.Options{Kind: forge.GitLab}); err == nil {
+		t.Error("expected error without a token")
+	}
+}
+
+// fakeAPI is an httptest stand-in for a forge API. Routes are keyed by
+// "METHOD /escaped/path" and anything else is a 404. Request bodies (or,
+// for requests without one, the query string) are recorded in Requests.
+type fakeAPI struct {
+	*httptest.Server
+	Requests map[string]map[string]any
+	Auth     []string
+}
+
+func newFakeAPI(t *testing.T, routes map[string]any) *fakeAPI {
+	t.Helper()
+	f := &fakeAPI{Requests: map[string]map[string]any{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.EscapedPath()
+		f.Auth = append(f.Auth, r.Header.Get("Authorization"))
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			var body map[string]any
+			json.Unmarshal(data, &body)
+			f.Requests[key] = body
+		} else {
+			f.Requests[key] = map[string]any{"query": r.URL.RawQuery}
+		}
+		resp, ok := routes[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func newTestForge(t *testing.T, kind string, api *fakeAPI, owner string) forge.Forge {
+	t.Helper()
+	f, err := forge.New(&forge.Remote{Name: "origin", Host: "example.com", Owner: owner, Repo: "widgets"},
+		forge.Options{Kind: kind, APIURL: api.URL, Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
@@ -0,0 +1,242 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// gitea talks to the Gitea (and Forgejo) REST API.
+type gitea struct {
+	pusher
+	c      *client
+	remote *Remote
+}
+
+type giteaPull struct {
+	Number    int    `json:"number"`
+	HTMLURL   string `json:"html_url"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	Draft     bool   `json:"draft"`
+	Merged    bool   `json:"merged"`
+	Mergeable bool   `json:"mergeable"`
+	Head      struct {
+		Ref  string  `json:"ref"`
+		SHA  string  `json:"sha"`
+		Repo *ghRepo `json:"repo"`
+	} `json:"head"`
+	Base struct {
+		Ref  string  `json:"ref"`
+		Repo *ghRepo `json:"repo"`
+	} `json:"base"`
+}
+
+// wipPrefix marks a draft PR on Gitea versions without a draft flag.
+const wipPrefix = "WIP: "
+
+func (p *giteaPull) pr() *PR {
+	pr := &PR{
+		Number:    p.Number,
+		URL:       p.HTMLURL,
+		Title:     p.Title,
+		State:     p.State,
+		Draft:     p.Draft || strings.HasPrefix(p.Title, wipPrefix),
+		Head:      p.Head.Ref,
+		Base:      p.Base.Ref,
+		Fork:      isFork(p.Head.Repo, p.Base.Repo),
+		HeadSHA:   p.Head.SHA,
+		Mergeable: Conflicting,
+	}
+	switch {
+	case p.Merged:
+		pr.State, pr.Mergeable = StateMerged, ""
+	case p.Mergeable:
+		pr.Mergeable = Mergeable
+	}
+	return pr
+}
+
+func (g *gitea) Kind() string { return Gitea }
+
+func (g *gitea) repoPath(format string, args ...any) string {
+	return fmt.Sprintf("/repos/%s/%s", g.remote.Owner, g.remote.Repo) + fmt.Sprintf(format, args...)
+}
+
+func (g *gitea) CreatePR(ctx context.Context, opts CreateOpts) (*PR, error) {
+	title := opts.Title
+	if opts.Draft && !strings.HasPrefix(title, wipPrefix) {
+		title = wipPrefix + title
+	}
+	in := map[string]any{
+		"title": title,
+		"body":  opts.Body,
+		"head":  opts.Head,
+		"base":  opts.Base,
+	}
+	if len(opts.Labels) > 0 {
+		ids, err := g.labelIDs(ctx, opts.Labels)
+		if err != nil {
+			return nil, err
+		}
+		in["labels"] = ids
+	}
+	var p giteaPull
+	if err := g.c.do(ctx, "POST", g.repoPath("/pulls"), in, &p); err != nil {
+		return nil, err
+	}
+	return p.pr(), g.requestReviewers(ctx, p.Number, opts.Reviewers)
+}
+
+func (g *gitea) UpdatePR(ctx context.Context, number int, opts CreateOpts) (*PR, error) {
+	edit := map[string]any{}
+	if opts.Title != "" {
+		edit["title"] = opts.Title
+	}
+	if opts.Body != "" {
+		edit["body"] = opts.Body
+	}
+	if len(edit) > 0 {
+		if err := g.c.do(ctx, "PATCH", g.repoPath("/pulls/%d", number), edit, nil); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.Labels) > 0 {
+		ids, err := g.labelIDs(ctx, opts.Labels)
+		if err != nil {
+			return nil, err
+		}
+		// PRs share their number and labels with the underlying issue.
+		if err := g.c.do(ctx, "POST", g.repoPath("/issues/%d/labels", number), map[string]any{"labels": ids}, nil); err != nil {
+			return nil, fmt.Errorf("add labels: %w", err)
+		}
+	}
+	if err := g.requestReviewers(ctx, number, opts.Reviewers); err != nil {
+		return nil, err
+	}
+	return g.GetPR(ctx, number)
+}
+
+func (g *gitea) requestReviewers(ctx context.Context, number int, reviewers []string) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+	if err := g.c.do(ctx, "POST", g.repoPath("/pulls/%d/requested_reviewers", number), map[string]any{"reviewers": reviewers}, nil); err != nil {
+		return fmt.Errorf("request reviewers: %w", err)
+	}
+	return nil
+}
+
+// labelIDs resolves label names to the ids Gitea expects.
+func (g *gitea) labelIDs(ctx context.Context, names []string) ([]int, error) {
+	var labels []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := g.c.do(ctx, "GET", g.repoPath("/labels"), nil, &labels); err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, l := range labels {
+			if strings.EqualFold(l.Name, name) {
+				ids = append(ids, l.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("label %q does not exist in %s", name, g.remote.Path())
+		}
+	}
+	return ids, nil
+}
+
+func (g *gitea) MergePR(ctx context.Context, number int, method MergeMethod) error {
+	return g.c.do(ctx, "POST", g.repoPath("/pulls/%d/merge", number), map[string]any{"Do": string(method)}, nil)
+}
+
+func (g *gitea) GetPR(ctx context.Context, number int) (*PR, error) {
+	var p giteaPull
+	if err := g.c.do(ctx, "GET", g.repoPath("/pulls/%d", number), nil, &p); err != nil {
+		return nil, err
+	}
+	pr := p.pr()
+
+	var reviews []struct {
+		State string `json:"state"`
+	}
+	if err := g.c.do(ctx, "GET", g.repoPath("/pulls/%d/reviews", number), nil, &reviews); err != nil {
+		return nil, err
+	}
+	states := make([]string, len(reviews))
+	for i, r := range reviews {
+		states[i] = r.State
+	}
+	pr.Review = reviewState(states, "APPROVED", "REQUEST_CHANGES")
+	return pr, nil
+}
+
+func (g *gitea) FindPR(ctx context.Context, head string) (*PR, error) {
+	// The list endpoint can't filter by head branch, so page through it.
+	for page := 1; page <= 20; page++ {
+		var pulls []giteaPull
+		if err := g.c.do(ctx, "GET", g.repoPath("/pulls?state=open&limit=50&page=%d", page), nil, &pulls); err != nil {
+			return nil, err
+		}
+		for i := range pulls {
+			if pulls[i].Head.Ref == head {
+				return pulls[i].pr(), nil
+			}
+		}
+		if len(pulls) < 50 {
+			break
+		}
+	}
+	return nil, nil
+}
+
+func (g *gitea) Checks(ctx context.Context, pr *PR) ([]Check, error) {
+	var statuses []struct {
+		Context   string `json:"context"`
+		Status    string `json:"status"`
+		State     string `json:"state"` // older versions
+		TargetURL string `json:"target_url"`
+	}
+	if err := g.c.do(ctx, "GET", g.repoPath("/commits/%s/statuses", pr.HeadSHA), nil, &statuses); err != nil {
+		return nil, err
+	}
+	checks := make([]Check, 0, len(statuses))
+	for _, s := range statuses {
+		status := s.Status
+		if status == "" {
+			status = s.State
+		}
+		checks = append(checks, Check{
+			Name:   s.Context,
+			Status: checkStatus(status, []string{"success", "warning"}, []string{"pending"}),
+			URL:    s.TargetURL,
+		})
+	}
+	return checks, nil
+}
+
+func (g *gitea) GetIssue(ctx context.Context, number int) (*Issue, error) {
+	var i struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		State   string `json:"state"`
+	}
+	if err := g.c.do(ctx, "GET", g.repoPath("/issues/%d", number), nil, &i); err != nil {
+		return nil, err
+	}
+	return &Issue{Number: i.Number, URL: i.HTMLURL, Title: i.Title, Body: i.Body, State: i.State}, nil
+}
+
+func giteaAuth(req *http.Request, token string) {
+	req.Header.Set("Authorization", "token "+token)
+}
@@ -0,0 +1,77 @@
+package forge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/forge"
+)
+
+func TestGitea(t *testing.T) {
+	ctx := context.Background()
+	pull := map[string]any{
+		"number": 12, "html_url": "https://codeberg.org/acme/widgets/pulls/12", "title": "WIP: Add auth",
+		"state": "open", "mergeable": true,
+		"head": map[string]any{"ref": "feat-auth", "sha": "fed789"},
+		"base": map[string]any{"ref": "main"},
+	}
+	other := map[string]any{"number": 11, "state": "open", "head": map[string]any{"ref": "other"}}
+	api := newFakeAPI(t, map[string]any{
+		"GET /repos/acme/widgets/labels": []any{
+			map[string]any{"id": 5, "name": "wiz"},
+			map[string]any{"id": 6, "name": "bug"},
+		},
+		"POST /repos/acme/widgets/pulls":                        pull,
+		"POST /repos/acme/widgets/pulls/12/requested_reviewers": []any{},
+		"GET /repos/acme/widgets/pulls":                         []any{other, pull},
+		"GET /repos/acme/widgets/pulls/12":                      pull,
+		"GET /repos/acme/widgets/pulls/12/reviews": []any{
+			map[string]any{"state": "APPROVED"},
+			map[string]any{"state": "REQUEST_CHANGES"},
+		},
+		"POST /repos/acme/widgets/pulls/12/merge": nil,
+		"GET /repos/acme/widgets/commits/fed789/statuses": []any{
+			map[string]any{"context": "ci/woodpecker", "status": "pending"},
+			map[string]any{"context": "ci/old", "state": "success"},
+		},
+	})
+	f := newTestForge(t, forge.Gitea, api, "acme")
+
+	pr, err := f.CreatePR(ctx, forge.CreateOpts{Title: "Add auth", Head: "feat-auth", Base: "main", Draft: true,
+		Labels: []string{"WIZ"}, Reviewers: []string{"bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Number != 12 || !pr.Draft || pr.Mergeable != forge.Mergeable {
+		t.Errorf("CreatePR = %+v", pr)
+	}
+	req := api.Requests["POST /repos/acme/widgets/pulls"]
+	if labels, _ := req["labels"].([]any); req["title"] != "WIP: Add auth" || len(labels) != 1 || labels[0] != float64(5) {
+		t.Errorf("create request = %v", req)
+	}
+	if api.Auth[0] != "token secret" {
+		t.Errorf("Authorization = %q", api.Auth[0])
+	}
+	if _, err := f.CreatePR(ctx, forge.CreateOpts{Title: "x", Head: "x", Base: "main", Labels: []string{"nope"}}); err == nil {
+		t.Error("expected error for an unknown label")
+	}
+
+	if found, err := f.FindPR(ctx, "feat-auth"); err != nil || found == nil || found.Number != 12 {
+		t.Fatalf("FindPR = %+v, %v", found, err)
+	}
+	got, err := f.GetPR(ctx, 12)
+	if err != nil || got.Review != forge.ReviewChangesRequested {
+		t.Fatalf("GetPR = %+v, %v", got, err)
+	}
+	checks, err := f.Checks(ctx, got)
+	if err != nil || len(checks) != 2 || checks[0].Status != forge.CheckPending || checks[1].Status != forge.CheckSuccess {
+		t.Errorf("Checks = %+v, %v", checks, err)
+	}
+
+	if err := f.MergePR(ctx, 12, forge.MergeRebase); err != nil {
+		t.Fatal(err)
+	}
+	if do := api.Requests["POST /repos/acme/widgets/pulls/12/merge"]["Do"]; do != "rebase" {
+		t.Errorf("Do = %v", do)
+	}
+}
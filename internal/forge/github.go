@@ -0,0 +1,255 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// gitHub talks to the GitHub REST API (github.com or GitHub Enterprise).
+type gitHub struct {
+	pusher
+	c      *client
+	remote *Remote
+}
+
+type ghPull struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	Draft   bool   `json:"draft"`
+	Merged  bool   `json:"merged"`
+	// MergedAt is set in list responses, which omit Merged.
+	MergedAt  *string `json:"merged_at"`
+	Mergeable *bool   `json:"mergeable"`
+	Head      struct {
+		Ref  string  `json:"ref"`
+		SHA  string  `json:"sha"`
+		Repo *ghRepo `json:"repo"`
+	} `json:"head"`
+	Base struct {
+		Ref  string  `json:"ref"`
+		Repo *ghRepo `json:"repo"`
+	} `json:"base"`
+}
+
+// ghRepo is the repository of a PR's head or base; a fork's head repository
+// is null once the fork is deleted. Gitea uses the same shape.
+type ghRepo struct {
+	FullName string `json:"full_name"`
+}
+
+// isFork reports whether a PR's head lives in another repository than its base.
+func isFork(head, base *ghRepo) bool {
+	return base != nil && (head == nil || !strings.EqualFold(head.FullName, base.FullName))
+}
+
+func (p *ghPull) pr() *PR {
+	pr := &PR{
+		Number:  p.Number,
+		URL:     p.HTMLURL,
+		Title:   p.Title,
+		State:   p.State,
+		Draft:   p.Draft,
+		Head:    p.Head.Ref,
+		Base:    p.Base.Ref,
+		Fork:    isFork(p.Head.Repo, p.Base.Repo),
+		HeadSHA: p.Head.SHA,
+	}
+	switch {
+	case p.Merged || p.MergedAt != nil:
+		pr.State = StateMerged
+	case p.Mergeable == nil:
+		pr.Mergeable = Unknown
+	case *p.Mergeable:
+		pr.Mergeable = Mergeable
+	default:
+		pr.Mergeable = Conflicting
+	}
+	return pr
+}
+
+func (g *gitHub) Kind() string { return GitHub }
+
+func (g *gitHub) repoPath(format string, args ...any) string {
+	return fmt.Sprintf("/repos/%s/%s", g.remote.Owner, g.remote.Repo) + fmt.Sprintf(format, args...)
+}
+
+func (g *gitHub) CreatePR(ctx context.Context, opts CreateOpts) (*PR, error) {
+	var p ghPull
+	err := g.c.do(ctx, "POST", g.repoPath("/pulls"), map[string]any{
+		"title": opts.Title,
+		"body":  opts.Body,
+		"head":  opts.Head,
+		"base":  opts.Base,
+		"draft": opts.Draft,
+	}, &p)
+	if err != nil {
+		return nil, err
+	}
+	return p.pr(), g.addLabelsAndReviewers(ctx, p.Number, opts)
+}
+
+func (g *gitHub) UpdatePR(ctx context.Context, number int, opts CreateOpts) (*PR, error) {
+	edit := map[string]any{}
+	if opts.Title != "" {
+		edit["title"] = opts.Title
+	}
+	if opts.Body != "" {
+		edit["body"] = opts.Body
+	}
+	if len(edit) > 0 {
+		if err := g.c.do(ctx, "PATCH", g.repoPath("/pulls/%d", number), edit, nil); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.addLabelsAndReviewers(ctx, number, opts); err != nil {
+		return nil, err
+	}
+	return g.GetPR(ctx, number)
+}
+
+func (g *gitHub) addLabelsAndReviewers(ctx context.Context, number int, opts CreateOpts) error {
+	if len(opts.Labels) > 0 {
+		if err := g.c.do(ctx, "POST", g.repoPath("/issues/%d/labels", number), map[string]any{"labels": opts.Labels}, nil); err != nil {
+			return fmt.Errorf("add labels: %w", err)
+		}
+	}
+	if len(opts.Reviewers) > 0 {
+		if err := g.c.do(ctx, "POST", g.repoPath("/pulls/%d/requested_reviewers", number), map[string]any{"reviewers": opts.Reviewers}, nil); err != nil {
+			return fmt.Errorf("request reviewers: %w", err)
+		}
+	}
+	return nil
+}
+
+func (g *gitHub) MergePR(ctx context.Context, number int, method MergeMethod) error {
+	return g.c.do(ctx, "PUT", g.repoPath("/pulls/%d/merge", number), map[string]any{"merge_method": string(method)}, nil)
+}
+
+func (g *gitHub) GetPR(ctx context.Context, number int) (*PR, error) {
+	var p ghPull
+	if err := g.c.do(ctx, "GET", g.repoPath("/pulls/%d", number), nil, &p); err != nil {
+		return nil, err
+	}
+	pr := p.pr()
+
+	var reviews []struct {
+		State string `json:"state"`
+		User  struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := g.c.do(ctx, "GET", g.repoPath("/pulls/%d/reviews", number), nil, &reviews); err != nil {
+		return nil, err
+	}
+	// Each reviewer's latest approving or blocking review counts.
+	latest := map[string]string{}
+	for _, r := range reviews {
+		if r.State == "APPROVED" || r.State == "CHANGES_REQUESTED" || r.State == "DISMISSED" {
+			latest[r.User.Login] = r.State
+		}
+	}
+	states := make([]string, 0, len(latest))
+	for _, s := range latest {
+		states = append(states, s)
+	}
+	pr.Review = reviewState(states, "APPROVED", "CHANGES_REQUESTED")
+	return pr, nil
+}
+
+func (g *gitHub) FindPR(ctx context.Context, head string) (*PR, error) {
+	var pulls []ghPull
+	q := url.Values{"state": {"open"}, "head": {g.remote.Owner + ":" + head}}
+	if err := g.c.do(ctx, "GET", g.repoPath("/pulls?%s", q.Encode()), nil, &pulls); err != nil {
+		return nil, err
+	}
+	if len(pulls) == 0 {
+		return nil, nil
+	}
+	return pulls[0].pr(), nil
+}
+
+func (g *gitHub) Checks(ctx context.Context, pr *PR) ([]Check, error) {
+	var runs struct {
+		CheckRuns []struct {
+			Name       string `json:"name"`
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+			HTMLURL    string `json:"html_url"`
+		} `json:"check_runs"`
+	}
+	if err := g.c.do(ctx, "GET", g.repoPath("/commits/%s/check-runs", pr.HeadSHA), nil, &runs); err != nil {
+		return nil, err
+	}
+	var checks []Check
+	for _, r := range runs.CheckRuns {
+		status := CheckPending
+		if r.Status == "completed" {
+			status = checkStatus(r.Conclusion, []string{"success", "neutral", "skipped"}, nil)
+		}
+		checks = append(checks, Check{Name: r.Name, Status: status, URL: r.HTMLURL})
+	}
+
+	// Commit statuses from older integrations are reported separately.
+	var combined struct {
+		Statuses []struct {
+			Context   string `json:"context"`
+			State     string `json:"state"`
+			TargetURL string `json:"target_url"`
+		} `json:"statuses"`
+	}
+	if err := g.c.do(ctx, "GET", g.repoPath("/commits/%s/status", pr.HeadSHA), nil, &combined); err != nil {
+		return nil, err
+	}
+	for _, s := range combined.Statuses {
+		checks = append(checks, Check{Name: s.Context, Status: checkStatus(s.State, []string{"success"}, []string{"pending"}), URL: s.TargetURL})
+	}
+	return checks, nil
+}
+
+func (g *gitHub) GetIssue(ctx context.Context, number int) (*Issue, error) {
+	var i struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		State   string `json:"state"`
+	}
+	if err := g.c.do(ctx, "GET", g.repoPath("/issues/%d", number), nil, &i); err != nil {
+		return nil, err
+	}
+	return &Issue{Number: i.Number, URL: i.HTMLURL, Title: i.Title, Body: i.Body, State: i.State}, nil
+}
+
+// reviewState summarizes individual review states: any blocking review
+// wins, then any approval; otherwise the review is pending.
+func reviewState(states []string, approved, blocking string) string {
+	result := ReviewPending
+	for _, s := range states {
+		switch s {
+		case blocking:
+			return ReviewChangesRequested
+		case approved:
+			result = ReviewApproved
+		}
+	}
+	return result
+}
+
+// checkStatus maps a forge-specific status to pending, success or failure.
+func checkStatus(s string, success, pending []string) string {
+	for _, v := range success {
+		if s == v {
+			return CheckSuccess
+		}
+	}
+	for _, v := range pending {
+		if s == v {
+			return CheckPending
+		}
+	}
+	return CheckFailure
+}
@@ -0,0 +1,106 @@
+package forge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/forge"
+)
+
+func TestGitHub(t *testing.T) {
+	ctx := context.Background()
+	pull := map[string]any{
+		"number": 7, "html_url": "https://github.com/acme/widgets/pull/7", "title": "Add auth",
+		"state": "open", "draft": true, "mergeable": true,
+		"head": map[string]any{"ref": "feat-auth", "sha": "abc123", "repo": map[string]any{"full_name": "alice/widgets"}},
+		"base": map[string]any{"ref": "main", "repo": map[string]any{"full_name": "acme/widgets"}},
+	}
+	api := newFakeAPI(t, map[string]any{
+		"POST /repos/acme/widgets/pulls":                       pull,
+		"POST /repos/acme/widgets/issues/7/labels":             []any{},
+		"POST /repos/acme/widgets/pulls/7/requested_reviewers": map[string]any{},
+		"GET /repos/acme/widgets/pulls":                        []any{pull},
+		"GET /repos/acme/widgets/pulls/7":                      pull,
+		"GET /repos/acme/widgets/pulls/7/reviews": []any{
+			map[string]any{"state": "CHANGES_REQUESTED", "user": map[string]any{"login": "bob"}},
+			map[string]any{"state": "APPROVED", "user": map[string]any{"login": "bob"}},
+			map[string]any{"state": "COMMENTED", "user": map[string]any{"login": "eve"}},
+		},
+		"PATCH /repos/acme/widgets/pulls/7":     pull,
+		"PUT /repos/acme/widgets/pulls/7/merge": map[string]any{"merged": true},
+		"GET /repos/acme/widgets/commits/abc123/check-runs": map[string]any{"check_runs": []any{
+			map[string]any{"name": "build", "status": "completed", "conclusion": "success"},
+			map[string]any{"name": "lint", "status": "in_progress"},
+		}},
+		"GET /repos/acme/widgets/commits/abc123/status": map[string]any{"statuses": []any{
+			map[string]any{"context": "ci/legacy", "state": "failure", "target_url": "https://ci/1"},
+		}},
+	})
+	f := newTestForge(t, forge.GitHub, api, "acme")
+
+	pr, err := f.CreatePR(ctx, forge.CreateOpts{Title: "Add auth", Head: "feat-auth", Base: "main", Draft: true,
+		Labels: []string{"wiz"}, Reviewers: []string{"bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Number != 7 || pr.URL == "" || !pr.Draft || pr.Mergeable != forge.Mergeable {
+		t.Errorf("CreatePR = %+v", pr)
+	}
+	if req := api.Requests["POST /repos/acme/widgets/pulls"]; req["head"] != "feat-auth" || req["draft"] != true {
+		t.Errorf("create request = %v", req)
+	}
+	if api.Auth[0] != "Bearer secret" {
+		t.Errorf("Authorization = %q", api.Auth[0])
+	}
+
+	found, err := f.FindPR(ctx, "feat-auth")
+	if err != nil || found == nil || found.Number != 7 {
+		t.Fatalf("FindPR = %+v, %v", found, err)
+	}
+	if q := api.Requests["GET /repos/acme/widgets/pulls"]["query"]; q != "head=acme%3Afeat-auth&state=open" {
+		t.Errorf("FindPR query = %v", q)
+	}
+
+	got, err := f.GetPR(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Review != forge.ReviewApproved || got.HeadSHA != "abc123" || !got.Fork {
+		t.Errorf("GetPR = %+v", got)
+	}
+
+	checks, err := f.Checks(ctx, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{forge.CheckSuccess, forge.CheckPending, forge.CheckFailure}
+	if len(checks) != len(want) {
+		t.Fatalf("Checks = %+v", checks)
+	}
+	for i, c := range checks {
+		if c.Status != want[i] {
+			t.Errorf("check %s = %s, want %s", c.Name, c.Status, want[i])
+		}
+	}
+
+	api.Requests = map[string]map[string]any{}
+	if _, err := f.UpdatePR(ctx, 7, forge.CreateOpts{Title: "Add auth v2", Labels: []string{"ready"}}); err != nil {
+		t.Fatal(err)
+	}
+	if req := api.Requests["PATCH /repos/acme/widgets/pulls/7"]; req["title"] != "Add auth v2" || req["body"] != nil {
+		t.Errorf("update request = %v", req)
+	}
+	if _, ok := api.Requests["POST /repos/acme/widgets/pulls/7/requested_reviewers"]; ok {
+		t.Error("UpdatePR requested reviewers without any given")
+	}
+	if labels := api.Requests["POST /repos/acme/widgets/issues/7/labels"]["labels"]; labels == nil {
+		t.Error("UpdatePR did not add labels")
+	}
+
+	if err := f.MergePR(ctx, 7, forge.MergeSquash); err != nil {
+		t.Fatal(err)
+	}
+	if m := api.Requests["PUT /repos/acme/widgets/pulls/7/merge"]["merge_method"]; m != "squash" {
+		t.Errorf("merge_method = %v", m)
+	}
+}
@@ -0,0 +1,218 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// gitLab talks to the GitLab REST API, where pull requests are merge
+// requests identified by their project-scoped iid.
+type gitLab struct {
+	pusher
+	c      *client
+	remote *Remote
+}
+
+type glMR struct {
+	IID          int    `json:"iid"`
+	WebURL       string `json:"web_url"`
+	Title        string `json:"title"`
+	State        string `json:"state"` // opened, closed, locked, merged
+	Draft        bool   `json:"draft"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	// The source project differs from the target for MRs from forks.
+	SourceProjectID int    `json:"source_project_id"`
+	TargetProjectID int    `json:"target_project_id"`
+	SHA             string `json:"sha"`
+	HasConflicts    bool   `json:"has_conflicts"`
+	MergeStatus     string `json:"detailed_merge_status"`
+}
+
+func (m *glMR) pr() *PR {
+	pr := &PR{
+		Number:    m.IID,
+		URL:       m.WebURL,
+		Title:     m.Title,
+		State:     m.State,
+		Draft:     m.Draft,
+		Head:      m.SourceBranch,
+		Base:      m.TargetBranch,
+		Fork:      m.SourceProjectID != 0 && m.TargetProjectID != 0 && m.SourceProjectID != m.TargetProjectID,
+		HeadSHA:   m.SHA,
+		Mergeable: Unknown,
+	}
+	switch m.State {
+	case "opened", "locked":
+		pr.State = StateOpen
+	case "merged":
+		pr.State = StateMerged
+		pr.Mergeable = ""
+	}
+	switch {
+	case pr.State != StateOpen:
+	case m.HasConflicts || m.MergeStatus == "conflict":
+		pr.Mergeable = Conflicting
+	case m.MergeStatus == "mergeable":
+		pr.Mergeable = Mergeable
+	}
+	return pr
+}
+
+func (g *gitLab) Kind() string { return GitLab }
+
+func (g *gitLab) projectPath(format string, args ...any) string {
+	return "/projects/" + url.PathEscape(g.remote.Path()) + fmt.Sprintf(format, args...)
+}
+
+func (g *gitLab) CreatePR(ctx context.Context, opts CreateOpts) (*PR, error) {
+	title := opts.Title
+	if opts.Draft && !strings.HasPrefix(title, "Draft:") {
+		title = "Draft: " + title
+	}
+	in := map[string]any{
+		"source_branch": opts.Head,
+		"target_branch": opts.Base,
+		"title":         title,
+		"description":   opts.Body,
+	}
+	if len(opts.Labels) > 0 {
+		in["labels"] = strings.Join(opts.Labels, ",")
+	}
+	if len(opts.Reviewers) > 0 {
+		ids, err := g.userIDs(ctx, opts.Reviewers)
+		if err != nil {
+			return nil, err
+		}
+		in["reviewer_ids"] = ids
+	}
+	var m glMR
+	if err := g.c.do(ctx, "POST", g.projectPath("/merge_requests"), in, &m); err != nil {
+		return nil, err
+	}
+	return m.pr(), nil
+}
+
+// UpdatePR edits a merge request. GitLab replaces the reviewer list.
+func (g *gitLab) UpdatePR(ctx context.Context, number int, opts CreateOpts) (*PR, error) {
+	in := map[string]any{}
+	if opts.Title != "" {
+		in["title"] = opts.Title
+	}
+	if opts.Body != "" {
+		in["description"] = opts.Body
+	}
+	if len(opts.Labels) > 0 {
+		in["add_labels"] = strings.Join(opts.Labels, ",")
+	}
+	if len(opts.Reviewers) > 0 {
+		ids, err := g.userIDs(ctx, opts.Reviewers)
+		if err != nil {
+			return nil, err
+		}
+		in["reviewer_ids"] = ids
+	}
+	if len(in) > 0 {
+		if err := g.c.do(ctx, "PUT", g.projectPath("/merge_requests/%d", number), in, nil); err != nil {
+			return nil, err
+		}
+	}
+	return g.GetPR(ctx, number)
+}
+
+// userIDs resolves usernames to the numeric ids GitLab expects.
+func (g *gitLab) userIDs(ctx context.Context, usernames []string) ([]int, error) {
+	ids := make([]int, 0, len(usernames))
+	for _, name := range usernames {
+		var users []struct {
+			ID int `json:"id"`
+		}
+		if err := g.c.do(ctx, "GET", "/users?username="+url.QueryEscape(name), nil, &users); err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("gitlab user %q not found", name)
+		}
+		ids = append(ids, users[0].ID)
+	}
+	return ids, nil
+}
+
+func (g *gitLab) MergePR(ctx context.Context, number int, method MergeMethod) error {
+	if method == MergeRebase {
+		return fmt.Errorf("gitlab: rebase merges are a project setting; merge with the default method or --squash")
+	}
+	return g.c.do(ctx, "PUT", g.projectPath("/merge_requests/%d/merge", number), map[string]any{"squash": method == MergeSquash}, nil)
+}
+
+func (g *gitLab) GetPR(ctx context.Context, number int) (*PR, error) {
+	var m glMR
+	if err := g.c.do(ctx, "GET", g.projectPath("/merge_requests/%d", number), nil, &m); err != nil {
+		return nil, err
+	}
+	pr := m.pr()
+
+	var approvals struct {
+		Approved bool `json:"approved"`
+	}
+	if err := g.c.do(ctx, "GET", g.projectPath("/merge_requests/%d/approvals", number), nil, &approvals); err != nil {
+		return nil, err
+	}
+	pr.Review = ReviewPending
+	if approvals.Approved {
+		pr.Review = ReviewApproved
+	}
+	return pr, nil
+}
+
+func (g *gitLab) FindPR(ctx context.Context, head string) (*PR, error) {
+	var mrs []glMR
+	q := url.Values{"state": {"opened"}, "source_branch": {head}}
+	if err := g.c.do(ctx, "GET", g.projectPath("/merge_requests?%s", q.Encode()), nil, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	return mrs[0].pr(), nil
+}
+
+func (g *gitLab) Checks(ctx context.Context, pr *PR) ([]Check, error) {
+	var statuses []struct {
+		Name      string `json:"name"`
+		Status    string `json:"status"`
+		TargetURL string `json:"target_url"`
+	}
+	if err := g.c.do(ctx, "GET", g.projectPath("/repository/commits/%s/statuses", pr.HeadSHA), nil, &statuses); err != nil {
+		return nil, err
+	}
+	checks := make([]Check, 0, len(statuses))
+	for _, s := range statuses {
+		checks = append(checks, Check{
+			Name:   s.Name,
+			Status: checkStatus(s.Status, []string{"success", "skipped"}, []string{"pending", "running", "created", "manual"}),
+			URL:    s.TargetURL,
+		})
+	}
+	return checks, nil
+}
+
+func (g *gitLab) GetIssue(ctx context.Context, number int) (*Issue, error) {
+	var i struct {
+		IID         int    `json:"iid"`
+		WebURL      string `json:"web_url"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		State       string `json:"state"` // opened or closed
+	}
+	if err := g.c.do(ctx, "GET", g.projectPath("/issues/%d", number), nil, &i); err != nil {
+		return nil, err
+	}
+	state := i.State
+	if state == "opened" {
+		state = StateOpen
+	}
+	return &Issue{Number: i.IID, URL: i.WebURL, Title: i.Title, Body: i.Description, State: state}, nil
+}
@@ -0,0 +1,79 @@
+package forge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/forge"
+)
+
+func TestGitLab(t *testing.T) {
+	ctx := context.Background()
+	mr := map[string]any{
+		"iid": 3, "web_url": "https://gitlab.com/group/sub/widgets/-/merge_requests/3", "title": "Draft: Add auth",
+		"state": "opened", "draft": true, "source_branch": "feat-auth", "target_branch": "main",
+		"sha": "def456", "has_conflicts": true,
+	}
+	project := "/projects/group%2Fsub%2Fwidgets"
+	api := newFakeAPI(t, map[string]any{
+		"GET /users":                                     []any{map[string]any{"id": 42}},
+		"POST " + project + "/merge_requests":            mr,
+		"GET " + project + "/merge_requests":             []any{mr},
+		"GET " + project + "/merge_requests/3":           mr,
+		"GET " + project + "/merge_requests/3/approvals": map[string]any{"approved": true},
+		"PUT " + project + "/merge_requests/3/merge":     map[string]any{"state": "merged"},
+		"GET " + project + "/issues/8": map[string]any{
+			"iid": 8, "web_url": "https://gitlab.com/group/sub/widgets/-/issues/8",
+			"title": "Login crashes", "description": "Steps to reproduce", "state": "opened",
+		},
+		"GET " + project + "/repository/commits/def456/statuses": []any{
+			map[string]any{"name": "test", "status": "running"},
+			map[string]any{"name": "build", "status": "success"},
+			map[string]any{"name": "deploy", "status": "failed"},
+		},
+	})
+	f := newTestForge(t, forge.GitLab, api, "group/sub")
+
+	pr, err := f.CreatePR(ctx, forge.CreateOpts{Title: "Add auth", Head: "feat-auth", Base: "main", Draft: true,
+		Labels: []string{"wiz", "ai"}, Reviewers: []string{"bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Number != 3 || pr.State != forge.StateOpen || pr.Mergeable != forge.Conflicting {
+		t.Errorf("CreatePR = %+v", pr)
+	}
+	req := api.Requests["POST "+project+"/merge_requests"]
+	if req["title"] != "Draft: Add auth" || req["labels"] != "wiz,ai" || req["source_branch"] != "feat-auth" {
+		t.Errorf("create request = %v", req)
+	}
+	if ids, _ := req["reviewer_ids"].([]any); len(ids) != 1 || ids[0] != float64(42) {
+		t.Errorf("reviewer_ids = %v", req["reviewer_ids"])
+	}
+
+	if found, err := f.FindPR(ctx, "feat-auth"); err != nil || found == nil || found.Number != 3 {
+		t.Fatalf("FindPR = %+v, %v", found, err)
+	}
+	got, err := f.GetPR(ctx, 3)
+	if err != nil || got.Review != forge.ReviewApproved {
+		t.Fatalf("GetPR = %+v, %v", got, err)
+	}
+	checks, err := f.Checks(ctx, got)
+	if err != nil || len(checks) != 3 || checks[0].Status != forge.CheckPending || checks[1].Status != forge.CheckSuccess || checks[2].Status != forge.CheckFailure {
+		t.Errorf("Checks = %+v, %v", checks, err)
+	}
+
+	issue, err := f.GetIssue(ctx, 8)
+	if err != nil || issue.Number != 8 || issue.Body != "Steps to reproduce" || issue.State != forge.StateOpen {
+		t.Errorf("GetIssue = %+v, %v", issue, err)
+	}
+
+	if err := f.MergePR(ctx, 3, forge.MergeSquash); err != nil {
+		t.Fatal(err)
+	}
+	if sq := api.Requests["PUT "+project+"/merge_requests/3/merge"]["squash"]; sq != true {
+		t.Errorf("squash = %v", sq)
+	}
+	if err := f.MergePR(ctx, 3, forge.MergeRebase); err == nil {
+		t.Error("expected rebase merges to be rejected")
+	}
+}
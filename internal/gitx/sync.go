@@ -0,0 +1,179 @@
+package gitx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConflictError is returned when a rebase or merge stops on conflicts.
+type ConflictError struct {
+	Op    string // "rebase", "merge" or "cherry-pick"
+	Files []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s conflict in %s", e.Op, strings.Join(e.Files, ", "))
+}
+
+// StatusAgainst returns the status of the checkout at dir with Ahead and
+// Behind counted against base rather than the branch's upstream.
+func StatusAgainst(ctx context.Context, dir, base string) (*RepoStatus, error) {
+	st, err := StatusAt(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitIn(ctx, dir, "rev-list", "--left-right", "--count", "HEAD..."+base)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	st.Ahead, _ = strconv.Atoi(fields[0])
+	st.Behind, _ = strconv.Atoi(fields[1])
+	return st, nil
+}
+
+// Rebase rebases the branch checked out at dir onto the given commit. On
+// conflict the rebase is aborted, leaving the branch untouched, and a
+// *ConflictError lists the conflicting files.
+func Rebase(ctx context.Context, dir, onto string, autostash bool) error {
+	args := []string{"rebase"}
+	if autostash {
+		args = append(args, "--autostash")
+	}
+	return integrate(ctx, dir, "rebase", append(args, onto))
+}
+
+// RebaseOnto replays the commits after upstream onto onto
+// (git rebase --onto), used to restack a branch whose parent was rewritten.
+// Conflicts are handled like Rebase.
+func RebaseOnto(ctx context.Context, dir, onto, upstream string, autostash bool) error {
+	args := []string{"rebase"}
+	if autostash {
+		args = append(args, "--autostash")
+	}
+	return integrate(ctx, dir, "rebase", append(args, "--onto", onto, upstream))
+}
+
+// IsAncestor reports whether commit a is an ancestor of b in the repository at dir.
+func IsAncestor(ctx context.Context, dir, a, b string) bool {
+	_, err := gitIn(ctx, dir, "merge-base", "--is-ancestor", a, b)
+	return err == nil
+}
+
+// Merge merges the given commit into the branch checked out at dir, aborting
+// on conflict like Rebase.
+func Merge(ctx context.Context, dir, ref string, autostash bool) error {
+	args := []string{"merge", "--no-edit"}
+	if autostash {
+		args = append(args, "--autostash")
+	}
+	return integrate(ctx, dir, "merge", append(args, ref))
+}
+
+// MergeCommit merges ref into the branch checked out at dir, always
+// recording a merge commit with the given message. Conflicts are handled
+// like Merge.
+func MergeCommit(ctx context.Context, dir, ref, message string) error {
+	return integrate(ctx, dir, "merge", []string{"merge", "--no-ff", "-m", message, ref})
+}
+
+// SquashMerge applies the changes on ref to the branch checked out at dir
+// as a single commit with the given message. On conflict the merge is
+// undone and a *ConflictError lists the conflicting files.
+func SquashMerge(ctx context.Context, dir, ref, message string) error {
+	if _, err := gitIn(ctx, dir, "merge", "--squash", ref); err != nil {
+		files, _ := gitIn(ctx, dir, "diff", "--name-only", "--diff-filter=U")
+		// A squash merge leaves no MERGE_HEAD, so it can't be aborted.
+		gitIn(ctx, dir, "reset", "--merge")
+		if files == "" {
+			return err
+		}
+		return &ConflictError{Op: "merge", Files: strings.Split(files, "\n")}
+	}
+	_, err := gitIn(ctx, dir, "commit", "--quiet", "-m", message)
+	return err
+}
+
+// FastForward moves the branch checked out at dir forward to ref, failing
+// if the branch has diverged from it.
+func FastForward(ctx context.Context, dir, ref string) error {
+	_, err := gitIn(ctx, dir, "merge", "--ff-only", ref)
+	return err
+}
+
+// CherryPick applies the non-merge commits in upstream..ref, oldest first,
+// onto the branch checked out at dir. Commits whose changes the branch
+// already has are kept as empty commits, so it returns the number of commits
+// applied; on conflict the whole cherry-pick is aborted like Rebase.
+func CherryPick(ctx context.Context, dir, upstream, ref string) (int, error) {
+	out, err := gitIn(ctx, dir, "rev-list", "--reverse", "--no-merges", upstream+".."+ref)
+	if err != nil || out == "" {
+		return 0, err
+	}
+	commits := strings.Split(out, "\n")
+	return len(commits), integrate(ctx, dir, "cherry-pick", append([]string{"cherry-pick", "--keep-redundant-commits"}, commits...))
+}
+
+// integrate runs the rebase, merge or cherry-pick op with args. Whatever
+// stops it, an operation it started is aborted, so the branch is left as it
+// was; conflicts are reported as a *ConflictError.
+func integrate(ctx context.Context, dir, op string, args []string) error {
+	if inProgress(ctx, dir, op) {
+		return fmt.Errorf("a %s is already in progress in %s", op, dir)
+	}
+	if _, err := gitIn(ctx, dir, args...); err != nil {
+		files, _ := gitIn(ctx, dir, "diff", "--name-only", "--diff-filter=U")
+		if inProgress(ctx, dir, op) {
+			// Abort restores the branch and re-applies any autostash.
+			gitIn(ctx, dir, op, "--abort")
+		}
+		if files == "" {
+			return err
+		}
+		return &ConflictError{Op: op, Files: strings.Split(files, "\n")}
+	}
+	return nil
+}
+
+// opState lists the files in the git dir that exist while op is stopped.
+var opState = map[string][]string{
+	"rebase":      {"rebase-merge", "rebase-apply"},
+	"merge":       {"MERGE_HEAD"},
+	"cherry-pick": {"CHERRY_PICK_HEAD", "sequencer"},
+}
+
+// inProgress reports whether op is stopped in the checkout at dir.
+func inProgress(ctx context.Context, dir, op string) bool {
+	for _, name := range opState[op] {
+		path, err := gitIn(ctx, dir, "rev-parse", "--git-path", name)
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// gitIn runs git in dir and returns trimmed stdout.
+func gitIn(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
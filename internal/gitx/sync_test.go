@@ -0,0 +1,191 @@
+package gitx_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/testutil"
+)
+
+func TestStatusAgainstAndRebase(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+	base := tr.CurrentBranch()
+
+	tr.CreateBranch("feature")
+	tr.Checkout("feature")
+	tr.AddFile("feature.txt", "feature\n")
+	tr.Commit("feature work")
+	tr.Checkout(base)
+	tr.AddFile("base.txt", "base\n")
+	tr.Commit("base moves on")
+	tr.Checkout("feature")
+
+	st, err := gitx.StatusAgainst(ctx, tr.Dir, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Ahead != 1 || st.Behind != 1 {
+		t.Errorf("before: ahead %d behind %d, want 1 1", st.Ahead, st.Behind)
+	}
+
+	if err := gitx.Rebase(ctx, tr.Dir, base, false); err != nil {
+		t.Fatal(err)
+	}
+	st, _ = gitx.StatusAgainst(ctx, tr.Dir, base)
+	if st.Ahead != 1 || st.Behind != 0 {
+		t.Errorf("after: ahead %d behind %d, want 1 0", st.Ahead, st.Behind)
+	}
+}
+
+func TestRebaseConflictAborts(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+	base := tr.CurrentBranch()
+
+	tr.CreateBranch("feature")
+	tr.Checkout("feature")
+	tr.AddFile("README.md", "feature version\n")
+	tr.Commit("feature edits readme")
+	tr.Checkout(base)
+	tr.AddFile("README.md", "base version\n")
+	tr.Commit("base edits readme")
+	tr.Checkout("feature")
+
+	for _, integrate := range []func() error{
+		func() error { return gitx.Rebase(ctx, tr.Dir, base, false) },
+		func() error { return gitx.Merge(ctx, tr.Dir, base, false) },
+		func() error { _, err := gitx.CherryPick(ctx, tr.Dir, "feature", base); return err },
+	} {
+		err := integrate()
+		var conflict *gitx.ConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("err = %v, want ConflictError", err)
+		}
+		if len(conflict.Files) != 1 || conflict.Files[0] != "README.md" {
+			t.Errorf("conflict files = %v", conflict.Files)
+		}
+		st, err := gitx.StatusAt(ctx, tr.Dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Dirty || st.Branch != "feature" {
+			t.Errorf("after abort: %+v", st)
+		}
+	}
+}
+
+func TestCherryPickAndMergeCommit(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+	base := tr.CurrentBranch()
+
+	tr.CreateBranch("a")
+	tr.Checkout("a")
+	tr.AddFile("a1.txt", "a1\n")
+	tr.Commit("a1")
+	tr.AddFile("a2.txt", "a2\n")
+	tr.Commit("a2")
+	tr.Checkout(base)
+	tr.CreateBranch("b")
+	tr.Checkout("b")
+	tr.AddFile("b.txt", "b\n")
+	tr.Commit("b")
+	tr.Checkout(base)
+	tr.CreateBranch("integration")
+	tr.Checkout("integration")
+
+	n, err := gitx.CherryPick(ctx, tr.Dir, base, "a")
+	if err != nil || n != 2 {
+		t.Fatalf("CherryPick = %d, %v; want 2 commits", n, err)
+	}
+	if n, err := gitx.CherryPick(ctx, tr.Dir, "a", "a"); err != nil || n != 0 {
+		t.Errorf("empty CherryPick = %d, %v", n, err)
+	}
+	// Commits the branch already has don't stop the cherry-pick.
+	if n, err := gitx.CherryPick(ctx, tr.Dir, base, "a"); err != nil || n != 2 {
+		t.Errorf("redundant CherryPick = %d, %v", n, err)
+	}
+	if err := gitx.MergeCommit(ctx, tr.Dir, "b", "Land b"); err != nil {
+		t.Fatal(err)
+	}
+	if !gitx.IsAncestor(ctx, tr.Dir, "b", "HEAD") || gitx.IsAncestor(ctx, tr.Dir, "a", "HEAD") {
+		t.Error("want b merged and a cherry-picked")
+	}
+}
+
+func TestSquashMergeAndFastForward(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+	base := tr.CurrentBranch()
+
+	tr.CreateBranch("feature")
+	tr.Checkout("feature")
+	tr.AddFile("one.txt", "1\n")
+	tr.Commit("one")
+	tr.AddFile("two.txt", "2\n")
+	tr.Commit("two")
+	tip := tr.Head()
+	tr.Checkout(base)
+	start := tr.Head()
+
+	if err := gitx.FastForward(ctx, tr.Dir, "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if tr.Head() != tip {
+		t.Errorf("fast-forward: HEAD = %s, want %s", tr.Head(), tip)
+	}
+
+	tr.Checkout(start)
+	tr.CreateBranch("squashed")
+	tr.Checkout("squashed")
+	if err := gitx.SquashMerge(ctx, tr.Dir, "feature", "Squash feature"); err != nil {
+		t.Fatal(err)
+	}
+	st, _ := gitx.StatusAgainst(ctx, tr.Dir, start)
+	if st.Ahead != 1 || st.Dirty {
+		t.Errorf("squash: ahead %d dirty %v, want one clean commit", st.Ahead, st.Dirty)
+	}
+	if err := gitx.FastForward(ctx, tr.Dir, "feature"); err == nil {
+		t.Error("expected fast-forward of a diverged branch to fail")
+	}
+}
+
+func TestCherryPickFailureAborts(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+	base := tr.CurrentBranch()
+
+	tr.CreateBranch("a")
+	tr.Checkout("a")
+	tr.AddFile("a1.txt", "a1\n")
+	tr.Commit("a1")
+	tr.AddFile("a2.txt", "a2\n")
+	tr.Commit("a2")
+	tr.Checkout(base)
+	head := tr.Head()
+	// An untracked file stops the second pick without a conflict.
+	if err := os.WriteFile(filepath.Join(tr.Dir, "a2.txt"), []byte("local\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := gitx.CherryPick(ctx, tr.Dir, base, "a")
+	var conflict *gitx.ConflictError
+	if err == nil || errors.As(err, &conflict) {
+		t.Fatalf("err = %v, want a non-conflict error", err)
+	}
+	if got := tr.Head(); got != head {
+		t.Errorf("HEAD = %s, want %s", got, head)
+	}
+	if _, err := os.Stat(filepath.Join(tr.GitDir(), "sequencer")); err == nil {
+		t.Error("cherry-pick left in progress")
+	}
+	if _, err := gitx.CherryPick(ctx, tr.Dir, base, "a"); err == nil || strings.Contains(err.Error(), "in progress") {
+		t.Errorf("retry err = %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package instructions
+
+import (
+	"bytes"
+	gocontext "context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/buck3000/wiz/internal/agent"
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+)
+
+// DefaultTemplate is used when no custom instructions template is configured.
+const DefaultTemplate = `# wiz context: {{.Name}}
+
+You are working in an isolated wiz context of the {{.Repo}} repository at
+{{.Path}}. Other agents may be working in sibling contexts at the same time;
+only read and write files inside this directory.
+
+## Task
+
+{{if .Task}}{{.Task}}{{else}}No task was recorded for this context.{{end}}
+
+## Git
+
+- Work on branch ` + "`{{.Branch}}`" + `{{if .Base}}, created from ` + "`{{.Base}}`" + `{{end}}.
+- Do not switch branches, rebase, merge other branches or push.
+- Commit your work to ` + "`{{.Branch}}`" + ` in small commits with descriptive messages.
+{{- if .Ports}}
+
+## Ports
+
+If you need to run servers, only bind to these ports: {{join .Ports}}.
+{{- end}}
+
+## When you're done
+
+- Commit all changes and leave the working tree clean.
+{{- if .Checks}}
+- Run ` + "`wiz check {{.Name}}`" + ` ({{join .Checks}}) and fix any failures.
+{{- end}}
+- End with a short summary of what you changed and anything left to do.
+- This file is generated by wiz and excluded from git; don't commit it.
+`
+
+// Data is the input to an instructions template.
+type Data struct {
+	Name   string
+	Branch string
+	Base   string
+	Repo   string
+	Path   string
+	Agent  string
+	Task   string
+	Ports  []int
+	Checks []string // names of the checks configured for the context
+}
+
+// Render executes tmpl (DefaultTemplate if empty) with d.
+func Render(tmpl string, d Data) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	funcs := template.FuncMap{
+		"join": func(v any) string {
+			switch v := v.(type) {
+			case []int:
+				s := make([]string, len(v))
+				for i, p := range v {
+					s[i] = strconv.Itoa(p)
+				}
+				return strings.Join(s, ", ")
+			case []string:
+				return strings.Join(v, ", ")
+			}
+			return fmt.Sprint(v)
+		},
+	}
+	t, err := template.New("instructions").Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse instructions template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return "", fmt.Errorf("render instructions template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// FileName returns the instructions file to write for a context: the
+// configured override, else the agent's instructions file, else AGENTS.md.
+func FileName(repo *gitx.Repo, cfg config.Config, agentName string) string {
+	if cfg.Instructions.File != "" {
+		return cfg.Instructions.File
+	}
+	if agentName != "" {
+		if a, err := agent.Lookup(repo, agentName); err == nil {
+			return a.InstructionsFileName()
+		}
+	}
+	return (&agent.Agent{}).InstructionsFileName()
+}
+
+// Apply renders the instructions for c and writes them into the context,
+// returning the file name written. If the repository tracks the
+// instructions file (a committed AGENTS.md, say), they go to its LocalName
+// instead.
+func Apply(ctx gocontext.Context, repo *gitx.Repo, cfg config.Config, c *wizctx.Context) (string, error) {
+	tmpl := ""
+	if cfg.Instructions.Template != "" {
+		path := cfg.Instructions.Template
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repo.WorkDir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read instructions template: %w", err)
+		}
+		tmpl = string(data)
+	}
+
+	base := c.BaseBranch
+	if base == "" {
+		base, _ = repo.CurrentBranch(ctx)
+	}
+	content, err := Render(tmpl, Data{
+		Name:   c.Name,
+		Branch: c.Branch,
+		Base:   base,
+		Repo:   repo.RepoName(),
+		Path:   c.Path,
+		Agent:  c.Agent,
+		Task:   c.Task,
+		Ports:  c.Ports,
+		Checks: checkNames(repo, c),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	file := FileName(repo, cfg, c.Agent)
+	if tracked(ctx, c.Path, file) {
+		file = LocalName(file)
+	}
+	if err := Write(ctx, c.Path, file, content); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// LocalName returns the untracked counterpart of an instructions file,
+// e.g. AGENTS.local.md for AGENTS.md.
+func LocalName(file string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + ".local" + ext
+}
+
+// tracked reports whether git tracks file in the working tree at dir.
+func tracked(ctx gocontext.Context, dir, file string) bool {
+	return exec.CommandContext(ctx, "git", "-C", dir, "ls-files", "--error-unmatch", "--", filepath.Clean(file)).Run() == nil
+}
+
+// checkNames returns the names of the checks wiz check will run for c.
+func checkNames(repo *gitx.Repo, c *wizctx.Context) []string {
+	checks, _ := check.ForTemplate(repo, c.Template)
+	names := make([]string, len(checks))
+	for i, ch := range checks {
+		names[i] = ch.Name
+	}
+	return names
+}
+
+// Write writes content to file inside the context at dir and adds it to the
+// context's info/exclude (shared with the main repo for worktrees) so it never
+// shows up in git status. It refuses to overwrite a file tracked by git.
+func Write(ctx gocontext.Context, dir, file, content string) error {
+	clean := filepath.Clean(file)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("instructions file %q must be inside the context", file)
+	}
+
+	if tracked(ctx, dir, clean) {
+		return fmt.Errorf("instructions file %q is tracked in the repository; set instructions.file to another name", file)
+	}
+
+	path := filepath.Join(dir, clean)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("write instructions file: %w", err)
+	}
+	return exclude(ctx, dir, "/"+filepath.ToSlash(clean))
+}
+
+// exclude appends pattern to the context's info/exclude unless already present.
+func exclude(ctx gocontext.Context, dir, pattern string) error {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--git-path", "info/exclude").Output()
+	if err != nil {
+		return fmt.Errorf("locate info/exclude: %w", err)
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, []byte(pattern+"\n")...)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
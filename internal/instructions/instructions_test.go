@@ -0,0 +1,121 @@
+package instructions
+
+import (
+	gocontext "context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/testutil"
+)
+
+func TestRenderDefault(t *testing.T) {
+	out, err := Render("", Data{
+		Name:   "auth",
+		Branch: "wiz/auth",
+		Base:   "main",
+		Repo:   "app",
+		Path:   "/tmp/auth",
+		Task:   "Fix the login flow",
+		Ports:  []int{4000, 4001},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Fix the login flow", "`wiz/auth`", "`main`", "4000, 4001", "/tmp/auth"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered instructions missing %q:\n%s", want, out)
+		}
+	}
+
+	out, _ = Render("", Data{Name: "x", Branch: "x"})
+	if strings.Contains(out, "## Ports") {
+		t.Errorf("ports section rendered without ports:\n%s", out)
+	}
+}
+
+func TestRenderCustom(t *testing.T) {
+	out, err := Render("{{.Name}} on {{.Branch}}", Data{Name: "n", Branch: "b"})
+	if err != nil || out != "n on b" {
+		t.Errorf("Render = %q, %v", out, err)
+	}
+}
+
+func TestWriteExcludesFromGit(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := gocontext.Background()
+
+	if err := Write(ctx, tr.Dir, "CLAUDE.local.md", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(tr.Dir, "CLAUDE.local.md"))
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("file = %q, %v", data, err)
+	}
+
+	out, err := exec.Command("git", "-C", tr.Dir, "status", "--porcelain").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(out)) != "" {
+		t.Errorf("instructions file not excluded: %s", out)
+	}
+
+	// Writing again doesn't duplicate the exclude entry.
+	if err := Write(ctx, tr.Dir, "CLAUDE.local.md", "again"); err != nil {
+		t.Fatal(err)
+	}
+	exclude, _ := os.ReadFile(filepath.Join(tr.GitDir(), "info", "exclude"))
+	if n := strings.Count(string(exclude), "/CLAUDE.local.md"); n != 1 {
+		t.Errorf("exclude has %d entries:\n%s", n, exclude)
+	}
+}
+
+func TestWriteRefusesTrackedFile(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	tr.AddFile("AGENTS.md", "team conventions")
+	tr.Commit("add AGENTS.md")
+
+	err := Write(gocontext.Background(), tr.Dir, "AGENTS.md", "generated")
+	if err == nil || !strings.Contains(err.Error(), "tracked") {
+		t.Fatalf("err = %v, want tracked error", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(tr.Dir, "AGENTS.md"))
+	if string(data) != "team conventions" {
+		t.Errorf("tracked file overwritten: %q", data)
+	}
+
+	if err := Write(gocontext.Background(), tr.Dir, "../escape.md", "x"); err == nil {
+		t.Error("expected error for path outside context")
+	}
+}
+
+func TestApplyFallsBackForTrackedFile(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	tr.AddFile("AGENTS.md", "team conventions")
+	tr.Commit("add AGENTS.md")
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &wizctx.Context{Name: "auth", Branch: "auth", Path: tr.Dir, Task: "Fix login"}
+	file, err := Apply(gocontext.Background(), repo, config.Load(repo), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != "AGENTS.local.md" {
+		t.Errorf("file = %q, want AGENTS.local.md", file)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tr.Dir, "AGENTS.md")); string(data) != "team conventions" {
+		t.Errorf("tracked file overwritten: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tr.Dir, file)); !strings.Contains(string(data), "Fix login") {
+		t.Errorf("%s = %q", file, data)
+	}
+}
@@ -0,0 +1,67 @@
+package land
+
+import "github.com/buck3000/wiz/internal/check"
+
+// Step results.
+const (
+	Landed       = "landed"
+	Conflict     = "conflict"
+	ChecksFailed = "checks-failed"
+	Error        = "error"
+	Pending      = "pending" // not attempted because an earlier step stopped the run
+)
+
+// Step is the outcome of landing one context onto the integration branch.
+type Step struct {
+	Context   string         `json:"context"`
+	Branch    string         `json:"branch"`
+	Tip       string         `json:"tip"`
+	Result    string         `json:"result"`
+	Commits   int            `json:"commits,omitempty"` // cherry-picked commits
+	Head      string         `json:"head,omitempty"`    // integration HEAD after the step
+	Detail    string         `json:"detail,omitempty"`
+	Conflicts []string       `json:"conflicts,omitempty"`
+	Checks    []check.Result `json:"checks,omitempty"`
+}
+
+// Report describes a whole land run.
+type Report struct {
+	Into  string `json:"into"`
+	Base  string `json:"base"`
+	Mode  string `json:"mode"` // merge or cherry-pick
+	Steps []Step `json:"steps"`
+	// Culprit is the context whose step first made the checks fail, found
+	// by bisecting; BaseFails is set instead when the base already fails.
+	Culprit   string `json:"culprit,omitempty"`
+	BaseFails bool   `json:"base_fails,omitempty"`
+}
+
+// Landed reports whether every step landed (and passed its checks).
+func (r *Report) Landed() bool {
+	for _, s := range r.Steps {
+		if s.Result != Landed {
+			return false
+		}
+	}
+	return r.Culprit == "" && !r.BaseFails
+}
+
+// Bisect finds the first of n steps whose state fails, given that the state
+// after the last step fails. fails(i) tests the state after step i; it is
+// called about log2(n) times.
+func Bisect(n int, fails func(i int) (bool, error)) (int, error) {
+	lo, hi := 0, n-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		bad, err := fails(mid)
+		if err != nil {
+			return 0, err
+		}
+		if bad {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,43 @@
+package land_test
+
+import (
+	"testing"
+
+	"github.com/buck3000/wiz/internal/land"
+)
+
+func TestBisect(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		for culprit := 0; culprit < n; culprit++ {
+			calls := 0
+			got, err := land.Bisect(n, func(i int) (bool, error) {
+				calls++
+				return i >= culprit, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != culprit {
+				t.Errorf("n=%d: Bisect = %d, want %d", n, got, culprit)
+			}
+			if calls > 4 {
+				t.Errorf("n=%d: %d probes", n, calls)
+			}
+		}
+	}
+}
+
+func TestReportLanded(t *testing.T) {
+	r := land.Report{Steps: []land.Step{{Result: land.Landed}, {Result: land.Landed}}}
+	if !r.Landed() {
+		t.Error("want landed")
+	}
+	r.Culprit = "b"
+	if r.Landed() {
+		t.Error("a bisected culprit means the run failed")
+	}
+	r = land.Report{Steps: []land.Step{{Result: land.Landed}, {Result: land.Conflict}, {Result: land.Pending}}}
+	if r.Landed() {
+		t.Error("want not landed")
+	}
+}
@@ -3,24 +3,41 @@ package orchestra
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // TaskDef is a single task in an orchestra file.
 type TaskDef struct {
-	Name      string   `yaml:"name"`
-	Branch    string   `yaml:"branch,omitempty"`
-	Base      string   `yaml:"base,omitempty"`
-	Prompt    string   `yaml:"prompt"`
-	Agent     string   `yaml:"agent"`
-	Strategy  string   `yaml:"strategy,omitempty"`
-	DependsOn []string `yaml:"depends_on,omitempty"`
+	Name       string            `yaml:"name"`
+	Branch     string            `yaml:"branch,omitempty"`
+	Base       string            `yaml:"base,omitempty"`
+	Prompt     string            `yaml:"prompt"`
+	PromptFile string            `yaml:"prompt_file,omitempty"`
+	Inputs     map[string]string `yaml:"inputs,omitempty"`
+	Agent      string            `yaml:"agent"`
+	Strategy   string            `yaml:"strategy,omitempty"`
+	DependsOn  []string          `yaml:"depends_on,omitempty"`
+	// WaitForChecks holds the task until every dependency's latest check
+	// results pass for its current commit. The checks run when wiz check is
+	// run or the dependency commits and leaves its working tree clean.
+	WaitForChecks bool `yaml:"wait_for_checks,omitempty"`
 }
 
 // Plan is the top-level orchestra YAML structure.
 type Plan struct {
 	Tasks []TaskDef `yaml:"tasks"`
+	// CheckTimeout bounds how long a task waits for its dependencies' checks
+	// (default 1h).
+	CheckTimeout string `yaml:"check_timeout,omitempty"`
+	// SnapshotInterval, if set, has wiz orchestra keep running after spawning
+	// the agents and snapshot each task's context this often while it changes.
+	SnapshotInterval string `yaml:"snapshot_interval,omitempty"`
+	// Dir is the directory of the plan file; relative prompt files are
+	// looked up there first.
+	Dir string `yaml:"-"`
 }
 
 // LoadPlan reads and parses an orchestra YAML file.
@@ -33,6 +50,13 @@ func LoadPlan(path string) (*Plan, error) {
 	if err := yaml.Unmarshal(data, &p); err != nil {
 		return nil, fmt.Errorf("parse orchestra file: %w", err)
 	}
+	p.Dir = filepath.Dir(path)
+	if _, err := p.checkTimeout(); err != nil {
+		return nil, err
+	}
+	if _, err := p.SnapshotEvery(); err != nil {
+		return nil, err
+	}
 	if len(p.Tasks) == 0 {
 		return nil, fmt.Errorf("orchestra file contains no tasks")
 	}
@@ -44,6 +68,12 @@ func LoadPlan(path string) (*Plan, error) {
 		if t.Agent == "" {
 			return nil, fmt.Errorf("task %d (%s): agent is required", i, t.Name)
 		}
+		if t.Prompt != "" && t.PromptFile != "" {
+			return nil, fmt.Errorf("task %d (%s): prompt and prompt_file are mutually exclusive", i, t.Name)
+		}
+		if t.WaitForChecks && len(t.DependsOn) == 0 {
+			return nil, fmt.Errorf("task %d (%s): wait_for_checks requires depends_on", i, t.Name)
+		}
 		names[t.Name] = true
 	}
 	// Validate depends_on references.
@@ -56,3 +86,27 @@ func LoadPlan(path string) (*Plan, error) {
 	}
 	return &p, nil
 }
+
+// checkTimeout returns the parsed check timeout, defaulting to one hour.
+func (p *Plan) checkTimeout() (time.Duration, error) {
+	if p.CheckTimeout == "" {
+		return time.Hour, nil
+	}
+	d, err := time.ParseDuration(p.CheckTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid check_timeout %q", p.CheckTimeout)
+	}
+	return d, nil
+}
+
+// SnapshotEvery returns the parsed snapshot interval, zero if unset.
+func (p *Plan) SnapshotEvery() (time.Duration, error) {
+	if p.SnapshotInterval == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(p.SnapshotInterval)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid snapshot_interval %q", p.SnapshotInterval)
+	}
+	return d, nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadPlanValid(t *testing.T) {
@@ -93,3 +94,62 @@ func TestLoadPlanInvalidYAML(t *testing.T) {
 		t.Fatal("expected error for invalid YAML")
 	}
 }
+
+func TestLoadPlanPromptFileExclusive(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "tasks.yaml")
+	os.WriteFile(f, []byte(`tasks:
+  - name: both
+    prompt: "inline"
+    prompt_file: review.md
+    agent: claude
+`), 0o644)
+
+	if _, err := LoadPlan(f); err == nil {
+		t.Fatal("expected error when both prompt and prompt_file are set")
+	}
+}
+
+func TestLoadPlanWaitForChecksNeedsDeps(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "tasks.yaml")
+	os.WriteFile(f, []byte(`tasks:
+  - name: lonely
+    prompt: "do it"
+    agent: claude
+    wait_for_checks: true
+`), 0o644)
+
+	if _, err := LoadPlan(f); err == nil {
+		t.Fatal("expected error for wait_for_checks without depends_on")
+	}
+}
+
+func TestLoadPlanSnapshotInterval(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "tasks.yaml")
+	os.WriteFile(f, []byte(`snapshot_interval: 10m
+tasks:
+  - name: a
+    prompt: "do it"
+    agent: claude
+`), 0o644)
+
+	plan, err := LoadPlan(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := plan.SnapshotEvery(); d != 10*time.Minute {
+		t.Errorf("SnapshotEvery = %s, want 10m", d)
+	}
+
+	os.WriteFile(f, []byte(`snapshot_interval: often
+tasks:
+  - name: a
+    prompt: "do it"
+    agent: claude
+`), 0o644)
+	if _, err := LoadPlan(f); err == nil {
+		t.Fatal("expected error for an invalid snapshot_interval")
+	}
+}
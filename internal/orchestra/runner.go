@@ -3,12 +3,19 @@ package orchestra
 import (
 	"context"
 	"fmt"
+	"os/exec"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/buck3000/wiz/internal/agent"
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/config"
 	wizctx "github.com/buck3000/wiz/internal/context"
 	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/instructions"
+	"github.com/buck3000/wiz/internal/prompt"
+	"github.com/buck3000/wiz/internal/snapshot"
 	"github.com/buck3000/wiz/internal/spawn"
 )
 
@@ -22,6 +29,7 @@ type Result struct {
 // respecting dependency ordering.
 func Run(ctx context.Context, repo *gitx.Repo, plan *Plan, term spawn.Terminal) []Result {
 	store := wizctx.NewStore(repo)
+	cfg := config.Load(repo)
 	results := make([]Result, len(plan.Tasks))
 
 	// Build name-to-index map.
@@ -32,10 +40,20 @@ func Run(ctx context.Context, repo *gitx.Repo, plan *Plan, term spawn.Terminal)
 
 	// Phase 1: Create contexts sequentially (store file lock).
 	for i, task := range plan.Tasks {
-		branch := task.Branch
-		if branch == "" {
-			branch = task.Name
+		branch, err := wizctx.BranchName(ctx, repo, task.Branch, cfg.BranchTemplate, task.Name)
+		if err != nil {
+			results[i] = Result{Name: task.Name, Error: err}
+			continue
 		}
+		text := task.Prompt
+		if task.PromptFile != "" {
+			text, err = prompt.Load(task.PromptFile, plan.Dir, repo.WorkDir, prompt.LibraryDir(repo.WorkDir))
+			if err != nil {
+				results[i] = Result{Name: task.Name, Error: err}
+				continue
+			}
+		}
+
 		strategy := wizctx.ParseStrategy(task.Strategy)
 		prov := wizctx.NewProvisioner(strategy, repo)
 
@@ -50,21 +68,52 @@ func Run(ctx context.Context, repo *gitx.Repo, plan *Plan, term spawn.Terminal)
 			continue
 		}
 
-		err = store.Add(ctx, wizctx.Context{
+		// Inline prompts are only templates if the task has inputs.
+		if task.PromptFile != "" || len(task.Inputs) > 0 {
+			base := task.Base
+			if base == "" {
+				base, _ = repo.CurrentBranch(ctx)
+			}
+			text, err = prompt.Render(text, path, prompt.Vars{
+				Name:   task.Name,
+				Branch: branch,
+				Base:   base,
+				Repo:   repo.RepoName(),
+				Agent:  task.Agent,
+				Inputs: task.Inputs,
+			})
+			if err != nil {
+				_ = prov.Destroy(ctx, path, true)
+				results[i] = Result{Name: task.Name, Error: fmt.Errorf("prompt: %w", err)}
+				continue
+			}
+		}
+
+		c := wizctx.Context{
 			Name:       task.Name,
 			Branch:     branch,
 			Path:       path,
 			Strategy:   prov.Strategy(),
 			CreatedAt:  time.Now(),
 			BaseBranch: task.Base,
-			Task:       task.Prompt,
+			Task:       text,
 			Agent:      task.Agent,
-		})
+			DependsOn:  task.DependsOn,
+		}
+		err = store.AddWithPorts(ctx, &c, cfg.PortBase, cfg.PortsPerContext)
 		if err != nil {
 			_ = prov.Destroy(ctx, path, true)
 			results[i] = Result{Name: task.Name, Error: fmt.Errorf("store: %w", err)}
 			continue
 		}
+		if cfg.Instructions.Enabled {
+			if _, err := instructions.Apply(ctx, repo, cfg, &c); err != nil {
+				_ = store.Remove(ctx, task.Name)
+				_ = prov.Destroy(ctx, path, true)
+				results[i] = Result{Name: task.Name, Error: fmt.Errorf("instructions: %w", err)}
+				continue
+			}
+		}
 	}
 
 	// Phase 2: Spawn agents respecting depends_on ordering.
@@ -95,6 +144,15 @@ func Run(ctx context.Context, repo *gitx.Repo, plan *Plan, term spawn.Terminal)
 					return
 				}
 			}
+			if t.WaitForChecks {
+				timeout, _ := plan.checkTimeout()
+				for _, dep := range t.DependsOn {
+					if err := waitForChecks(ctx, repo, store, dep, timeout); err != nil {
+						results[idx] = Result{Name: t.Name, Error: err}
+						return
+					}
+				}
+			}
 
 			ag, err := agent.Resolve(repo, t.Agent)
 			if err != nil {
@@ -102,7 +160,7 @@ func Run(ctx context.Context, repo *gitx.Repo, plan *Plan, term spawn.Terminal)
 				return
 			}
 			c, _ := store.Get(t.Name)
-			shellCmd := ag.BuildCommand(t.Prompt)
+			shellCmd := ag.BuildCommand(c.Task)
 			title := fmt.Sprintf("\U0001f9d9 %s [%s]", t.Name, t.Agent)
 			if err := term.OpenTab(c.Path, shellCmd, title); err != nil {
 				results[idx] = Result{Name: t.Name, Error: fmt.Errorf("spawn: %w", err)}
@@ -115,3 +173,120 @@ func Run(ctx context.Context, repo *gitx.Repo, plan *Plan, term spawn.Terminal)
 
 	return results
 }
+
+// checkPollInterval is how often waitForChecks re-reads check results.
+var checkPollInterval = 5 * time.Second
+
+// waitForChecks blocks until the named context's latest check results all
+// pass for its current commit. Results are recorded by wiz check, run by the
+// agent or a user; once the context has new commits and a clean working tree,
+// waitForChecks runs its checks itself. A context without configured checks
+// fails right away, as nothing could ever record results for it.
+func waitForChecks(ctx context.Context, repo *gitx.Repo, store *wizctx.Store, name string, timeout time.Duration) error {
+	c, err := store.Get(name)
+	if err != nil {
+		return fmt.Errorf("dependency %q: %w", name, err)
+	}
+	checks, err := check.ForTemplate(repo, c.Template)
+	if err != nil {
+		return fmt.Errorf("dependency %q: %w", name, err)
+	}
+	if len(checks) == 0 {
+		return fmt.Errorf("dependency %q has no checks configured; wait_for_checks needs \"checks\" in %s/config.json", name, config.WizDir(repo))
+	}
+
+	deadline := time.Now().Add(timeout)
+	start := headOf(ctx, c.Path)
+	ran := ""
+	summary := "no results"
+	for {
+		c, err := store.Get(name)
+		if err != nil {
+			return fmt.Errorf("dependency %q: %w", name, err)
+		}
+		head := headOf(ctx, c.Path)
+		if len(c.Checks) > 0 && checksCurrent(c, head) {
+			if check.Passed(c.Checks) {
+				return nil
+			}
+			summary = check.Summary(c.Checks)
+		} else if head != "" && head != start && head != ran {
+			if st, err := gitx.StatusAt(ctx, c.Path); err == nil && !st.Dirty {
+				ran = head
+				runChecks(ctx, repo, store, c, checks)
+				continue
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("dependency %q: checks did not pass within %s (%s)", name, timeout, summary)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(checkPollInterval):
+		}
+	}
+}
+
+// runChecks runs checks in c and records the results like wiz check.
+func runChecks(ctx context.Context, repo *gitx.Repo, store *wizctx.Store, c *wizctx.Context, checks []check.Check) {
+	jobs := []check.Job{{Context: c.Name, Dir: c.Path, Checks: checks}}
+	check.RunAll(ctx, jobs, config.ChecksDir(repo), 1, func(job check.Job, rs []check.Result) {
+		_ = store.Update(ctx, job.Context, func(c *wizctx.Context) { c.Checks = rs })
+	})
+}
+
+// headOf returns the commit checked out at dir, or "" if it can't be read.
+func headOf(ctx context.Context, dir string) string {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// checksCurrent reports whether c's check results ran against head.
+func checksCurrent(c *wizctx.Context, head string) bool {
+	if head == "" {
+		return false
+	}
+	for _, r := range c.Checks {
+		if r.Head != head {
+			return false
+		}
+	}
+	return true
+}
+
+// AutoSnapshot snapshots the named contexts every interval, skipping those
+// unchanged since their latest snapshot, until ctx is done or none of them
+// exist any more. report is called for every snapshot taken or failed.
+func AutoSnapshot(ctx context.Context, store *wizctx.Store, names []string, interval time.Duration, report func(name string, s *snapshot.Snapshot, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		alive := 0
+		for _, name := range names {
+			c, err := store.Get(name)
+			if err != nil {
+				continue
+			}
+			alive++
+			s, err := snapshot.TakeIfChanged(ctx, c.Path, c.Name, "orchestra auto-snapshot")
+			if ctx.Err() != nil {
+				return
+			}
+			if s != nil || err != nil {
+				report(c.Name, s, err)
+			}
+		}
+		if alive == 0 {
+			return
+		}
+	}
+}
@@ -2,10 +2,19 @@ package orchestra
 
 import (
 	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/config"
+	wizctx "github.com/buck3000/wiz/internal/context"
 	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/snapshot"
 	"github.com/buck3000/wiz/testutil"
 )
 
@@ -90,3 +99,271 @@ func TestRunHandlesDuplicateNames(t *testing.T) {
 		t.Error("second task with duplicate name should fail")
 	}
 }
+
+func TestRunRendersPromptFile(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	tr.AddFile(".wiz/prompts/fix.md", "Fix {{.Inputs.issue}} on {{.Branch}}")
+	tr.Commit("add prompt library")
+
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &Plan{
+		Tasks: []TaskDef{
+			{Name: "fix-a", Branch: "fix/a", PromptFile: "fix", Inputs: map[string]string{"issue": "#7"}, Agent: "claude"},
+		},
+		Dir: t.TempDir(),
+	}
+
+	term := &mockTerminal{}
+	results := Run(context.Background(), repo, plan, term)
+	if results[0].Error != nil {
+		t.Fatalf("task failed: %v", results[0].Error)
+	}
+
+	c, err := wizctx.NewStore(repo).Get("fix-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Task != "Fix #7 on fix/a" {
+		t.Errorf("Task = %q", c.Task)
+	}
+	term.mu.Lock()
+	defer term.mu.Unlock()
+	if len(term.calls) != 1 || !strings.Contains(term.calls[0].ShellCmd, "Fix #7 on fix/a") {
+		t.Errorf("spawned command = %+v", term.calls)
+	}
+}
+
+func TestRunInlinePrompts(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &Plan{
+		Tasks: []TaskDef{
+			{Name: "literal", Prompt: "Escape {{ in templates", Agent: "claude"},
+			{Name: "templated", Prompt: "Merge into {{.Base}} for {{.Inputs.who}}", Inputs: map[string]string{"who": "ops"}, Agent: "claude"},
+		},
+		Dir: t.TempDir(),
+	}
+	for i, r := range Run(context.Background(), repo, plan, &mockTerminal{}) {
+		if r.Error != nil {
+			t.Fatalf("task %d failed: %v", i, r.Error)
+		}
+	}
+
+	store := wizctx.NewStore(repo)
+	if c, _ := store.Get("literal"); c == nil || c.Task != "Escape {{ in templates" {
+		t.Errorf("literal task = %+v", c)
+	}
+	// Without a base, the context branches from the current branch.
+	want := "Merge into " + tr.CurrentBranch() + " for ops"
+	if c, _ := store.Get("templated"); c == nil || c.Task != want {
+		t.Errorf("templated task = %+v, want %q", c, want)
+	}
+}
+
+func TestRunWaitsForDependencyChecks(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPollInterval = 10 * time.Millisecond
+	defer func() { checkPollInterval = 5 * time.Second }()
+	writeChecks(t, repo)
+
+	plan := &Plan{
+		Tasks: []TaskDef{
+			{Name: "api", Prompt: "Build API", Agent: "claude"},
+			{Name: "ui", Prompt: "Build UI", Agent: "claude", DependsOn: []string{"api"}, WaitForChecks: true},
+		},
+		CheckTimeout: "10s",
+	}
+
+	term := &mockTerminal{}
+	done := make(chan []Result)
+	go func() { done <- Run(context.Background(), repo, plan, term) }()
+
+	// Wait for the api context, then record failing and later passing checks.
+	store := wizctx.NewStore(repo)
+	var api *wizctx.Context
+	for i := 0; i < 200; i++ {
+		if api, err = store.Get("api"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if api == nil {
+		t.Fatal("api context was not created")
+	}
+	out, _ := exec.Command("git", "-C", api.Path, "rev-parse", "HEAD").Output()
+	head := strings.TrimSpace(string(out))
+
+	record := func(passed bool) {
+		store.Update(context.Background(), "api", func(c *wizctx.Context) {
+			c.Checks = []check.Result{{Name: "test", Passed: passed, Head: head}}
+		})
+	}
+	record(false)
+	time.Sleep(100 * time.Millisecond)
+	term.mu.Lock()
+	spawned := len(term.calls)
+	term.mu.Unlock()
+	if spawned != 1 {
+		t.Fatalf("ui spawned before api checks passed (%d tabs)", spawned)
+	}
+
+	record(true)
+	select {
+	case results := <-done:
+		for _, r := range results {
+			if r.Error != nil {
+				t.Errorf("task %s failed: %v", r.Name, r.Error)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ui was not spawned after api checks passed")
+	}
+}
+
+func TestRunWaitForChecksTimeout(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPollInterval = 10 * time.Millisecond
+	defer func() { checkPollInterval = 5 * time.Second }()
+	writeChecks(t, repo)
+
+	plan := &Plan{
+		Tasks: []TaskDef{
+			{Name: "api", Prompt: "Build API", Agent: "claude"},
+			{Name: "ui", Prompt: "Build UI", Agent: "claude", DependsOn: []string{"api"}, WaitForChecks: true},
+		},
+		CheckTimeout: "50ms",
+	}
+	results := Run(context.Background(), repo, plan, &mockTerminal{})
+	if results[1].Error == nil || !strings.Contains(results[1].Error.Error(), "checks did not pass") {
+		t.Errorf("ui result = %v", results[1].Error)
+	}
+}
+
+func TestRunRunsDependencyChecks(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPollInterval = 10 * time.Millisecond
+	defer func() { checkPollInterval = 5 * time.Second }()
+	writeChecks(t, repo)
+
+	plan := &Plan{
+		Tasks: []TaskDef{
+			{Name: "api", Prompt: "Build API", Agent: "claude"},
+			{Name: "ui", Prompt: "Build UI", Agent: "claude", DependsOn: []string{"api"}, WaitForChecks: true},
+		},
+		CheckTimeout: "10s",
+	}
+	done := make(chan []Result)
+	go func() { done <- Run(context.Background(), repo, plan, &mockTerminal{}) }()
+
+	store := wizctx.NewStore(repo)
+	var api *wizctx.Context
+	for i := 0; i < 200; i++ {
+		if api, err = store.Get("api"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if api == nil {
+		t.Fatal("api context was not created")
+	}
+	// The "agent" finishes by committing the file the check looks for.
+	time.Sleep(50 * time.Millisecond)
+	os.WriteFile(filepath.Join(api.Path, "done.txt"), []byte("done\n"), 0o644)
+	for _, args := range [][]string{{"add", "done.txt"}, {"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "done"}} {
+		if out, err := exec.Command("git", append([]string{"-C", api.Path}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	select {
+	case results := <-done:
+		for _, r := range results {
+			if r.Error != nil {
+				t.Errorf("task %s failed: %v", r.Name, r.Error)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ui was not spawned after api committed")
+	}
+	if c, _ := store.Get("api"); c == nil || !check.Passed(c.Checks) || len(c.Checks) != 1 {
+		t.Errorf("api checks = %+v", c)
+	}
+}
+
+func TestRunWaitForChecksNeedsChecks(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := &Plan{
+		Tasks: []TaskDef{
+			{Name: "api", Prompt: "Build API", Agent: "claude"},
+			{Name: "ui", Prompt: "Build UI", Agent: "claude", DependsOn: []string{"api"}, WaitForChecks: true},
+		},
+	}
+	results := Run(context.Background(), repo, plan, &mockTerminal{})
+	if results[1].Error == nil || !strings.Contains(results[1].Error.Error(), "no checks configured") {
+		t.Errorf("ui result = %v", results[1].Error)
+	}
+}
+
+// writeChecks configures a check that passes once done.txt is committed.
+func writeChecks(t *testing.T, repo *gitx.Repo) {
+	t.Helper()
+	dir := config.WizDir(repo)
+	os.MkdirAll(dir, 0o755)
+	cfg := `{"checks": [{"name": "test", "run": "test -f done.txt"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAutoSnapshot(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	repo, err := gitx.Discover(tr.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := wizctx.NewStore(repo)
+	if err := store.Add(context.Background(), wizctx.Context{Name: "a", Branch: "main", Path: tr.Dir}); err != nil {
+		t.Fatal(err)
+	}
+	tr.AddFile("work.txt", "in progress")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var taken []int
+	AutoSnapshot(ctx, store, []string{"a", "gone"}, 10*time.Millisecond, func(name string, s *snapshot.Snapshot, err error) {
+		if err != nil {
+			t.Errorf("snapshot %s: %v", name, err)
+			return
+		}
+		taken = append(taken, s.ID)
+	})
+
+	// The tree changed once, so only one snapshot is recorded.
+	if len(taken) != 1 || taken[0] != 1 {
+		t.Errorf("snapshots taken = %v, want [1]", taken)
+	}
+}
@@ -0,0 +1,118 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Vars are the values available to prompt templates, e.g. {{.Branch}} or
+// {{.Inputs.ticket}}.
+type Vars struct {
+	Name   string
+	Branch string
+	Base   string
+	Repo   string
+	Agent  string
+	Inputs map[string]string
+}
+
+// LibraryDir returns <root>/.wiz/prompts — the shared prompt library.
+func LibraryDir(root string) string {
+	return filepath.Join(root, ".wiz", "prompts")
+}
+
+// Find resolves a prompt file name. Absolute paths are used as-is; relative
+// names are tried against each of dirs in order, with and without a .md
+// extension.
+func Find(name string, dirs ...string) (string, error) {
+	if filepath.IsAbs(name) {
+		if _, err := os.Stat(name); err != nil {
+			return "", fmt.Errorf("prompt file: %w", err)
+		}
+		return name, nil
+	}
+	candidates := []string{name}
+	if filepath.Ext(name) == "" {
+		candidates = append(candidates, name+".md")
+	}
+	for _, dir := range dirs {
+		for _, c := range candidates {
+			path := filepath.Join(dir, c)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("prompt file %q not found (searched: %s)", name, strings.Join(dirs, ", "))
+}
+
+// Load finds a prompt file via Find and returns its contents.
+func Load(name string, dirs ...string) (string, error) {
+	path, err := Find(name, dirs...)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read prompt file: %w", err)
+	}
+	return string(data), nil
+}
+
+// Render executes text as a Go template with vars. The include function
+// inserts a file relative to root, which must stay inside root:
+//
+//	{{include "docs/CONVENTIONS.md"}}
+//
+// Referencing an input that wasn't provided is an error.
+func Render(text, root string, vars Vars) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	if vars.Inputs == nil {
+		vars.Inputs = map[string]string{}
+	}
+	funcs := template.FuncMap{
+		"include": func(rel string) (string, error) {
+			return include(root, rel)
+		},
+	}
+	tmpl, err := template.New("prompt").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse prompt template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("render prompt template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func include(root, rel string) (string, error) {
+	clean := filepath.Clean(rel)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("include %q: path must be inside the repository", rel)
+	}
+	// Resolve symlinks so that a link inside the repository cannot point
+	// outside it.
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("include %q: %w", rel, err)
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(root, clean))
+	if err != nil {
+		return "", fmt.Errorf("include %q: %w", rel, err)
+	}
+	if inside, err := filepath.Rel(realRoot, target); err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("include %q: path must be inside the repository", rel)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return "", fmt.Errorf("include %q: %w", rel, err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderVars(t *testing.T) {
+	got, err := Render("Work on {{.Name}} ({{.Branch}} from {{.Base}}) in {{.Repo}} for {{.Inputs.ticket}}", "", Vars{
+		Name:   "auth",
+		Branch: "wiz/auth",
+		Base:   "main",
+		Repo:   "app",
+		Inputs: map[string]string{"ticket": "APP-12"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Work on auth (wiz/auth from main) in app for APP-12"
+	if got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+}
+
+func TestRenderPlainTextUnchanged(t *testing.T) {
+	got, err := Render("Fix it's bug", "", Vars{})
+	if err != nil || got != "Fix it's bug" {
+		t.Errorf("Render = %q, %v", got, err)
+	}
+}
+
+func TestRenderMissingInput(t *testing.T) {
+	if _, err := Render("{{.Inputs.nope}}", "", Vars{}); err == nil {
+		t.Error("expected error for missing input")
+	}
+}
+
+func TestRenderInclude(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "docs"), 0o755)
+	os.WriteFile(filepath.Join(root, "docs", "style.md"), []byte("Use tabs."), 0o644)
+
+	got, err := Render(`Rules: {{include "docs/style.md"}}`, root, Vars{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Rules: Use tabs." {
+		t.Errorf("Render = %q", got)
+	}
+
+	if _, err := Render(`{{include "../secret"}}`, root, Vars{}); err == nil || !strings.Contains(err.Error(), "inside the repository") {
+		t.Errorf("include outside root: err = %v", err)
+	}
+
+	// Symlinks are followed only as far as the root.
+	secret := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(secret, []byte("hunter2"), 0o644)
+	os.Symlink(secret, filepath.Join(root, "leak"))
+	if _, err := Render(`{{include "leak"}}`, root, Vars{}); err == nil || !strings.Contains(err.Error(), "inside the repository") {
+		t.Errorf("include through symlink: err = %v", err)
+	}
+	os.Symlink(filepath.Join("docs", "style.md"), filepath.Join(root, "style"))
+	if got, err := Render(`{{include "style"}}`, root, Vars{}); err != nil || got != "Use tabs." {
+		t.Errorf("include symlink inside root = %q, %v", got, err)
+	}
+}
+
+func TestFindSearchesDirsAndExtension(t *testing.T) {
+	root := t.TempDir()
+	lib := LibraryDir(root)
+	os.MkdirAll(lib, 0o755)
+	os.WriteFile(filepath.Join(lib, "refactor.md"), []byte("Refactor {{.Name}}"), 0o644)
+
+	path, err := Find("refactor", root, lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(lib, "refactor.md") {
+		t.Errorf("Find = %q", path)
+	}
+
+	text, err := Load("refactor.md", lib)
+	if err != nil || text != "Refactor {{.Name}}" {
+		t.Errorf("Load = %q, %v", text, err)
+	}
+
+	if _, err := Find("missing", root, lib); err == nil {
+		t.Error("expected error for missing prompt")
+	}
+}
@@ -0,0 +1,102 @@
+// Package prstatus polls the forge for the pull request and CI state of each
+// context's branch and records it on the context.
+package prstatus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
+)
+
+// Poll refreshes c's PR, review state, mergeability and CI checks from f
+// and records them in store. A context without a known PR is matched to
+// the open PR for its branch; the returned context's PR is nil if there is
+// none.
+func Poll(ctx context.Context, f forge.Forge, store *wizctx.Store, c *wizctx.Context) (*wizctx.Context, error) {
+	var pr *forge.PR
+	var err error
+	if c.PR != nil {
+		pr, err = f.GetPR(ctx, c.PR.Number)
+	} else if pr, err = f.FindPR(ctx, c.Branch); err == nil && pr != nil {
+		// List endpoints leave out reviews; fetch the PR itself.
+		pr, err = f.GetPR(ctx, pr.Number)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name, err)
+	}
+
+	var checks []forge.Check
+	if pr != nil && pr.HeadSHA != "" {
+		if checks, err = f.Checks(ctx, pr); err != nil {
+			return nil, fmt.Errorf("%s: checks: %w", c.Name, err)
+		}
+	}
+
+	now := time.Now()
+	err = store.Update(ctx, c.Name, func(c *wizctx.Context) {
+		c.PR, c.PRChecks, c.PRPolledAt = pr, checks, now
+	})
+	if err != nil {
+		return nil, err
+	}
+	updated := *c
+	updated.PR, updated.PRChecks, updated.PRPolledAt = pr, checks, now
+	return &updated, nil
+}
+
+// PollAll polls contexts concurrently, at most parallel at a time, and
+// returns the errors by context name.
+func PollAll(ctx context.Context, f forge.Forge, store *wizctx.Store, contexts []wizctx.Context, parallel int) map[string]error {
+	if parallel < 1 {
+		parallel = 1
+	}
+	errs := map[string]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for i := range contexts {
+		c := &contexts[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if _, err := Poll(ctx, f, store, c); err != nil {
+				mu.Lock()
+				errs[c.Name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// Summary describes a PR in one line, e.g.
+// "#12 open, approved, mergeable, CI 2/3 passed".
+func Summary(pr *forge.PR, checks []forge.Check) string {
+	if pr == nil {
+		return "no PR"
+	}
+	parts := []string{fmt.Sprintf("#%d %s", pr.Number, pr.State)}
+	if pr.Draft {
+		parts[0] += " (draft)"
+	}
+	if pr.State == forge.StateOpen {
+		if pr.Review != "" {
+			parts = append(parts, strings.ReplaceAll(pr.Review, "_", " "))
+		}
+		if pr.Mergeable != "" {
+			parts = append(parts, pr.Mergeable)
+		}
+	}
+	if len(checks) > 0 {
+		parts = append(parts, "CI "+forge.ChecksSummary(checks))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,79 @@
+package prstatus_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
+	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/prstatus"
+	"github.com/buck3000/wiz/testutil"
+)
+
+// fakeForge serves PRs by number, each with the given checks.
+type fakeForge struct {
+	forge.Forge
+	pulls  map[int]*forge.PR
+	checks []forge.Check
+}
+
+func (f *fakeForge) GetPR(_ context.Context, n int) (*forge.PR, error) {
+	pr := *f.pulls[n]
+	pr.Review = forge.ReviewApproved
+	return &pr, nil
+}
+
+func (f *fakeForge) FindPR(_ context.Context, head string) (*forge.PR, error) {
+	for _, pr := range f.pulls {
+		if pr.Head == head && pr.State == forge.StateOpen {
+			return pr, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeForge) Checks(context.Context, *forge.PR) ([]forge.Check, error) {
+	return f.checks, nil
+}
+
+func TestPollAll(t *testing.T) {
+	ctx := context.Background()
+	repo, err := gitx.Discover(testutil.NewTestRepo(t).Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := wizctx.NewStore(repo)
+	for _, name := range []string{"feat", "fix", "spike"} {
+		store.Add(ctx, wizctx.Context{Name: name, Branch: name, Path: "/tmp/" + name, CreatedAt: time.Now()})
+	}
+	store.Update(ctx, "fix", func(c *wizctx.Context) { c.PR = &forge.PR{Number: 2, State: forge.StateOpen} })
+
+	f := &fakeForge{
+		pulls: map[int]*forge.PR{
+			1: {Number: 1, State: forge.StateOpen, Head: "feat", HeadSHA: "aaa", Mergeable: forge.Mergeable},
+			2: {Number: 2, State: forge.StateMerged, Head: "fix", HeadSHA: "bbb"},
+		},
+		checks: []forge.Check{{Name: "ci", Status: forge.CheckSuccess}},
+	}
+	contexts, _ := store.List()
+	if errs := prstatus.PollAll(ctx, f, store, contexts, 2); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	feat, _ := store.Get("feat")
+	if feat.PR == nil || feat.PR.Number != 1 || feat.PR.Review != forge.ReviewApproved || len(feat.PRChecks) != 1 || feat.PRPolledAt.IsZero() {
+		t.Errorf("feat = %+v", feat)
+	}
+	if got := prstatus.Summary(feat.PR, feat.PRChecks); got != "#1 open, approved, mergeable, CI 1/1 passed" {
+		t.Errorf("Summary = %q", got)
+	}
+	fix, _ := store.Get("fix")
+	if fix.PR == nil || fix.PR.State != forge.StateMerged {
+		t.Errorf("fix PR = %+v", fix.PR)
+	}
+	if spike, _ := store.Get("spike"); spike.PR != nil || spike.PRPolledAt.IsZero() {
+		t.Errorf("spike = %+v", spike)
+	}
+}
@@ -0,0 +1,144 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Verdict is the reviewer's overall decision.
+type Verdict string
+
+const (
+	VerdictApprove        Verdict = "approve"
+	VerdictRequestChanges Verdict = "request_changes"
+	VerdictComment        Verdict = "comment"
+)
+
+// MaxDiffBytes caps the diff sent to the reviewer so the prompt stays within
+// argument and context limits.
+const MaxDiffBytes = 100_000
+
+// Issue is a single finding, anchored to a file and line when possible.
+type Issue struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity,omitempty"` // error, warning, nit
+	Message  string `json:"message"`
+}
+
+// Review is a structured review of a context's diff.
+type Review struct {
+	Verdict   Verdict   `json:"verdict"`
+	Summary   string    `json:"summary"`
+	Issues    []Issue   `json:"issues,omitempty"`
+	Reviewer  string    `json:"reviewer,omitempty"`
+	Base      string    `json:"base,omitempty"`
+	Head      string    `json:"head,omitempty"` // commit that was reviewed
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BuildPrompt returns the reviewer prompt for a task and diff.
+func BuildPrompt(task, diff string) string {
+	truncated := false
+	if len(diff) > MaxDiffBytes {
+		diff = truncate(diff, MaxDiffBytes)
+		truncated = true
+	}
+
+	var b strings.Builder
+	b.WriteString("You are a senior engineer reviewing a change made by a coding agent.\n")
+	b.WriteString("Do not modify any files; only review.\n\n")
+	if task != "" {
+		b.WriteString("The change was meant to accomplish this task:\n\n")
+		b.WriteString(task)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Judge correctness, whether it accomplishes the task, tests and code quality.\n")
+	b.WriteString("Respond with only a JSON object, no prose and no code fences, in this shape:\n\n")
+	b.WriteString(`{"verdict": "approve" | "request_changes" | "comment", "summary": "...", ` +
+		`"issues": [{"file": "path", "line": 12, "severity": "error" | "warning" | "nit", "message": "..."}]}`)
+	b.WriteString("\n\nThe diff:\n\n")
+	b.WriteString(diff)
+	if truncated {
+		b.WriteString("\n\n[diff truncated]\n")
+	}
+	return b.String()
+}
+
+// truncate cuts s to at most n bytes, at the end of a line if there is one
+// and otherwise at a rune boundary.
+func truncate(s string, n int) string {
+	if i := strings.LastIndexByte(s[:n], '\n'); i >= 0 {
+		return s[:i+1]
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Parse extracts a review from the reviewer's output, tolerating prose or
+// code fences around the JSON object.
+func Parse(output string) (*Review, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("reviewer output contains no JSON object")
+	}
+	var r Review
+	if err := json.Unmarshal([]byte(output[start:end+1]), &r); err != nil {
+		return nil, fmt.Errorf("parse reviewer output: %w", err)
+	}
+	switch v := Verdict(strings.ToLower(strings.TrimSpace(string(r.Verdict)))); v {
+	case VerdictApprove, VerdictRequestChanges, VerdictComment:
+		r.Verdict = v
+	case "changes_requested", "request changes", "reject":
+		r.Verdict = VerdictRequestChanges
+	default:
+		r.Verdict = VerdictComment
+	}
+	return &r, nil
+}
+
+// Markdown renders the review as Markdown.
+func (r *Review) Markdown(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Review: %s\n\n", name)
+	// Each field is a paragraph of its own; consecutive lines would be
+	// joined into one.
+	fmt.Fprintf(&b, "**Verdict:** %s\n\n", r.Verdict)
+	if r.Reviewer != "" {
+		fmt.Fprintf(&b, "**Reviewer:** %s\n\n", r.Reviewer)
+	}
+	if r.Base != "" {
+		fmt.Fprintf(&b, "**Base:** %s\n\n", r.Base)
+	}
+	if r.Head != "" {
+		fmt.Fprintf(&b, "**Commit:** %s\n\n", r.Head)
+	}
+	b.WriteString("## Summary\n\n")
+	b.WriteString(strings.TrimSpace(r.Summary))
+	b.WriteString("\n")
+	if len(r.Issues) > 0 {
+		b.WriteString("\n## Issues\n\n")
+		for _, is := range r.Issues {
+			loc := is.File
+			if loc != "" && is.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", is.File, is.Line)
+			}
+			b.WriteString("- ")
+			if is.Severity != "" {
+				fmt.Fprintf(&b, "**%s** ", is.Severity)
+			}
+			if loc != "" {
+				fmt.Fprintf(&b, "`%s` ", loc)
+			}
+			b.WriteString(is.Message)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
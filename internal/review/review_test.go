@@ -0,0 +1,73 @@
+package review
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseWithSurroundingText(t *testing.T) {
+	out := "Here is my review:\n```json\n" +
+		`{"verdict": "Request_Changes", "summary": "Missing tests.", "issues": [{"file": "auth.go", "line": 42, "severity": "error", "message": "nil deref"}]}` +
+		"\n```\n"
+	r, err := Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Verdict != VerdictRequestChanges {
+		t.Errorf("Verdict = %q", r.Verdict)
+	}
+	if len(r.Issues) != 1 || r.Issues[0].File != "auth.go" || r.Issues[0].Line != 42 {
+		t.Errorf("Issues = %+v", r.Issues)
+	}
+}
+
+func TestParseUnknownVerdict(t *testing.T) {
+	r, err := Parse(`{"verdict": "meh", "summary": "ok"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Verdict != VerdictComment {
+		t.Errorf("Verdict = %q, want comment", r.Verdict)
+	}
+}
+
+func TestParseNoJSON(t *testing.T) {
+	if _, err := Parse("looks good to me"); err == nil {
+		t.Error("expected error for output without JSON")
+	}
+}
+
+func TestBuildPromptTruncates(t *testing.T) {
+	diff := strings.Repeat("x", MaxDiffBytes+10)
+	p := BuildPrompt("Fix auth", diff)
+	if !strings.Contains(p, "Fix auth") || !strings.Contains(p, "[diff truncated]") {
+		t.Errorf("prompt missing task or truncation marker")
+	}
+	if len(p) > MaxDiffBytes+2000 {
+		t.Errorf("prompt not truncated: %d bytes", len(p))
+	}
+
+	// Cuts fall on a line boundary, or at least a rune boundary.
+	if got := truncate("+one\n+two\n", 7); got != "+one\n" {
+		t.Errorf("truncate at line = %q", got)
+	}
+	if got := truncate("añb", 2); got != "a" || !utf8.ValidString(got) {
+		t.Errorf("truncate at rune = %q", got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	r := &Review{
+		Verdict:  VerdictApprove,
+		Summary:  "Looks good.",
+		Reviewer: "codex",
+		Issues:   []Issue{{File: "a.go", Line: 3, Severity: "nit", Message: "rename x"}},
+	}
+	md := r.Markdown("feat")
+	for _, want := range []string{"# Review: feat", "**Verdict:** approve\n\n**Reviewer:** codex\n\n## Summary", "`a.go:3`", "rename x"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
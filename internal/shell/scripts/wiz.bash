@@ -25,6 +25,11 @@ _wiz_prompt_hook() {
     if [[ -f "$index_file" ]]; then
       current_mtime="$(command stat -f %m "$index_file" 2>/dev/null || command stat -c %Y "$index_file" 2>/dev/null)"
     fi
+    # ...or the state file, which wiz rename rewrites.
+    local state_file="${WIZ_DIR}/../../state.json"
+    if [[ -f "$state_file" ]]; then
+      current_mtime="${current_mtime}:$(command stat -f %m "$state_file" 2>/dev/null || command stat -c %Y "$state_file" 2>/dev/null)"
+    fi
 
     if [[ "$current_mtime" != "$_wiz_last_index_mtime" || -z "$_wiz_cached_status" ]]; then
       _wiz_cached_status="$(command wiz status --porcelain 2>/dev/null)"
@@ -32,8 +37,16 @@ _wiz_prompt_hook() {
     fi
 
     if [[ -n "$_wiz_cached_status" ]]; then
-      local ctx repo branch state ahead behind
-      read -r ctx repo branch state ahead behind <<< "$_wiz_cached_status"
+      local ctx repo branch state ahead behind dir
+      read -r ctx repo branch state ahead behind dir <<< "$_wiz_cached_status"
+      # Follow wiz rename: take the new name, branch and directory.
+      if [[ -n "$dir" && "$dir" != "$WIZ_DIR" ]]; then
+        if [[ "$PWD" == "$WIZ_DIR"* && ! -d "$PWD" ]]; then
+          cd "${dir}${PWD#"$WIZ_DIR"}" 2>/dev/null || cd "$dir"
+        fi
+        export WIZ_DIR="$dir"
+      fi
+      export WIZ_CTX="$ctx" WIZ_BRANCH="$branch"
       local dirty=""
       [[ "$state" == "dirty" ]] && dirty="*"
       # Terminal title
@@ -26,6 +26,11 @@ function _wiz_prompt_hook --on-event fish_prompt
         if test -f "$index_file"
             set current_mtime (command stat -f %m "$index_file" 2>/dev/null; or command stat -c %Y "$index_file" 2>/dev/null)
         end
+        # ...or the state file, which wiz rename rewrites.
+        set -l state_file "$WIZ_DIR/../../state.json"
+        if test -f "$state_file"
+            set current_mtime "$current_mtime:"(command stat -f %m "$state_file" 2>/dev/null; or command stat -c %Y "$state_file" 2>/dev/null)
+        end
 
         if test "$current_mtime" != "$_wiz_last_index_mtime"; or test -z "$_wiz_cached_status"
             set -g _wiz_cached_status (command wiz status --porcelain 2>/dev/null)
@@ -38,6 +43,16 @@ function _wiz_prompt_hook --on-event fish_prompt
             set -l repo $parts[2]
             set -l branch $parts[3]
             set -l state $parts[4]
+            # Follow wiz rename: take the new name, branch and directory.
+            set -l dir (string join ' ' $parts[7..])
+            if test -n "$dir"; and test "$dir" != "$WIZ_DIR"
+                if string match -q -- "$WIZ_DIR*" "$PWD"; and not test -d "$PWD"
+                    cd "$dir"(string replace -- "$WIZ_DIR" "" "$PWD") 2>/dev/null; or cd "$dir"
+                end
+                set -gx WIZ_DIR "$dir"
+            end
+            set -gx WIZ_CTX $ctx
+            set -gx WIZ_BRANCH $branch
             set -l dirty ""
             if test "$state" = "dirty"
                 set dirty "*"
@@ -0,0 +1,280 @@
+// Package snapshot records a context's working tree, including uncommitted
+// and untracked files, as commits on hidden refs so it can be rolled back
+// without touching the branch history.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	wizctx "github.com/buck3000/wiz/internal/context"
+)
+
+// RefPrefix is where snapshot refs live: refs/wiz/snapshots/<context>/<id>.
+const RefPrefix = "refs/wiz/snapshots/"
+
+// Snapshot is a recorded working tree.
+type Snapshot struct {
+	ID      int       `json:"id"`
+	Ref     string    `json:"ref"`
+	Commit  string    `json:"commit"`
+	Head    string    `json:"head,omitempty"` // HEAD when the snapshot was taken
+	Message string    `json:"message"`
+	Created time.Time `json:"created"`
+}
+
+// Ref returns the ref of snapshot id of the named context.
+func Ref(name string, id int) string {
+	return refDir(name) + strconv.Itoa(id)
+}
+
+// Refspec returns a fetch refspec that copies the named context's snapshots
+// between repositories.
+func Refspec(name string) string {
+	return "+" + refDir(name) + "*:" + refDir(name) + "*"
+}
+
+func refDir(name string) string {
+	return RefPrefix + wizctx.SafeDirName(name) + "/"
+}
+
+// Take records the working tree at dir, the named context's directory, as a
+// new snapshot. Ignored files are left out.
+func Take(ctx context.Context, dir, name, message string) (*Snapshot, error) {
+	return take(ctx, dir, name, message, false)
+}
+
+// TakeIfChanged is like Take, but returns nil without recording anything if
+// the working tree and HEAD are unchanged since the latest snapshot.
+func TakeIfChanged(ctx context.Context, dir, name, message string) (*Snapshot, error) {
+	return take(ctx, dir, name, message, true)
+}
+
+func take(ctx context.Context, dir, name, message string, skipUnchanged bool) (*Snapshot, error) {
+	tree, err := writeTree(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	head, _ := git(ctx, dir, nil, "rev-parse", "--verify", "--quiet", "HEAD")
+
+	existing, err := List(ctx, dir, name)
+	if err != nil {
+		return nil, err
+	}
+	id := 1
+	if n := len(existing); n > 0 {
+		latest := existing[n-1]
+		id = latest.ID + 1
+		if skipUnchanged && latest.Head == head {
+			if t, err := git(ctx, dir, nil, "rev-parse", latest.Commit+"^{tree}"); err == nil && t == tree {
+				return nil, nil
+			}
+		}
+	}
+
+	if message == "" {
+		message = "wiz snapshot"
+	}
+	commit, err := commitTree(ctx, dir, tree, head, message)
+	if err != nil {
+		return nil, fmt.Errorf("record snapshot: %w", err)
+	}
+	ref := Ref(name, id)
+	// An empty old value makes this fail if a concurrent snapshot took the id.
+	if _, err := git(ctx, dir, nil, "update-ref", ref, commit, ""); err != nil {
+		return nil, fmt.Errorf("record snapshot: %w", err)
+	}
+	return &Snapshot{ID: id, Ref: ref, Commit: commit, Head: head, Message: message, Created: time.Now()}, nil
+}
+
+// Capture records the working tree at dir as a commit on top of HEAD, like a
+// snapshot but without a ref, and returns it along with HEAD. The commit is
+// only reachable until the next git gc unless the caller keeps a ref to it.
+func Capture(ctx context.Context, dir, message string) (commit, head string, err error) {
+	tree, err := writeTree(ctx, dir)
+	if err != nil {
+		return "", "", err
+	}
+	head, _ = git(ctx, dir, nil, "rev-parse", "--verify", "--quiet", "HEAD")
+	if commit, err = commitTree(ctx, dir, tree, head, message); err != nil {
+		return "", "", fmt.Errorf("capture working tree: %w", err)
+	}
+	return commit, head, nil
+}
+
+func commitTree(ctx context.Context, dir, tree, parent, message string) (string, error) {
+	args := []string{"commit-tree", tree, "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	// Snapshot commits never leave the repository, so they don't need the
+	// user's identity if git has none configured (e.g. in a fresh clone).
+	var env []string
+	if _, err := git(ctx, dir, nil, "var", "GIT_COMMITTER_IDENT"); err != nil {
+		env = []string{"GIT_AUTHOR_NAME=wiz", "GIT_AUTHOR_EMAIL=wiz@localhost",
+			"GIT_COMMITTER_NAME=wiz", "GIT_COMMITTER_EMAIL=wiz@localhost"}
+	}
+	return git(ctx, dir, env, args...)
+}
+
+// writeTree writes the working tree at dir as a tree object, staging every
+// change in a temporary copy of the index so the real one is left alone.
+func writeTree(ctx context.Context, dir string) (string, error) {
+	tmp, err := os.CreateTemp("", "wiz-snapshot-index-*")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+	// git creates a missing index but rejects an empty one.
+	os.Remove(tmp.Name())
+	defer os.Remove(tmp.Name())
+
+	// Starting from the real index lets git reuse its cached file stats.
+	if index, err := git(ctx, dir, nil, "rev-parse", "--git-path", "index"); err == nil {
+		if !filepath.IsAbs(index) {
+			index = filepath.Join(dir, index)
+		}
+		if data, err := os.ReadFile(index); err == nil {
+			if err := os.WriteFile(tmp.Name(), data, 0o644); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + tmp.Name()}
+	if _, err := git(ctx, dir, env, "add", "--all"); err != nil {
+		return "", fmt.Errorf("stage working tree: %w", err)
+	}
+	tree, err := git(ctx, dir, env, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("write tree: %w", err)
+	}
+	return tree, nil
+}
+
+// List returns the named context's snapshots, oldest first.
+func List(ctx context.Context, dir, name string) ([]Snapshot, error) {
+	out, err := git(ctx, dir, nil, "for-each-ref",
+		"--format=%(refname)%00%(objectname)%00%(parent)%00%(creatordate:unix)%00%(subject)", refDir(name))
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+	var snaps []Snapshot
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.SplitN(line, "\x00", 5)
+		if len(f) < 5 {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(f[0], refDir(name)))
+		if err != nil {
+			continue
+		}
+		unix, _ := strconv.ParseInt(f[3], 10, 64)
+		snaps = append(snaps, Snapshot{
+			ID:      id,
+			Ref:     f[0],
+			Commit:  f[1],
+			Head:    f[2],
+			Created: time.Unix(unix, 0),
+			Message: f[4],
+		})
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].ID < snaps[j].ID })
+	return snaps, nil
+}
+
+// Get returns snapshot id of the named context.
+func Get(ctx context.Context, dir, name string, id int) (*Snapshot, error) {
+	snaps, err := List(ctx, dir, name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snaps {
+		if snaps[i].ID == id {
+			return &snaps[i], nil
+		}
+	}
+	return nil, fmt.Errorf("context %q has no snapshot %d; run 'wiz snapshots %s' to list them", name, id, name)
+}
+
+// Restore rolls the working tree at dir back to s: HEAD is reset to the
+// commit it was on when s was taken and s's files are checked out as
+// uncommitted changes. Untracked files not in s are removed, ignored ones
+// kept. Callers take a snapshot first if the current state may be wanted.
+func Restore(ctx context.Context, dir string, s *Snapshot) error {
+	resetTo := []string{"reset", "--quiet", "--hard"}
+	if s.Head != "" {
+		resetTo = append(resetTo, s.Head)
+	}
+	for _, args := range [][]string{resetTo, {"clean", "-d", "--force", "--quiet"}} {
+		if _, err := git(ctx, dir, nil, args...); err != nil {
+			return fmt.Errorf("restore snapshot %d: %w", s.ID, err)
+		}
+	}
+	if err := Apply(ctx, dir, s.Commit); err != nil {
+		return fmt.Errorf("restore snapshot %d: %w", s.ID, err)
+	}
+	return nil
+}
+
+// Apply checks out the files of commit, a snapshot or Capture, in the clean
+// working tree at dir and leaves them as uncommitted changes to HEAD.
+func Apply(ctx context.Context, dir, commit string) error {
+	for _, args := range [][]string{{"read-tree", "-u", "--reset", commit}, {"reset", "--quiet"}} {
+		if _, err := git(ctx, dir, nil, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rename moves the snapshots of oldName to newName.
+func Rename(ctx context.Context, dir, oldName, newName string) error {
+	snaps, err := List(ctx, dir, oldName)
+	if err != nil {
+		return err
+	}
+	for _, s := range snaps {
+		if _, err := git(ctx, dir, nil, "update-ref", Ref(newName, s.ID), s.Commit); err != nil {
+			return fmt.Errorf("rename snapshot %d: %w", s.ID, err)
+		}
+		git(ctx, dir, nil, "update-ref", "-d", s.Ref)
+	}
+	return nil
+}
+
+// Delete removes all snapshots of the named context.
+func Delete(ctx context.Context, dir, name string) error {
+	snaps, err := List(ctx, dir, name)
+	if err != nil {
+		return err
+	}
+	for _, s := range snaps {
+		if _, err := git(ctx, dir, nil, "update-ref", "-d", s.Ref); err != nil {
+			return fmt.Errorf("delete snapshot %d: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+func git(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,142 @@
+package snapshot_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buck3000/wiz/internal/snapshot"
+	"github.com/buck3000/wiz/testutil"
+)
+
+func gitOut(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "<missing>"
+	}
+	return string(data)
+}
+
+func TestTakeListRestore(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+	dir := tr.Dir
+	head := tr.Head()
+	status := gitOut(t, dir, "status", "--porcelain")
+
+	// An uncommitted edit and an untracked file.
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("edited\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes\n"), 0o644)
+
+	s1, err := snapshot.Take(ctx, dir, "feat/a", "good state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s1.ID != 1 || s1.Ref != "refs/wiz/snapshots/feat__a/1" || s1.Head != head {
+		t.Errorf("snapshot = %+v", s1)
+	}
+	// Taking a snapshot leaves the index and branch alone.
+	if tr.Head() != head {
+		t.Error("snapshot moved HEAD")
+	}
+	if got := gitOut(t, dir, "diff", "--cached", "--name-only"); got != "" {
+		t.Errorf("snapshot staged files: %q", got)
+	}
+
+	if s, err := snapshot.TakeIfChanged(ctx, dir, "feat/a", "auto"); err != nil || s != nil {
+		t.Errorf("TakeIfChanged on an unchanged tree = %+v, %v", s, err)
+	}
+
+	// Wreck the working tree and commit part of the damage.
+	os.Remove(filepath.Join(dir, "notes.txt"))
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("wrecked\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "junk.txt"), []byte("junk\n"), 0o644)
+	tr.Commit("bad commit")
+	os.WriteFile(filepath.Join(dir, "stray.txt"), []byte("stray\n"), 0o644)
+
+	s2, err := snapshot.TakeIfChanged(ctx, dir, "feat/a", "")
+	if err != nil || s2 == nil || s2.ID != 2 {
+		t.Fatalf("TakeIfChanged after changes = %+v, %v", s2, err)
+	}
+
+	snaps, err := snapshot.List(ctx, dir, "feat/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 || snaps[0].Message != "good state" || snaps[1].Message != "wiz snapshot" {
+		t.Fatalf("List = %+v", snaps)
+	}
+	if other, _ := snapshot.List(ctx, dir, "feat"); len(other) != 0 {
+		t.Errorf("snapshots of another context listed: %+v", other)
+	}
+
+	got, err := snapshot.Get(ctx, dir, "feat/a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshot.Restore(ctx, dir, got); err != nil {
+		t.Fatal(err)
+	}
+	if tr.Head() != head {
+		t.Error("restore did not reset HEAD to the snapshot's commit")
+	}
+	if got := readFile(t, filepath.Join(dir, "README.md")); got != "edited\n" {
+		t.Errorf("README.md = %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "notes.txt")); got != "notes\n" {
+		t.Errorf("notes.txt = %q", got)
+	}
+	for _, f := range []string{"junk.txt", "stray.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); !os.IsNotExist(err) {
+			t.Errorf("%s should be gone after restore", f)
+		}
+	}
+	// Restored changes are uncommitted again, not staged.
+	want := strings.TrimSpace(status + "\n M README.md\n?? notes.txt")
+	if got := gitOut(t, dir, "status", "--porcelain"); got != want {
+		t.Errorf("status after restore = %q, want %q", got, want)
+	}
+
+	if _, err := snapshot.Get(ctx, dir, "feat/a", 9); err == nil {
+		t.Error("Get of a missing snapshot should fail")
+	}
+}
+
+func TestRenameAndDelete(t *testing.T) {
+	tr := testutil.NewTestRepo(t)
+	ctx := context.Background()
+
+	if _, err := snapshot.Take(ctx, tr.Dir, "old", "one"); err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshot.Rename(ctx, tr.Dir, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if snaps, _ := snapshot.List(ctx, tr.Dir, "old"); len(snaps) != 0 {
+		t.Errorf("old snapshots left: %+v", snaps)
+	}
+	snaps, _ := snapshot.List(ctx, tr.Dir, "new")
+	if len(snaps) != 1 || snaps[0].Message != "one" {
+		t.Fatalf("renamed snapshots = %+v", snaps)
+	}
+
+	if err := snapshot.Delete(ctx, tr.Dir, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if snaps, _ := snapshot.List(ctx, tr.Dir, "new"); len(snaps) != 0 {
+		t.Errorf("snapshots left after delete: %+v", snaps)
+	}
+}
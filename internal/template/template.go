@@ -16,6 +16,10 @@ type Template struct {
 	Base     string `json:"base,omitempty"`
 	Strategy string `json:"strategy,omitempty"`
 	Agent    string `json:"agent,omitempty"`
+	// BranchTemplate overrides the configured branch_template.
+	BranchTemplate string `json:"branch_template,omitempty"`
+	// Checks replace the configured checks for contexts created from this template.
+	Checks []config.CheckConfig `json:"checks,omitempty"`
 }
 
 // Store manages templates on disk.
@@ -11,8 +11,12 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/buck3000/wiz/internal/check"
+	"github.com/buck3000/wiz/internal/conflict"
 	wizctx "github.com/buck3000/wiz/internal/context"
+	"github.com/buck3000/wiz/internal/forge"
 	"github.com/buck3000/wiz/internal/gitx"
+	"github.com/buck3000/wiz/internal/prstatus"
 )
 
 // ContextStatus holds a context plus its live git status.
@@ -21,26 +25,38 @@ type ContextStatus struct {
 	Status     *gitx.RepoStatus
 	Error      error
 	IndexMtime int64
+	// Conflicts names the other contexts this one would conflict with.
+	Conflicts []string
 }
 
 type tickMsg time.Time
 type statusMsg []ContextStatus
+type prTickMsg time.Time
+type prPolledMsg struct{}
 
 // DashboardModel is the Bubble Tea model for the watch dashboard.
 type DashboardModel struct {
 	store    *wizctx.Store
+	forecast *conflict.Forecaster
 	statuses []ContextStatus
 	interval time.Duration
-	width    int
-	height   int
-	quitting bool
+	// forge, if set, is polled for PR and CI status every prInterval.
+	forge      forge.Forge
+	prInterval time.Duration
+	width      int
+	height     int
+	quitting   bool
 }
 
-// NewDashboard creates a new dashboard model.
-func NewDashboard(store *wizctx.Store, interval time.Duration) DashboardModel {
+// NewDashboard creates a new dashboard model. With a non-nil forge, the
+// contexts' PR and CI status is polled every prInterval.
+func NewDashboard(store *wizctx.Store, interval time.Duration, f forge.Forge, prInterval time.Duration) DashboardModel {
 	return DashboardModel{
-		store:    store,
-		interval: interval,
+		store:      store,
+		forecast:   conflict.NewForecaster(store.Repo().WorkDir),
+		interval:   interval,
+		forge:      f,
+		prInterval: prInterval,
 	}
 }
 
@@ -48,9 +64,25 @@ func (m DashboardModel) Init() tea.Cmd {
 	return tea.Batch(
 		m.fetchStatuses(),
 		m.tick(),
+		m.pollPRs(),
 	)
 }
 
+// pollPRs refreshes the PR status recorded on each context; the next
+// status fetch picks it up.
+func (m DashboardModel) pollPRs() tea.Cmd {
+	if m.forge == nil {
+		return nil
+	}
+	store, f := m.store, m.forge
+	return func() tea.Msg {
+		if contexts, err := store.List(); err == nil {
+			prstatus.PollAll(context.Background(), f, store, contexts, 4)
+		}
+		return prPolledMsg{}
+	}
+}
+
 func (m DashboardModel) tick() tea.Cmd {
 	return tea.Tick(m.interval, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -82,12 +114,14 @@ func getIndexMtime(ctxPath string) int64 {
 
 func (m DashboardModel) fetchStatuses() tea.Cmd {
 	store := m.store
+	forecast := m.forecast
 	prev := m.statuses
 	return func() tea.Msg {
 		contexts, err := store.List()
 		if err != nil {
 			return statusMsg(nil)
 		}
+		contexts = wizctx.Active(contexts)
 
 		// Build lookup of previous statuses by name for mtime comparison.
 		prevMap := make(map[string]ContextStatus, len(prev))
@@ -125,6 +159,7 @@ func (m DashboardModel) fetchStatuses() tea.Cmd {
 		}
 		wg.Wait()
 
+		flagConflicts(store.Repo(), forecast, statuses)
 		return statusMsg(statuses)
 	}
 }
@@ -142,6 +177,10 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case tickMsg:
 		return m, tea.Batch(m.fetchStatuses(), m.tick())
+	case prPolledMsg:
+		return m, tea.Tick(m.prInterval, func(t time.Time) tea.Msg { return prTickMsg(t) })
+	case prTickMsg:
+		return m, m.pollPRs()
 	case statusMsg:
 		m.statuses = []ContextStatus(msg)
 	}
@@ -181,14 +220,17 @@ func (m DashboardModel) View() string {
 		b.WriteString("\n")
 	} else {
 		// Header row.
-		b.WriteString(fmt.Sprintf("  %-20s %-10s %-20s %-10s %s\n",
+		b.WriteString(fmt.Sprintf("  %-20s %-10s %-20s %-10s %-12s %-14s %-14s %s\n",
 			headerStyle.Render("CONTEXT"),
 			headerStyle.Render("AGENT"),
 			headerStyle.Render("BRANCH"),
 			headerStyle.Render("STATE"),
+			headerStyle.Render("CHECKS"),
+			headerStyle.Render("CONFLICTS"),
+			headerStyle.Render("PR"),
 			headerStyle.Render("CHANGES"),
 		))
-		b.WriteString(fmt.Sprintf("  %s\n", dimStyle.Render(strings.Repeat("\u2500", 76))))
+		b.WriteString(fmt.Sprintf("  %s\n", dimStyle.Render(strings.Repeat("\u2500", 119))))
 
 		for _, cs := range m.statuses {
 			var stateStr, diffStr string
@@ -236,26 +278,117 @@ func (m DashboardModel) View() string {
 				branch = branch[:17] + "..."
 			}
 
-			b.WriteString(fmt.Sprintf("  %-20s %-10s %-20s %-10s %s\n",
+			b.WriteString(fmt.Sprintf("  %-20s %-10s %-20s %-10s %-12s %-14s %-14s %s\n",
 				cellStyle.Render(name),
 				cellStyle.Render(agentLabel),
 				cellStyle.Render(branch),
 				stateStr,
+				checksCell(cs.Context.Checks),
+				conflictsCell(cs.Conflicts),
+				prCell(cs.Context.PR, cs.Context.PRChecks),
 				dimStyle.Render(diffStr),
 			))
 		}
 	}
 
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("q: quit | refreshing every " + m.interval.String()))
+	help := "q: quit | refreshing every " + m.interval.String()
+	if m.forge != nil {
+		help += ", PRs every " + m.prInterval.String()
+	}
+	b.WriteString(helpStyle.Render(help))
 	b.WriteString("\n")
 
 	return b.String()
 }
 
+// flagConflicts forecasts merges between every pair of contexts and records,
+// on each status, the contexts it would conflict with. Results are cached by
+// commit, so only contexts whose branch moved are merged again.
+func flagConflicts(repo *gitx.Repo, forecast *conflict.Forecaster, statuses []ContextStatus) {
+	ctx := context.Background()
+	current, _ := repo.CurrentBranch(ctx)
+	branches := make([]conflict.Branch, 0, len(statuses))
+	for i := range statuses {
+		c := &statuses[i].Context
+		tip, err := wizctx.Tip(ctx, repo, c)
+		if err != nil {
+			continue
+		}
+		base := c.BaseBranch
+		if base == "" {
+			base = current
+		}
+		branches = append(branches, conflict.Branch{Name: c.Name, Tip: tip, Base: base})
+	}
+	pairs, err := forecast.Forecast(ctx, branches)
+	if err != nil {
+		return
+	}
+	byName := make(map[string]*ContextStatus, len(statuses))
+	for i := range statuses {
+		byName[statuses[i].Context.Name] = &statuses[i]
+	}
+	for _, p := range pairs {
+		if !p.Conflicting() {
+			continue
+		}
+		byName[p.A].Conflicts = append(byName[p.A].Conflicts, p.B)
+		byName[p.B].Conflicts = append(byName[p.B].Conflicts, p.A)
+	}
+}
+
+// conflictsCell renders the contexts a context would conflict with.
+func conflictsCell(names []string) string {
+	if len(names) == 0 {
+		return dimStyle.Render("-")
+	}
+	return errorStyle.Render("\u26a0 " + strings.Join(names, ","))
+}
+
+// prCell renders a context's PR as its number and the most pressing of
+// its CI, review and merge state.
+func prCell(pr *forge.PR, checks []forge.Check) string {
+	if pr == nil {
+		return dimStyle.Render("-")
+	}
+	label := fmt.Sprintf("#%d", pr.Number)
+	if pr.State != forge.StateOpen {
+		return dimStyle.Render(label + " " + pr.State)
+	}
+	ci := forge.ChecksState(checks)
+	switch {
+	case ci == forge.CheckFailure:
+		return errorStyle.Render(label + " \u2717 CI")
+	case pr.Mergeable == forge.Conflicting:
+		return errorStyle.Render(label + " conflict")
+	case pr.Review == forge.ReviewChangesRequested:
+		return dirtyStyle.Render(label + " changes")
+	case ci == forge.CheckPending:
+		return cellStyle.Render(label + " CI\u2026")
+	case pr.Review == forge.ReviewApproved:
+		return cleanStyle.Render(label + " \u2713 approved")
+	case pr.Draft:
+		return dimStyle.Render(label + " draft")
+	}
+	return cellStyle.Render(label + " review")
+}
+
+// checksCell renders a context's latest check results as e.g. "2/3 passed".
+func checksCell(rs []check.Result) string {
+	switch {
+	case len(rs) == 0:
+		return dimStyle.Render("-")
+	case check.Passed(rs):
+		return cleanStyle.Render(check.Summary(rs))
+	default:
+		return errorStyle.Render(check.Summary(rs))
+	}
+}
+
 // RunDashboard launches the dashboard TUI.
-func RunDashboard(store *wizctx.Store, interval time.Duration) error {
-	m := NewDashboard(store, interval)
+func RunDashboard(store *wizctx.Store, interval time.Duration, f forge.Forge, prInterval time.Duration) error {
+	m := NewDashboard(store, interval, f, prInterval)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
@@ -18,12 +18,14 @@ const (
 	ActionSpawn
 	ActionDelete
 	ActionCreate
+	ActionFork
 )
 
 // Result holds the picker outcome.
 type Result struct {
 	Action  Action
 	Context *wizctx.Context
+	Name    string // new context name, for ActionFork
 }
 
 // Model is the Bubble Tea model for the context picker.
@@ -37,6 +39,8 @@ type Model struct {
 	height   int
 	filter   string
 	filtering bool
+	forking  bool   // typing the name of a fork of the selected context
+	forkName string
 }
 
 // NewPicker creates a new picker model.
@@ -59,12 +63,11 @@ func (m *Model) applyFilter() {
 		}
 	} else {
 		m.filtered = m.filtered[:0]
-		lower := strings.ToLower(m.filter)
+		// The filter is a wiz list --filter expression; one that doesn't
+		// parse (e.g. a lone "=") is searched for as is.
+		filter, err := wizctx.ParseFilter(m.filter)
 		for i, c := range m.contexts {
-			if strings.Contains(strings.ToLower(c.Name), lower) ||
-				strings.Contains(strings.ToLower(c.Branch), lower) ||
-				strings.Contains(strings.ToLower(c.Task), lower) ||
-				strings.Contains(strings.ToLower(c.Agent), lower) {
+			if (err == nil && filter.Match(&c)) || (err != nil && c.Matches(m.filter)) {
 				m.filtered = append(m.filtered, i)
 			}
 		}
@@ -86,6 +89,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.forking {
+			switch msg.String() {
+			case "enter":
+				if m.forkName != "" {
+					idx := m.filtered[m.cursor]
+					m.result = Result{Action: ActionFork, Context: &m.contexts[idx], Name: m.forkName}
+					m.quitting = true
+					return m, tea.Quit
+				}
+			case "esc":
+				m.forking = false
+			case "backspace":
+				if len(m.forkName) > 0 {
+					m.forkName = m.forkName[:len(m.forkName)-1]
+				}
+			default:
+				if len(msg.String()) == 1 {
+					m.forkName += msg.String()
+				}
+			}
+			return m, nil
+		}
 		if m.filtering {
 			switch msg.String() {
 			case "enter":
@@ -150,6 +175,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			}
 
+		case "f":
+			if len(m.filtered) > 0 {
+				m.forking = true
+				m.forkName = m.contexts[m.filtered[m.cursor]].Name + "-fork"
+			}
+
 		case "n":
 			m.result = Result{Action: ActionCreate}
 			m.quitting = true
@@ -211,6 +242,9 @@ func (m Model) View() string {
 			if c.Agent != "" {
 				detail += fmt.Sprintf(" | agent: %s", c.Agent)
 			}
+			if len(c.Tags) > 0 {
+				detail += fmt.Sprintf(" | tags: %s", strings.Join(c.Tags, ", "))
+			}
 			b.WriteString(dimStyle.Render(detail))
 			b.WriteString("\n")
 
@@ -225,7 +259,15 @@ func (m Model) View() string {
 		}
 	}
 
-	help := "enter: activate  s: spawn  d: delete  n: new  /: filter  q: quit"
+	if m.forking {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("fork %s as: %s\u2588", m.contexts[m.filtered[m.cursor]].Name, m.forkName)))
+		b.WriteString("\n")
+	}
+
+	help := "enter: activate  s: spawn  f: fork  d: delete  n: new  /: filter  q: quit"
+	if m.forking {
+		help = "enter: fork  esc: cancel"
+	}
 	b.WriteString(helpStyle.Render(help))
 	b.WriteString("\n")
 
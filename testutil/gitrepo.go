@@ -69,6 +69,12 @@ func (r *TestRepo) CurrentBranch() string {
 	return out
 }
 
+// Head returns the commit SHA at HEAD.
+func (r *TestRepo) Head() string {
+	r.t.Helper()
+	return runOutput(r.t, r.Dir, "git", "rev-parse", "HEAD")
+}
+
 func run(t testing.TB, dir string, name string, args ...string) {
 	t.Helper()
 	cmd := exec.Command(name, args...)